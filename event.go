@@ -0,0 +1,81 @@
+package hypher
+
+import (
+	"context"
+	"time"
+)
+
+// EventType is a hypher Run event type.
+type EventType int
+
+const (
+	// RunStarted is emitted when the Run starts.
+	RunStarted EventType = iota
+	// RunFinished is emitted when the Run finishes.
+	RunFinished
+	// NodeScheduled is emitted when the node is scheduled for execution.
+	NodeScheduled
+	// NodeSkipped is emitted for nodes which are not executed
+	// because they are not on the path to any output node.
+	NodeSkipped
+	// NodeStarted is emitted when the node execution starts.
+	NodeStarted
+	// NodeFinished is emitted when the node execution finishes.
+	NodeFinished
+)
+
+// String implements fmt.Stringer.
+func (t EventType) String() string {
+	switch t {
+	case RunStarted:
+		return "RunStarted"
+	case RunFinished:
+		return "RunFinished"
+	case NodeScheduled:
+		return "NodeScheduled"
+	case NodeSkipped:
+		return "NodeSkipped"
+	case NodeStarted:
+		return "NodeStarted"
+	case NodeFinished:
+		return "NodeFinished"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is emitted during a hypher Run.
+type Event struct {
+	// Type is event type.
+	Type EventType
+	// RunID is the ID of the Run.
+	RunID string
+	// Graph is the graph being run.
+	Graph Graph
+	// ConcMode is the Run concurrency mode.
+	ConcMode ConcMode
+	// Node is the node the event is about.
+	// It's nil for Run events.
+	Node Node
+	// Lane is the node execution lane: the graph level
+	// in ConcLevelMode or the goroutine in ConcAllMode.
+	Lane int
+	// Time is the time of the event.
+	Time time.Time
+	// Inputs are node inputs.
+	Inputs []Value
+	// Outputs are node outputs.
+	Outputs []Value
+	// Err is the Run or node execution error.
+	Err error
+	// Usage is the Run or node execution usage.
+	Usage Usage
+}
+
+// Observer observes hypher Runs.
+// Observe may be called concurrently
+// from multiple goroutines during a Run.
+type Observer interface {
+	// Observe is called for every Run event.
+	Observe(ctx context.Context, e Event)
+}
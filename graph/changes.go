@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"slices"
+	"sync"
+)
+
+// Changes are graph changes.
+type Changes struct {
+	// Revision is the graph revision the changes were collected at.
+	Revision uint64
+	// Graph reports whether the graph metadata changed.
+	Graph bool
+	// Nodes are the UIDs of added or modified nodes.
+	Nodes []string
+	// Edges are the UIDs of added or modified edges.
+	Edges []string
+	// RemovedNodes are the UIDs of removed nodes.
+	RemovedNodes []string
+	// RemovedEdges are the UIDs of removed edges.
+	RemovedEdges []string
+}
+
+// IsEmpty returns true if there are no changes.
+func (c Changes) IsEmpty() bool {
+	return !c.Graph &&
+		len(c.Nodes) == 0 &&
+		len(c.Edges) == 0 &&
+		len(c.RemovedNodes) == 0 &&
+		len(c.RemovedEdges) == 0
+}
+
+// changeLog records the revisions graph elements were last changed at.
+// Every recorded change increments the graph revision. Removed elements
+// are kept as tombstones until they're added back to the graph.
+type changeLog struct {
+	rev          uint64
+	graph        uint64
+	nodes        map[string]uint64
+	edges        map[string]uint64
+	removedNodes map[string]uint64
+	removedEdges map[string]uint64
+	mu           sync.Mutex
+}
+
+func newChangeLog() *changeLog {
+	return &changeLog{
+		nodes:        make(map[string]uint64),
+		edges:        make(map[string]uint64),
+		removedNodes: make(map[string]uint64),
+		removedEdges: make(map[string]uint64),
+	}
+}
+
+// revision returns the current revision.
+func (c *changeLog) revision() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rev
+}
+
+// touchGraph records a change of the graph metadata.
+func (c *changeLog) touchGraph() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rev++
+	c.graph = c.rev
+}
+
+// touchNode records an addition or a change of node uid.
+func (c *changeLog) touchNode(uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rev++
+	c.nodes[uid] = c.rev
+	delete(c.removedNodes, uid)
+}
+
+// removeNode records a removal of node uid.
+func (c *changeLog) removeNode(uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rev++
+	c.removedNodes[uid] = c.rev
+	delete(c.nodes, uid)
+}
+
+// touchEdge records an addition or a change of edge uid.
+func (c *changeLog) touchEdge(uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rev++
+	c.edges[uid] = c.rev
+	delete(c.removedEdges, uid)
+}
+
+// removeEdge records a removal of edge uid.
+func (c *changeLog) removeEdge(uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rev++
+	c.removedEdges[uid] = c.rev
+	delete(c.edges, uid)
+}
+
+// since returns the changes recorded after revision rev.
+// Changed element UIDs are sorted.
+func (c *changeLog) since(rev uint64) Changes {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	after := func(m map[string]uint64) []string {
+		var uids []string
+		for uid, r := range m {
+			if r > rev {
+				uids = append(uids, uid)
+			}
+		}
+		slices.Sort(uids)
+		return uids
+	}
+
+	return Changes{
+		Revision:     c.rev,
+		Graph:        c.graph > rev,
+		Nodes:        after(c.nodes),
+		Edges:        after(c.edges),
+		RemovedNodes: after(c.removedNodes),
+		RemovedEdges: after(c.removedEdges),
+	}
+}
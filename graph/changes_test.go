@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+func TestGraphChanges(t *testing.T) {
+	g := MustGraph(t)
+	n1 := MustNode(t, hypher.WithUID("n1"))
+	n2 := MustNode(t, hypher.WithUID("n2"))
+	n3 := MustNode(t, hypher.WithUID("n3"))
+
+	if err := g.AddNode(n1); err != nil {
+		t.Fatalf("failed to add node: %v", err)
+	}
+	if err := g.SetEdge(MustEdge(t, n1, n2, hypher.WithUID("e12"))); err != nil {
+		t.Fatalf("failed to set edge: %v", err)
+	}
+	if err := g.SetEdge(MustEdge(t, n2, n3, hypher.WithUID("e23"))); err != nil {
+		t.Fatalf("failed to set edge: %v", err)
+	}
+
+	changes := g.ChangesSince(0)
+	if changes.Revision != g.Revision() {
+		t.Errorf("expected revision: %d, got: %d", g.Revision(), changes.Revision)
+	}
+	if changes.Graph {
+		t.Error("expected no graph changes")
+	}
+	if want := []string{"n1", "n2", "n3"}; !reflect.DeepEqual(changes.Nodes, want) {
+		t.Errorf("expected nodes: %v, got: %v", want, changes.Nodes)
+	}
+	if want := []string{"e12", "e23"}; !reflect.DeepEqual(changes.Edges, want) {
+		t.Errorf("expected edges: %v, got: %v", want, changes.Edges)
+	}
+
+	rev := g.Revision()
+	if changes := g.ChangesSince(rev); !changes.IsEmpty() {
+		t.Errorf("expected no changes, got: %+v", changes)
+	}
+
+	g.SetLabel("changed")
+	n1.SetLabel("changed")
+	g.Edge(n2.ID(), n3.ID()).(*Edge).SetWeight(2.0)
+
+	changes = g.ChangesSince(rev)
+	if !changes.Graph {
+		t.Error("expected graph changes")
+	}
+	if want := []string{"n1"}; !reflect.DeepEqual(changes.Nodes, want) {
+		t.Errorf("expected nodes: %v, got: %v", want, changes.Nodes)
+	}
+	if want := []string{"e23"}; !reflect.DeepEqual(changes.Edges, want) {
+		t.Errorf("expected edges: %v, got: %v", want, changes.Edges)
+	}
+
+	rev = g.Revision()
+	g.RemoveNode(n2.ID())
+
+	changes = g.ChangesSince(rev)
+	if want := []string{"n2"}; !reflect.DeepEqual(changes.RemovedNodes, want) {
+		t.Errorf("expected removed nodes: %v, got: %v", want, changes.RemovedNodes)
+	}
+	if want := []string{"e12", "e23"}; !reflect.DeepEqual(changes.RemovedEdges, want) {
+		t.Errorf("expected removed edges: %v, got: %v", want, changes.RemovedEdges)
+	}
+	if len(changes.Nodes) != 0 || len(changes.Edges) != 0 {
+		t.Errorf("expected no changed elements, got: %+v", changes)
+	}
+
+	// removed elements are no longer reported as changed
+	if changes := g.ChangesSince(0); !reflect.DeepEqual(changes.Edges, []string(nil)) {
+		t.Errorf("expected no changed edges, got: %v", changes.Edges)
+	}
+}
+
+func TestGraphChangesCycle(t *testing.T) {
+	g := MustGraph(t)
+	n1 := MustNode(t, hypher.WithUID("n1"))
+	n2 := MustNode(t, hypher.WithUID("n2"))
+
+	if err := g.SetEdge(MustEdge(t, n1, n2, hypher.WithUID("e12"))); err != nil {
+		t.Fatalf("failed to set edge: %v", err)
+	}
+
+	rev := g.Revision()
+	if err := g.SetEdge(MustEdge(t, n2, n1, hypher.WithUID("e21"))); err == nil {
+		t.Fatal("expected cycle detection error")
+	}
+
+	if changes := g.ChangesSince(rev); !changes.IsEmpty() {
+		t.Errorf("expected no changes, got: %+v", changes)
+	}
+}
+
+func TestGraphRemoveNode(t *testing.T) {
+	g := MustGraph(t)
+	n1 := MustNode(t, hypher.WithUID("n1"))
+	n2 := MustNode(t, hypher.WithUID("n2"))
+
+	if err := g.SetEdge(MustEdge(t, n1, n2)); err != nil {
+		t.Fatalf("failed to set edge: %v", err)
+	}
+	g.SetInputs([]*Node{n1})
+	g.SetOutputs([]*Node{n2})
+
+	g.RemoveNode(n2.ID())
+
+	if g.Node(n2.ID()) != nil {
+		t.Error("expected node to be removed")
+	}
+	if g.Edges().Len() != 0 {
+		t.Errorf("expected no edges, got: %d", g.Edges().Len())
+	}
+	if len(g.Inputs()) != 1 {
+		t.Errorf("expected 1 input, got: %d", len(g.Inputs()))
+	}
+	if len(g.Outputs()) != 0 {
+		t.Errorf("expected no outputs, got: %d", len(g.Outputs()))
+	}
+}
+
+func TestGraphChangesRoles(t *testing.T) {
+	g := MustGraph(t)
+	n1 := MustNode(t, hypher.WithUID("n1"))
+	n2 := MustNode(t, hypher.WithUID("n2"))
+
+	if err := g.SetEdge(MustEdge(t, n1, n2)); err != nil {
+		t.Fatalf("failed to set edge: %v", err)
+	}
+	g.SetInputs([]*Node{n1})
+
+	rev := g.Revision()
+	g.SetInputs([]*Node{n2})
+
+	// both the former and the new input nodes changed
+	if want := []string{"n1", "n2"}; !reflect.DeepEqual(g.ChangesSince(rev).Nodes, want) {
+		t.Errorf("expected nodes: %v, got: %v", want, g.ChangesSince(rev).Nodes)
+	}
+}
@@ -10,12 +10,14 @@ import (
 // It does not copy node inputs or outputs.
 func NodeDeepCopy(n *Node) *Node {
 	return &Node{
-		id:    n.id,
-		uid:   n.uid,
-		dotid: n.dotid,
-		label: n.label,
-		attrs: maps.Clone(n.attrs),
-		graph: n.graph,
+		id:      n.id,
+		uid:     n.uid,
+		dotid:   n.dotid,
+		label:   n.label,
+		attrs:   maps.Clone(n.attrs),
+		graph:   n.graph,
+		op:      n.op,
+		timeout: n.timeout,
 	}
 }
 
@@ -43,6 +45,11 @@ func DeepCopy(g *Graph) *Graph {
 		label:                 g.label,
 		attrs:                 maps.Clone(g.attrs),
 		nodes:                 maps.Clone(g.nodes),
+		metrics:               g.metrics,
+		logger:                g.logger,
+		logOut:                g.logOut,
+		logLevel:              g.logLevel,
+		changes:               newChangeLog(),
 	}
 
 	inputs := make([]*Node, 0, len(g.inputs))
@@ -27,6 +27,7 @@ type Edge struct {
 	to     hypher.Node
 	weight float64
 	attrs  map[string]any
+	graph  *Graph
 	mu     sync.RWMutex
 }
 
@@ -74,7 +75,11 @@ func (e *Edge) SetUID(uid string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if e.graph != nil {
+		e.graph.changes.removeEdge(e.uid)
+	}
 	e.uid = uid
+	e.touch()
 }
 
 // Label returns edge label.
@@ -91,6 +96,7 @@ func (e *Edge) SetLabel(l string) {
 	defer e.mu.Unlock()
 
 	e.label = l
+	e.touch()
 }
 
 // From returns the from node of the first non-nil edge, or nil.
@@ -123,6 +129,7 @@ func (e *Edge) SetWeight(w float64) {
 	defer e.mu.Unlock()
 
 	e.weight = w
+	e.touch()
 }
 
 // ReversedEdge returns a new edge with end points of the pair swapped.
@@ -141,6 +148,8 @@ func (e *Edge) ReversedEdge() gonum.Edge {
 }
 
 // Attrs returns node attributes.
+// Changes made directly to the returned map
+// are not recorded in the edge graph changes.
 func (e *Edge) Attrs() map[string]any {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -148,6 +157,36 @@ func (e *Edge) Attrs() map[string]any {
 	return e.attrs
 }
 
+// SetAttr sets edge attribute key to val.
+func (e *Edge) SetAttr(key string, val any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.attrs == nil {
+		e.attrs = make(map[string]any)
+	}
+	e.attrs[key] = val
+	e.touch()
+}
+
+// setGraph sets the edge graph unless the edge already belongs to one.
+func (e *Edge) setGraph(g *Graph) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.graph == nil {
+		e.graph = g
+	}
+}
+
+// touch records the change of the edge in its graph.
+// It must be called with the edge lock held.
+func (e *Edge) touch() {
+	if e.graph != nil {
+		e.graph.changes.touchEdge(e.uid)
+	}
+}
+
 // Attributes returns node DOT attributes.
 func (e *Edge) Attributes() []encoding.Attribute {
 	e.mu.RLock()
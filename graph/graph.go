@@ -3,8 +3,10 @@ package graph
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
@@ -14,6 +16,7 @@ import (
 	"gonum.org/v1/gonum/graph/topo"
 
 	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/internal/logging"
 )
 
 const (
@@ -34,6 +37,15 @@ type Graph struct {
 	// input and output nodes
 	inputs  []*Node
 	outputs []*Node
+	// graph metrics
+	metrics hypher.Metrics
+	// graph logger
+	logger *slog.Logger
+	// configured logger and log level
+	logOut   *slog.Logger
+	logLevel slog.Leveler
+	// graph change log
+	changes *changeLog
 	mu      sync.RWMutex
 }
 
@@ -52,6 +64,11 @@ func NewGraph(opts ...hypher.Option) (*Graph, error) {
 		apply(&gopts)
 	}
 
+	metrics := gopts.Metrics
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+
 	return &Graph{
 		WeightedDirectedGraph: simple.NewWeightedDirectedGraph(gopts.Weight, 0.0),
 		uid:                   gopts.UID,
@@ -61,6 +78,11 @@ func NewGraph(opts ...hypher.Option) (*Graph, error) {
 		nodes:                 make(map[string]int64),
 		inputs:                []*Node{},
 		outputs:               []*Node{},
+		metrics:               metrics,
+		logger:                logging.New(gopts.Logger, gopts.LogLevel),
+		logOut:                gopts.Logger,
+		logLevel:              gopts.LogLevel,
+		changes:               newChangeLog(),
 	}, nil
 }
 
@@ -83,6 +105,7 @@ func (g *Graph) SetLabel(l string) {
 	defer g.mu.Unlock()
 
 	g.label = l
+	g.changes.touchGraph()
 }
 
 // SetUID sets UID.
@@ -91,9 +114,12 @@ func (g *Graph) SetUID(uid string) {
 	defer g.mu.Unlock()
 
 	g.uid = uid
+	g.changes.touchGraph()
 }
 
 // Attrs returns graph attributes.
+// Changes made directly to the returned map
+// are not recorded in the graph changes.
 // TODO: consider cloning these
 func (g *Graph) Attrs() map[string]any {
 	g.mu.RLock()
@@ -102,6 +128,30 @@ func (g *Graph) Attrs() map[string]any {
 	return g.attrs
 }
 
+// SetAttr sets graph attribute key to val.
+func (g *Graph) SetAttr(key string, val any) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.attrs == nil {
+		g.attrs = make(map[string]any)
+	}
+	g.attrs[key] = val
+	g.changes.touchGraph()
+}
+
+// Revision returns the graph revision.
+// The revision is incremented on every recorded graph change.
+func (g *Graph) Revision() uint64 {
+	return g.changes.revision()
+}
+
+// ChangesSince returns the graph changes made after revision rev.
+// Elements which were changed and then removed are only reported as removed.
+func (g *Graph) ChangesSince(rev uint64) Changes {
+	return g.changes.since(rev)
+}
+
 // DOTID returns GraphVIz DOT ID.
 func (g *Graph) DOTID() string {
 	g.mu.RLock()
@@ -116,6 +166,7 @@ func (g *Graph) SetDOTID(dotid string) {
 	defer g.mu.Unlock()
 
 	g.dotid = dotid
+	g.changes.touchGraph()
 }
 
 // DOTAttributers are graph.Graph values that specify top-level DOT attributes
@@ -160,6 +211,7 @@ func (g *Graph) SetInputs(nodes []*Node) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	g.touchNodes(g.inputs, nodes)
 	g.inputs = nodes
 }
 
@@ -177,9 +229,20 @@ func (g *Graph) SetOutputs(nodes []*Node) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	g.touchNodes(g.outputs, nodes)
 	g.outputs = nodes
 }
 
+// touchNodes records the changes of the given node sets
+// whose nodes gained or lost their graph input or output role.
+func (g *Graph) touchNodes(sets ...[]*Node) {
+	for _, nodes := range sets {
+		for _, n := range nodes {
+			g.changes.touchNode(n.UID())
+		}
+	}
+}
+
 // Outputs returns graph output nodes.
 // TODO: consider cloning outputs
 func (g *Graph) Outputs() []*Node {
@@ -189,11 +252,75 @@ func (g *Graph) Outputs() []*Node {
 	return g.outputs
 }
 
+// Metrics returns graph metrics.
+func (g *Graph) Metrics() hypher.Metrics {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.metrics
+}
+
+// SetMetrics sets graph metrics.
+// If m is nil, the metrics are disabled.
+func (g *Graph) SetMetrics(m hypher.Metrics) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if m == nil {
+		m = noopMetrics{}
+	}
+	g.metrics = m
+}
+
+// Logger returns graph logger.
+func (g *Graph) Logger() *slog.Logger {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.logger
+}
+
+// SetLogger sets graph logger.
+// The configured log level is kept.
+// If l is nil, the logging is disabled.
+func (g *Graph) SetLogger(l *slog.Logger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logOut = l
+	if l == nil {
+		g.logger = logging.Discard()
+		return
+	}
+	g.logger = logging.New(l, g.logLevel)
+}
+
+// runLogger returns the logger of the run configured with opts.
+// The run Logger and LogLevel override the graph ones.
+func (g *Graph) runLogger(opts hypher.Options) *slog.Logger {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if opts.Logger == nil && opts.LogLevel == nil {
+		return g.logger
+	}
+
+	l, level := g.logOut, g.logLevel
+	if opts.Logger != nil {
+		l = opts.Logger
+	}
+	if opts.LogLevel != nil {
+		level = opts.LogLevel
+	}
+	return logging.New(l, level)
+}
+
 // Reset resets node inputs and outputs.
 func (g *Graph) Reset() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	g.touchNodes(g.inputs, g.outputs)
 	g.inputs = []*Node{}
 	g.outputs = []*Node{}
 }
@@ -245,10 +372,88 @@ func (g *Graph) AddNode(n *Node) error {
 	n.graph = g
 	g.WeightedDirectedGraph.AddNode(n)
 	g.nodes[n.UID()] = n.ID()
+	g.changes.touchNode(n.UID())
 
 	return nil
 }
 
+// RemoveNode removes the node with the given id from the graph
+// along with all the edges linked to it. The node is also removed
+// from the graph inputs and outputs.
+func (g *Graph) RemoveNode(id int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	node, ok := g.Node(id).(*Node)
+	if !ok {
+		g.WeightedDirectedGraph.RemoveNode(id)
+		return
+	}
+
+	to := g.WeightedDirectedGraph.From(id)
+	for to.Next() {
+		g.removedEdge(g.Edge(id, to.Node().ID()))
+	}
+	from := g.WeightedDirectedGraph.To(id)
+	for from.Next() {
+		g.removedEdge(g.Edge(from.Node().ID(), id))
+	}
+
+	g.WeightedDirectedGraph.RemoveNode(id)
+	delete(g.nodes, node.UID())
+	g.inputs = removeNode(g.inputs, id)
+	g.outputs = removeNode(g.outputs, id)
+	g.changes.removeNode(node.UID())
+}
+
+// RemoveEdge removes the edge between the nodes with the given IDs.
+func (g *Graph) RemoveEdge(fid, tid int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.removedEdge(g.Edge(fid, tid))
+	g.WeightedDirectedGraph.RemoveEdge(fid, tid)
+}
+
+// removedEdge records the removal of edge e.
+func (g *Graph) removedEdge(e gonum.Edge) {
+	if edge, ok := e.(*Edge); ok {
+		g.changes.removeEdge(edge.UID())
+	}
+}
+
+// removeNode returns nodes without the node with the given id.
+func removeNode(nodes []*Node, id int64) []*Node {
+	res := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.ID() != id {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// SetWeightedEdge adds the weighted edge e to the graph without checking
+// for graph cycles. Edge nodes which are not in the graph are added to it.
+// Use SetEdge to add edges which must keep the graph acyclic.
+func (g *Graph) SetWeightedEdge(e gonum.WeightedEdge) {
+	g.WeightedDirectedGraph.SetWeightedEdge(e)
+	g.addedEdge(e)
+}
+
+// addedEdge records the addition of edge e and its nodes.
+func (g *Graph) addedEdge(e gonum.Edge) {
+	for _, n := range []gonum.Node{e.From(), e.To()} {
+		if node, ok := n.(*Node); ok {
+			g.changes.touchNode(node.UID())
+		}
+	}
+	if edge, ok := e.(*Edge); ok {
+		edge.setGraph(g)
+		g.changes.touchEdge(edge.UID())
+	}
+}
+
 // NewEdge creates a new edge link its node in the graph.
 // It returns the new edge or fails with error.
 func (g *Graph) NewEdge(from, to hypher.Node, opts ...hypher.Option) (*Edge, error) {
@@ -298,41 +503,51 @@ func (g *Graph) SetEdge(e hypher.Edge) error {
 		g.nodes[toNode.UID()] = toNode.ID()
 		toAdded = true
 	}
-	g.SetWeightedEdge(e)
+	g.WeightedDirectedGraph.SetWeightedEdge(e)
 
 	// check if there is a cycle
 	if topo.PathExistsIn(g, g.Node(e.To().ID()), g.Node(e.From().ID())) {
 		// remove the edge and the nodes that have just been created
-		g.RemoveEdge(fromNode.ID(), toNode.ID())
+		g.WeightedDirectedGraph.RemoveEdge(fromNode.ID(), toNode.ID())
 		// remove nodes if they had been created
 		// and reset their IDs and graphs
 		if fromAdded {
-			g.RemoveNode(fromNode.ID())
+			g.WeightedDirectedGraph.RemoveNode(fromNode.ID())
 			fromNode.id = fromNodeID
 			fromNode.graph = fromNodeGraph
 			delete(g.nodes, fromNode.uid)
 		}
 		if toAdded {
-			g.RemoveNode(toNode.ID())
+			g.WeightedDirectedGraph.RemoveNode(toNode.ID())
 			toNode.id = toNodeID
 			toNode.graph = toNodeGraph
 			delete(g.nodes, toNode.uid)
 		}
 		return fmt.Errorf("cycle detected when adding edge: %s", e)
 	}
+	g.addedEdge(e)
 
 	return nil
 }
 
+// addSubNode adds n to sub-graph g.
+// Unlike AddNode it neither changes the ID of n
+// nor the graph n belongs to.
+func (g *Graph) addSubNode(n *Node) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.WeightedDirectedGraph.AddNode(n)
+	g.nodes[n.UID()] = n.ID()
+}
+
 func (g *Graph) buildSubGraph(sg *Graph, n *Node, outputNodes map[int64]struct{}) (bool, error) {
 	if sg.Node(n.ID()) != nil {
 		return true, nil
 	}
 
 	if _, isOutput := outputNodes[n.ID()]; isOutput {
-		if err := sg.AddNode(n); err != nil {
-			return false, err
-		}
+		sg.addSubNode(n)
 		return true, nil
 	}
 
@@ -347,14 +562,10 @@ func (g *Graph) buildSubGraph(sg *Graph, n *Node, outputNodes map[int64]struct{}
 		if succInPathToOut {
 			nodeInPathToOut = true
 			if sg.Node(n.ID()) == nil {
-				if err := sg.AddNode(n); err != nil {
-					return false, err
-				}
+				sg.addSubNode(n)
 			}
 			if sg.Node(succNode.ID()) == nil {
-				if err := sg.AddNode(succNode); err != nil {
-					return false, err
-				}
+				sg.addSubNode(succNode)
 			}
 			edge := g.Edge(n.ID(), succNode.ID()).(*Edge)
 			if err := sg.SetEdge(edge); err != nil {
@@ -369,6 +580,7 @@ func (g *Graph) buildSubGraph(sg *Graph, n *Node, outputNodes map[int64]struct{}
 // SubGraph returns a sub-graph of g which contains all the nodes
 // which are either outputNodes or are on the path to the outputNodes
 // when starting the graph traversal in inputNodes, including the inputNodes.
+// The nodes of the sub-graph remain associated with g.
 func (g *Graph) SubGraph(inputNodes, outputNodes Nodes) (*Graph, error) {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -451,11 +663,14 @@ func (g *Graph) TopoSortWithLevels() ([][]gonum.Node, error) {
 	return components, nil
 }
 
-func (g *Graph) execNodeWait(ctx context.Context, node *Node, nodeChans map[int64]chan struct{}) error {
+func (g *Graph) execNodeWait(ctx context.Context, r *run, node *Node, lane int, nodeChans map[int64]chan struct{}) error {
 	// TODO: handle the case where we might not want to
 	// aggregate all the predecessor outputs into Exec input;
 	// We might want to do an exec for each predecessor output
-	var nodeExecInputs []hypher.Value
+	var (
+		nodeExecInputs []hypher.Value
+		preds          []*Node
+	)
 	to := g.To(node.ID())
 	for to.Next() {
 		pred := to.Node()
@@ -465,6 +680,7 @@ func (g *Graph) execNodeWait(ctx context.Context, node *Node, nodeChans map[int6
 		case <-nodeChans[pred.ID()]: // Wait for the predecessor to finish
 			predOutputs := pred.(*Node).Outputs()
 			nodeExecInputs = append(nodeExecInputs, predOutputs...)
+			preds = append(preds, pred.(*Node))
 		}
 	}
 
@@ -472,7 +688,7 @@ func (g *Graph) execNodeWait(ctx context.Context, node *Node, nodeChans map[int6
 	// TODO: Exec should have two modes
 	// * combined (all inputs are combined for a single exec)
 	// * oneshot (exec is run for every input separately)
-	if _, err := node.Exec(ctx, nodeExecInputs...); err != nil {
+	if err := r.exec(ctx, node, lane, preds, nodeExecInputs); err != nil {
 		return err
 	}
 
@@ -482,13 +698,7 @@ func (g *Graph) execNodeWait(ctx context.Context, node *Node, nodeChans map[int6
 	return nil
 }
 
-func (g *Graph) runAll(ctx context.Context) error {
-	// get the execution (sub)graph
-	sg, err := g.SubGraph(g.inputs, g.outputs)
-	if err != nil {
-		return err
-	}
-
+func (g *Graph) runAll(ctx context.Context, r *run, sg *Graph) error {
 	// sort the returned graph topologically
 	nodes, err := sg.TopoSort()
 	if err != nil {
@@ -504,11 +714,12 @@ func (g *Graph) runAll(ctx context.Context) error {
 	}
 
 	// Start a goroutine for each node
-	for _, node := range nodes {
+	for i, node := range nodes {
 		// NOTE: we could also just pass the node UID
-		node := node
+		i, node := i, node
+		r.schedule(ctx, node.(*Node), i)
 		eg.Go(func() error {
-			return g.execNodeWait(egCtx, node.(*Node), nodeChans)
+			return g.execNodeWait(egCtx, r, node.(*Node), i, nodeChans)
 		})
 	}
 
@@ -520,43 +731,42 @@ func (g *Graph) runAll(ctx context.Context) error {
 	return nil
 }
 
-func (g *Graph) execNode(ctx context.Context, node *Node) error {
-	var nodeExecInputs []hypher.Value
+func (g *Graph) execNode(ctx context.Context, r *run, node *Node, lane int) error {
+	var (
+		nodeExecInputs []hypher.Value
+		preds          []*Node
+	)
 	to := g.To(node.ID())
 
 	for to.Next() {
 		pred := to.Node()
 		predOutputs := pred.(*Node).Outputs()
 		nodeExecInputs = append(nodeExecInputs, predOutputs...)
+		preds = append(preds, pred.(*Node))
 	}
 
 	// exec the node
-	if _, err := node.Exec(ctx, nodeExecInputs...); err != nil {
+	if err := r.exec(ctx, node, lane, preds, nodeExecInputs); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (g *Graph) run(ctx context.Context) error {
-	// get the execution (sub)graph
-	sg, err := g.SubGraph(g.inputs, g.outputs)
-	if err != nil {
-		return err
-	}
-
+func (g *Graph) run(ctx context.Context, r *run, sg *Graph) error {
 	nodeLevels, err := sg.TopoSortWithLevels()
 	if err != nil {
 		return err
 	}
 
-	for _, nodes := range nodeLevels {
+	for level, nodes := range nodeLevels {
 		// run all nodes on the same level in parallel
 		eg, egCtx := errgroup.WithContext(ctx)
 		for _, node := range nodes {
 			node := node
+			r.schedule(ctx, node.(*Node), level)
 			eg.Go(func() error {
-				return g.execNode(egCtx, node.(*Node))
+				return g.execNode(egCtx, r, node.(*Node), level)
 			})
 		}
 		if err := eg.Wait(); err != nil {
@@ -573,8 +783,17 @@ func (g *Graph) run(ctx context.Context) error {
 // passed via options. Run is a blocking call.
 // It returns when the execution finished or
 // if any of the executed nodes failed with error.
+// If a Tracer is passed via options, the run is traced
+// in a single span and every node Exec in its own child span.
+// Run events are sent to the Observers passed via options.
+// Run metrics are recorded in the graph Metrics and run events
+// are logged by the graph Logger unless Logger or LogLevel
+// are passed via options.
+// Ops report their usage via hypher.RecordUsage. If the usage
+// exceeds the Budget passed via options, the run is canceled
+// and fails with *hypher.ErrBudgetExceeded.
 func (g *Graph) Run(ctx context.Context, inputs map[string]hypher.Value, opts ...hypher.Option) error {
-	// NOTE: we only read Parallel option.
+	// NOTE: we only read run options.
 	gopts := hypher.Options{}
 	for _, apply := range opts {
 		apply(&gopts)
@@ -589,11 +808,66 @@ func (g *Graph) Run(ctx context.Context, inputs map[string]hypher.Value, opts ..
 		}
 	}
 
-	if gopts.ConcMode == hypher.ConcAllMode {
-		return g.runAll(ctx)
+	r := newRun(g, gopts)
+	r.metrics.RunStarted()
+
+	ctx, span := r.tracer.Start(ctx, RunSpanName)
+	defer span.End()
+
+	span.SetAttrs(map[string]any{
+		AttrGraphUID:   g.UID(),
+		AttrGraphLabel: g.Label(),
+		AttrConcMode:   gopts.ConcMode.String(),
+	})
+
+	r.emit(ctx, hypher.Event{Type: hypher.RunStarted})
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// cancel the run when its budget is exceeded
+	go func() {
+		select {
+		case <-r.meter.Exceeded():
+			cancel(r.meter.Err())
+		case <-runCtx.Done():
+		}
+	}()
+
+	err := g.runWith(runCtx, r)
+	if berr := r.meter.Err(); berr != nil {
+		err = fmt.Errorf("graph run failed: %w", berr)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	r.emit(ctx, hypher.Event{Type: hypher.RunFinished, Err: err, Usage: r.meter.Usage()})
+	r.metrics.RunFinished(time.Since(r.start), err)
+
+	return err
+}
+
+// runWith runs the execution subgraph of g in run r.
+func (g *Graph) runWith(ctx context.Context, r *run) error {
+	// get the execution (sub)graph
+	sg, err := g.SubGraph(g.inputs, g.outputs)
+	if err != nil {
+		return err
+	}
+
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if node := nodes.Node(); sg.Node(node.ID()) == nil {
+			r.skip(ctx, node.(*Node))
+		}
+	}
+
+	if r.mode == hypher.ConcAllMode {
+		return g.runAll(ctx, r, sg)
 	}
 
-	return g.run(ctx)
+	return g.run(ctx, r, sg)
 }
 
 // String implements fmt.Stringer.
@@ -217,6 +217,9 @@ func TestSubGraph(t *testing.T) {
 		if !expectedNodes[nodeID] {
 			t.Errorf("Unexpected node %d found in the subgraph", nodeID)
 		}
+		if n := subgraph.Node(nodeID).(*Node); n.Graph() != g {
+			t.Errorf("Node %d must remain associated with the graph", nodeID)
+		}
 	}
 
 	// Check if all expected edges are in the subgraph
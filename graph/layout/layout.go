@@ -0,0 +1,386 @@
+// Package layout computes layered layouts of hypher graphs.
+package layout
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/milosgajdos/go-hypher/graph"
+	gonum "gonum.org/v1/gonum/graph"
+)
+
+// Point is a point in the layout plane.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Layout is a graph layout.
+// The layout flows from top to bottom: the roots of
+// the graph are placed in the top layer and every edge
+// points down to a layer below its source node.
+type Layout struct {
+	// Width is the layout width.
+	Width float64
+	// Height is the layout height.
+	Height float64
+	// NodeWidth is the width of the nodes.
+	NodeWidth float64
+	// NodeHeight is the height of the nodes.
+	NodeHeight float64
+	// Layers are the UIDs of the nodes in each layer ordered from left to right.
+	Layers [][]string
+	// Nodes are the node centers indexed by node UID.
+	Nodes map[string]Point
+	// Edges are the edge polylines indexed by edge UID.
+	// Polylines start in the source node center, end in
+	// the target node center and bend in every layer the
+	// edge passes through.
+	Edges map[string][]Point
+}
+
+// vertex is a layout vertex.
+// Dummy vertices route edges which span multiple layers.
+type vertex struct {
+	node  *graph.Node
+	layer int
+	pos   int
+	x     float64
+	up    []*vertex
+	down  []*vertex
+}
+
+func (v *vertex) dummy() bool {
+	return v.node == nil
+}
+
+// Sugiyama computes Sugiyama style layered layouts.
+type Sugiyama struct {
+	opts Options
+}
+
+// NewSugiyama creates a new Sugiyama layout and returns it.
+func NewSugiyama(opts ...Option) (*Sugiyama, error) {
+	lopts := Options{
+		NodeWidth:  DefaultNodeWidth,
+		NodeHeight: DefaultNodeHeight,
+		NodeSep:    DefaultNodeSep,
+		LayerSep:   DefaultLayerSep,
+		Margin:     DefaultMargin,
+		Sweeps:     DefaultSweeps,
+	}
+
+	for _, apply := range opts {
+		apply(&lopts)
+	}
+
+	if lopts.NodeWidth <= 0 || lopts.NodeHeight <= 0 {
+		return nil, fmt.Errorf("invalid node size: %gx%g", lopts.NodeWidth, lopts.NodeHeight)
+	}
+	if lopts.NodeSep < 0 || lopts.LayerSep < 0 || lopts.Margin < 0 {
+		return nil, errors.New("invalid negative spacing")
+	}
+	if lopts.Sweeps < 0 {
+		return nil, fmt.Errorf("invalid sweeps: %d", lopts.Sweeps)
+	}
+
+	return &Sugiyama{
+		opts: lopts,
+	}, nil
+}
+
+// Layout computes the layout of g.
+// Nodes are assigned to the layers returned by g.TopoSortWithLevels.
+// Edges which span multiple layers are routed through dummy vertices.
+// Node crossings are then minimised by barycenter sweeps alternating
+// between down and up the layers and the node coordinates are placed
+// close to the barycenters of their neighbours.
+func (s *Sugiyama) Layout(g *graph.Graph) (*Layout, error) {
+	levels, err := g.TopoSortWithLevels()
+	if err != nil {
+		return nil, fmt.Errorf("layering: %w", err)
+	}
+
+	layers, edges := s.layers(g, levels)
+	s.order(layers)
+	s.place(layers)
+
+	return s.layout(layers, edges), nil
+}
+
+// layers creates layout layers of vertices from graph levels.
+// Edges spanning multiple layers are split by dummy vertices.
+// It returns the layers and the vertex chains of graph edges.
+func (s *Sugiyama) layers(g *graph.Graph, levels [][]gonum.Node) ([][]*vertex, map[*graph.Edge][]*vertex) {
+	var layers [][]*vertex
+	vertices := make(map[int64]*vertex)
+
+	for _, level := range levels {
+		if len(level) == 0 {
+			continue
+		}
+		nodes := make([]*graph.Node, 0, len(level))
+		for _, n := range level {
+			nodes = append(nodes, n.(*graph.Node))
+		}
+		slices.SortFunc(nodes, func(a, b *graph.Node) int { return cmp.Compare(a.ID(), b.ID()) })
+
+		layer := make([]*vertex, 0, len(nodes))
+		for _, n := range nodes {
+			v := &vertex{node: n, layer: len(layers), pos: len(layer)}
+			vertices[n.ID()] = v
+			layer = append(layer, v)
+		}
+		layers = append(layers, layer)
+	}
+
+	var ge []*graph.Edge
+	it := g.Edges()
+	for it.Next() {
+		ge = append(ge, it.Edge().(*graph.Edge))
+	}
+	slices.SortFunc(ge, func(a, b *graph.Edge) int {
+		return cmp.Or(cmp.Compare(a.From().ID(), b.From().ID()), cmp.Compare(a.To().ID(), b.To().ID()))
+	})
+
+	chains := make(map[*graph.Edge][]*vertex, len(ge))
+	for _, e := range ge {
+		from, to := vertices[e.From().ID()], vertices[e.To().ID()]
+
+		chain := []*vertex{from}
+		for l := from.layer + 1; l < to.layer; l++ {
+			d := &vertex{layer: l, pos: len(layers[l])}
+			layers[l] = append(layers[l], d)
+			chain = append(chain, d)
+		}
+		chain = append(chain, to)
+
+		for i := 1; i < len(chain); i++ {
+			chain[i-1].down = append(chain[i-1].down, chain[i])
+			chain[i].up = append(chain[i].up, chain[i-1])
+		}
+		chains[e] = chain
+	}
+
+	return layers, chains
+}
+
+// order minimises edge crossings by barycenter sweeps.
+// The vertex order with the fewest crossings is kept.
+func (s *Sugiyama) order(layers [][]*vertex) {
+	best := snapshot(layers)
+	bestCrossings := crossings(layers)
+
+	for i := 0; i < s.opts.Sweeps && bestCrossings > 0; i++ {
+		if i%2 == 0 {
+			for l := 1; l < len(layers); l++ {
+				sortByBarycenter(layers[l], func(v *vertex) []*vertex { return v.up })
+			}
+		} else {
+			for l := len(layers) - 2; l >= 0; l-- {
+				sortByBarycenter(layers[l], func(v *vertex) []*vertex { return v.down })
+			}
+		}
+
+		if c := crossings(layers); c < bestCrossings {
+			best, bestCrossings = snapshot(layers), c
+		}
+	}
+
+	for l := range layers {
+		copy(layers[l], best[l])
+		for i, v := range layers[l] {
+			v.pos = i
+		}
+	}
+}
+
+// place assigns x coordinates to vertices.
+// Vertices are pulled towards the barycenters of their neighbours
+// in alternating down and up passes while keeping their order and
+// minimum separation within the layer.
+func (s *Sugiyama) place(layers [][]*vertex) {
+	for _, layer := range layers {
+		x := 0.0
+		for i, v := range layer {
+			if i > 0 {
+				x += s.gap(layer[i-1], v)
+			}
+			v.x = x
+		}
+	}
+
+	for i := 0; i < 2*len(layers); i++ {
+		neighbours := func(v *vertex) []*vertex { return v.up }
+		if i%2 == 1 {
+			neighbours = func(v *vertex) []*vertex { return v.down }
+		}
+		for _, layer := range layers {
+			desired := make([]float64, len(layer))
+			for j, v := range layer {
+				desired[j] = v.x
+				if ns := neighbours(v); len(ns) > 0 {
+					sum := 0.0
+					for _, n := range ns {
+						sum += n.x
+					}
+					desired[j] = sum / float64(len(ns))
+				}
+			}
+			s.pack(layer, desired)
+		}
+	}
+}
+
+// pack places the layer vertices as close to the desired coordinates
+// as possible while keeping their order and minimum separation.
+// It averages the leftmost and the rightmost packing which are both
+// feasible, so their average is feasible, too.
+func (s *Sugiyama) pack(layer []*vertex, desired []float64) {
+	n := len(layer)
+	left := make([]float64, n)
+	right := make([]float64, n)
+
+	for i := 0; i < n; i++ {
+		left[i] = desired[i]
+		if i > 0 {
+			left[i] = math.Max(left[i], left[i-1]+s.gap(layer[i-1], layer[i]))
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		right[i] = desired[i]
+		if i < n-1 {
+			right[i] = math.Min(right[i], right[i+1]-s.gap(layer[i], layer[i+1]))
+		}
+	}
+
+	for i, v := range layer {
+		v.x = (left[i] + right[i]) / 2
+	}
+}
+
+// gap returns the minimum distance between the centers of adjacent vertices.
+func (s *Sugiyama) gap(a, b *vertex) float64 {
+	return (s.width(a)+s.width(b))/2 + s.opts.NodeSep
+}
+
+// width returns the width of vertex v.
+// Dummy vertices have no width.
+func (s *Sugiyama) width(v *vertex) float64 {
+	if v.dummy() {
+		return 0
+	}
+	return s.opts.NodeWidth
+}
+
+// layout translates the placed vertices into Layout.
+func (s *Sugiyama) layout(layers [][]*vertex, chains map[*graph.Edge][]*vertex) *Layout {
+	l := &Layout{
+		NodeWidth:  s.opts.NodeWidth,
+		NodeHeight: s.opts.NodeHeight,
+		Layers:     make([][]string, 0, len(layers)),
+		Nodes:      make(map[string]Point),
+		Edges:      make(map[string][]Point, len(chains)),
+	}
+
+	if len(layers) == 0 {
+		return l
+	}
+
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, layer := range layers {
+		for _, v := range layer {
+			minX = math.Min(minX, v.x-s.width(v)/2)
+			maxX = math.Max(maxX, v.x+s.width(v)/2)
+		}
+	}
+
+	point := func(v *vertex) Point {
+		return Point{
+			X: v.x - minX + s.opts.Margin,
+			Y: s.opts.Margin + s.opts.NodeHeight/2 + float64(v.layer)*(s.opts.NodeHeight+s.opts.LayerSep),
+		}
+	}
+
+	for _, layer := range layers {
+		var uids []string
+		for _, v := range layer {
+			if v.dummy() {
+				continue
+			}
+			uids = append(uids, v.node.UID())
+			l.Nodes[v.node.UID()] = point(v)
+		}
+		l.Layers = append(l.Layers, uids)
+	}
+
+	for e, chain := range chains {
+		points := make([]Point, 0, len(chain))
+		for _, v := range chain {
+			points = append(points, point(v))
+		}
+		l.Edges[e.UID()] = points
+	}
+
+	n := float64(len(layers))
+	l.Width = maxX - minX + 2*s.opts.Margin
+	l.Height = n*s.opts.NodeHeight + (n-1)*s.opts.LayerSep + 2*s.opts.Margin
+
+	return l
+}
+
+// sortByBarycenter sorts layer vertices by the mean position of their neighbours.
+// Vertices without neighbours keep their current position.
+func sortByBarycenter(layer []*vertex, neighbours func(*vertex) []*vertex) {
+	bary := make(map[*vertex]float64, len(layer))
+	for _, v := range layer {
+		ns := neighbours(v)
+		if len(ns) == 0 {
+			bary[v] = float64(v.pos)
+			continue
+		}
+		sum := 0
+		for _, n := range ns {
+			sum += n.pos
+		}
+		bary[v] = float64(sum) / float64(len(ns))
+	}
+
+	slices.SortStableFunc(layer, func(a, b *vertex) int { return cmp.Compare(bary[a], bary[b]) })
+	for i, v := range layer {
+		v.pos = i
+	}
+}
+
+// crossings returns the number of edge crossings between all adjacent layers.
+func crossings(layers [][]*vertex) int {
+	count := 0
+	for l := 0; l < len(layers)-1; l++ {
+		var segs [][2]int
+		for _, v := range layers[l] {
+			for _, d := range v.down {
+				segs = append(segs, [2]int{v.pos, d.pos})
+			}
+		}
+		for i := range segs {
+			for j := i + 1; j < len(segs); j++ {
+				if (segs[i][0]-segs[j][0])*(segs[i][1]-segs[j][1]) < 0 {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// snapshot returns a copy of the vertex order of layers.
+func snapshot(layers [][]*vertex) [][]*vertex {
+	s := make([][]*vertex, len(layers))
+	for i, layer := range layers {
+		s[i] = slices.Clone(layer)
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package layout
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func MustGraph(t *testing.T, edges ...[2]string) *graph.Graph {
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+
+	// nodes are created in the order of their UIDs
+	var uids []string
+	for _, e := range edges {
+		uids = append(uids, e[0], e[1])
+	}
+	slices.Sort(uids)
+
+	nodes := make(map[string]*graph.Node)
+	for _, uid := range slices.Compact(uids) {
+		n, err := g.NewNode(hypher.WithUID(uid))
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		nodes[uid] = n
+	}
+
+	for _, e := range edges {
+		from, to := nodes[e[0]], nodes[e[1]]
+		edge, err := g.NewEdge(from, to, hypher.WithUID(e[0]+"-"+e[1]))
+		if err != nil {
+			t.Fatalf("failed to create edge: %v", err)
+		}
+		if err := g.SetEdge(edge); err != nil {
+			t.Fatalf("failed to set edge: %v", err)
+		}
+	}
+
+	return g
+}
+
+func MustSugiyama(t *testing.T, opts ...Option) *Sugiyama {
+	s, err := NewSugiyama(opts...)
+	if err != nil {
+		t.Fatalf("failed to create layout: %v", err)
+	}
+	return s
+}
+
+func TestLayoutLayers(t *testing.T) {
+	// c and d are initially ordered by their IDs
+	// which makes the edges from a and b cross.
+	g := MustGraph(t, [2]string{"a", "d"}, [2]string{"b", "c"}, [2]string{"d", "e"}, [2]string{"c", "e"})
+
+	l, err := MustSugiyama(t).Layout(g)
+	if err != nil {
+		t.Fatalf("failed to compute layout: %v", err)
+	}
+
+	want := [][]string{{"a", "b"}, {"d", "c"}, {"e"}}
+	if !reflect.DeepEqual(l.Layers, want) {
+		t.Errorf("expected layers: %v, got: %v", want, l.Layers)
+	}
+
+	for _, layer := range l.Layers {
+		for i := 1; i < len(layer); i++ {
+			prev, cur := l.Nodes[layer[i-1]], l.Nodes[layer[i]]
+			if cur.X-prev.X < DefaultNodeWidth+DefaultNodeSep {
+				t.Errorf("nodes %s and %s overlap: %v, %v", layer[i-1], layer[i], prev, cur)
+			}
+			if cur.Y != prev.Y {
+				t.Errorf("nodes %s and %s in different rows: %v, %v", layer[i-1], layer[i], prev, cur)
+			}
+		}
+	}
+
+	if a, d := l.Nodes["a"], l.Nodes["d"]; d.Y-a.Y != DefaultNodeHeight+DefaultLayerSep {
+		t.Errorf("unexpected layer distance: %f", d.Y-a.Y)
+	}
+
+	// e is placed between its predecessors
+	if e, c, d := l.Nodes["e"], l.Nodes["c"], l.Nodes["d"]; e.X != (c.X+d.X)/2 {
+		t.Errorf("expected e centered at %f, got: %f", (c.X+d.X)/2, e.X)
+	}
+
+	if l.Width != 2*DefaultNodeWidth+DefaultNodeSep+2*DefaultMargin {
+		t.Errorf("unexpected width: %f", l.Width)
+	}
+	if l.Height != 3*DefaultNodeHeight+2*DefaultLayerSep+2*DefaultMargin {
+		t.Errorf("unexpected height: %f", l.Height)
+	}
+}
+
+func TestLayoutEdges(t *testing.T) {
+	g := MustGraph(t, [2]string{"a", "b"}, [2]string{"b", "c"}, [2]string{"a", "c"})
+
+	l, err := MustSugiyama(t, WithNodeSize(80, 20), WithMargin(0)).Layout(g)
+	if err != nil {
+		t.Fatalf("failed to compute layout: %v", err)
+	}
+
+	if points := l.Edges["a-b"]; len(points) != 2 || points[0] != l.Nodes["a"] || points[1] != l.Nodes["b"] {
+		t.Errorf("unexpected edge a-b points: %v", points)
+	}
+
+	points := l.Edges["a-c"]
+	if len(points) != 3 {
+		t.Fatalf("expected 3 edge a-c points, got: %v", points)
+	}
+	if bend := points[1]; bend.Y != l.Nodes["b"].Y || bend.X == l.Nodes["b"].X {
+		t.Errorf("expected edge a-c to bend beside node b, got: %v", bend)
+	}
+	if l.Nodes["a"].Y != 10 {
+		t.Errorf("expected node a at y: 10, got: %f", l.Nodes["a"].Y)
+	}
+}
+
+func TestLayoutEmpty(t *testing.T) {
+	l, err := MustSugiyama(t).Layout(MustGraph(t))
+	if err != nil {
+		t.Fatalf("failed to compute layout: %v", err)
+	}
+	if len(l.Nodes) != 0 || len(l.Layers) != 0 || l.Width != 0 || l.Height != 0 {
+		t.Errorf("expected empty layout, got: %+v", l)
+	}
+}
+
+func TestNewSugiyamaErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{"node size", []Option{WithNodeSize(0, 10)}},
+		{"spacing", []Option{WithNodeSep(-1)}},
+		{"sweeps", []Option{WithSweeps(-1)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewSugiyama(tc.opts...); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package layout
+
+const (
+	// DefaultNodeWidth is default node width.
+	DefaultNodeWidth = 120.0
+	// DefaultNodeHeight is default node height.
+	DefaultNodeHeight = 40.0
+	// DefaultNodeSep is default horizontal space between nodes.
+	DefaultNodeSep = 40.0
+	// DefaultLayerSep is default vertical space between layers.
+	DefaultLayerSep = 60.0
+	// DefaultMargin is default layout margin.
+	DefaultMargin = 20.0
+	// DefaultSweeps is default number of crossing minimisation sweeps.
+	DefaultSweeps = 8
+)
+
+// Options configure layout.
+type Options struct {
+	// NodeWidth configures node width.
+	NodeWidth float64
+	// NodeHeight configures node height.
+	NodeHeight float64
+	// NodeSep configures horizontal space between nodes.
+	NodeSep float64
+	// LayerSep configures vertical space between layers.
+	LayerSep float64
+	// Margin configures layout margin.
+	Margin float64
+	// Sweeps configures the number of crossing minimisation sweeps.
+	Sweeps int
+}
+
+// Option is functional layout option.
+type Option func(*Options)
+
+// WithNodeSize sets node width and height.
+func WithNodeSize(width, height float64) Option {
+	return func(o *Options) {
+		o.NodeWidth = width
+		o.NodeHeight = height
+	}
+}
+
+// WithNodeSep sets horizontal space between nodes.
+func WithNodeSep(sep float64) Option {
+	return func(o *Options) {
+		o.NodeSep = sep
+	}
+}
+
+// WithLayerSep sets vertical space between layers.
+func WithLayerSep(sep float64) Option {
+	return func(o *Options) {
+		o.LayerSep = sep
+	}
+}
+
+// WithMargin sets layout margin.
+func WithMargin(m float64) Option {
+	return func(o *Options) {
+		o.Margin = m
+	}
+}
+
+// WithSweeps sets the number of crossing minimisation sweeps.
+func WithSweeps(n int) Option {
+	return func(o *Options) {
+		o.Sweeps = n
+	}
+}
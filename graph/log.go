@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/internal/logging"
+)
+
+// logObserver logs run events.
+type logObserver struct {
+	logger *slog.Logger
+	// start times of runs and node execs
+	starts sync.Map
+}
+
+// newLogObserver creates a new log observer and returns it.
+func newLogObserver(l *slog.Logger) *logObserver {
+	return &logObserver{
+		logger: l,
+	}
+}
+
+// since returns the time elapsed since the start of key.
+func (o *logObserver) since(key string, t time.Time) time.Duration {
+	start, ok := o.starts.LoadAndDelete(key)
+	if !ok {
+		return 0
+	}
+	return t.Sub(start.(time.Time))
+}
+
+// Observe logs event e.
+func (o *logObserver) Observe(ctx context.Context, e hypher.Event) {
+	attrs := []any{
+		slog.String("run_id", e.RunID),
+		slog.String("graph_uid", e.Graph.UID()),
+	}
+	if e.Node != nil {
+		attrs = append(attrs,
+			slog.String("node_uid", e.Node.UID()),
+			slog.String("node_label", e.Node.Label()),
+		)
+	}
+
+	switch e.Type {
+	case hypher.RunStarted:
+		o.starts.Store(e.RunID, e.Time)
+		attrs = append(attrs, slog.String("conc_mode", e.ConcMode.String()))
+		o.logger.InfoContext(ctx, "run started", attrs...)
+	case hypher.RunFinished:
+		attrs = append(attrs,
+			slog.Duration("duration", o.since(e.RunID, e.Time)),
+			slog.Int64("tokens_in", e.Usage.TokensIn),
+			slog.Int64("tokens_out", e.Usage.TokensOut),
+			slog.Float64("cost", e.Usage.Cost),
+		)
+		if e.Err != nil {
+			attrs = append(attrs, slog.Any("error", e.Err))
+			o.logger.ErrorContext(ctx, "run failed", attrs...)
+			return
+		}
+		o.logger.InfoContext(ctx, "run finished", attrs...)
+	case hypher.NodeScheduled:
+		attrs = append(attrs, slog.Int("lane", e.Lane))
+		o.logger.DebugContext(ctx, "node scheduled", attrs...)
+	case hypher.NodeSkipped:
+		o.logger.DebugContext(ctx, "node skipped", attrs...)
+	case hypher.NodeStarted:
+		o.starts.Store(e.RunID+e.Node.UID(), e.Time)
+		attrs = append(attrs,
+			slog.Int("lane", e.Lane),
+			slog.Any("inputs", logging.NewPayload(e.Inputs)),
+		)
+		o.logger.DebugContext(ctx, "node exec started", attrs...)
+	case hypher.NodeFinished:
+		attrs = append(attrs, slog.Duration("duration", o.since(e.RunID+e.Node.UID(), e.Time)))
+		if e.Err != nil {
+			attrs = append(attrs, slog.Any("error", e.Err))
+			o.logger.ErrorContext(ctx, "node exec failed", attrs...)
+			return
+		}
+		attrs = append(attrs, slog.Any("outputs", logging.NewPayload(e.Outputs)))
+		o.logger.DebugContext(ctx, "node exec finished", attrs...)
+	}
+}
@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+func TestRunLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	g := MustGraph(t, hypher.WithLogger(logger))
+	n1 := MustNode(t, hypher.WithGraph(g), hypher.WithOp(testOp{}))
+	n2 := MustNode(t, hypher.WithGraph(g), hypher.WithOp(failOp{}))
+	n3 := MustNode(t, hypher.WithGraph(g))
+	MustEdge(t, n1, n2, hypher.WithGraph(g))
+	MustEdge(t, n1, n3, hypher.WithGraph(g))
+	g.SetInputs([]*Node{n1})
+	g.SetOutputs([]*Node{n2})
+
+	if err := g.Run(context.Background(), map[string]hypher.Value{
+		n1.UID(): {"payload": strings.Repeat("x", 1024)},
+	}); err == nil {
+		t.Fatal("expected run error")
+	}
+
+	out := buf.String()
+	for _, msg := range []string{
+		"run started",
+		"run failed",
+		"node scheduled",
+		"node skipped",
+		"node exec started",
+		"node exec finished",
+		"node exec failed",
+		"node_uid=" + n2.UID(),
+		"<redacted:",
+	} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("expected %q in log output:\n%s", msg, out)
+		}
+	}
+
+	if strings.Contains(out, strings.Repeat("x", 1024)) {
+		t.Error("expected large payload to be redacted")
+	}
+}
+
+func TestRunLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	g := MustGraph(t, hypher.WithLogger(logger), hypher.WithLogLevel(slog.LevelInfo))
+	n := MustNode(t, hypher.WithGraph(g))
+	g.SetInputs([]*Node{n})
+	g.SetOutputs([]*Node{n})
+
+	if err := g.Run(context.Background(), nil); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "run finished") {
+		t.Errorf("expected run finished record in log output:\n%s", out)
+	}
+	if strings.Contains(out, "node scheduled") {
+		t.Errorf("unexpected debug record in log output:\n%s", out)
+	}
+
+	buf.Reset()
+	g.SetLogger(nil)
+
+	if err := g.Run(context.Background(), nil); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no log output, got:\n%s", buf.String())
+	}
+}
+
+func TestRunLoggerOptions(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	g := MustGraph(t, hypher.WithLogLevel(slog.LevelInfo))
+	n := MustNode(t, hypher.WithGraph(g))
+	g.SetInputs([]*Node{n})
+	g.SetOutputs([]*Node{n})
+
+	// SetLogger keeps the graph log level
+	g.SetLogger(logger)
+	if err := g.Run(context.Background(), nil); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "run finished") || strings.Contains(out, "node scheduled") {
+		t.Errorf("expected info records in log output:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := g.Run(context.Background(), nil, hypher.WithLogLevel(slog.LevelDebug)); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "node scheduled") {
+		t.Errorf("expected run log level debug records in log output:\n%s", out)
+	}
+
+	buf.Reset()
+	var runBuf bytes.Buffer
+	runLogger := slog.New(slog.NewTextHandler(&runBuf, nil))
+	if err := g.Run(context.Background(), nil, hypher.WithLogger(runLogger)); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no graph log output, got:\n%s", buf.String())
+	}
+	if out := runBuf.String(); !strings.Contains(out, "run finished") {
+		t.Errorf("expected run finished record in run log output:\n%s", out)
+	}
+}
@@ -1,12 +1,42 @@
 package cytoscape
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"gonum.org/v1/gonum/graph/formats/cytoscapejs"
 
 	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/layout"
+	"github.com/milosgajdos/go-hypher/graph/marshal/dot"
+)
+
+const (
+	// LabelKey is data key of node and edge labels.
+	LabelKey = "label"
+	// WeightKey is data key of edge weights.
+	WeightKey = "weight"
+	// DOTIDKey is data key of node DOT IDs.
+	DOTIDKey = "dotid"
+	// ParentKey is data key of compound node parents.
+	ParentKey = "parent"
+	// InputClass is the class of graph input nodes.
+	InputClass = "input"
+	// OutputClass is the class of graph output nodes.
+	OutputClass = "output"
+	// StatusKey is data key of node run statuses.
+	StatusKey = "status"
+	// DurationKey is data key of node execution durations in milliseconds.
+	DurationKey = "duration_ms"
+	// ErrorKey is data key of node execution errors.
+	ErrorKey = "error"
 )
 
 // Marshaler implements graph.Marshaler.
@@ -14,56 +44,313 @@ type Marshaler struct {
 	name   string
 	prefix string
 	indent string
+	layout *layout.Layout
+	run    *graph.RunResult
 }
 
 // NewMarshaler creates a new Marshaler and returns it.
-func NewMarshaler(name, prefix, indent string) (*Marshaler, error) {
+func NewMarshaler(name, prefix, indent string, opts ...Option) (*Marshaler, error) {
+	mOpts := Options{}
+
+	for _, apply := range opts {
+		apply(&mOpts)
+	}
+
 	return &Marshaler{
 		name:   name,
 		prefix: prefix,
 		indent: indent,
+		layout: mOpts.Layout,
+		run:    mOpts.RunResult,
 	}, nil
 }
 
 // Marshal marshals g into format that can be used by
 // CytoscapJS https://js.cytoscape.org/
+// Nodes and edges are identified by their UIDs. Node and edge
+// labels, node DOT IDs and edge weights are stored in the element
+// data along with the attributes. Graph input and output nodes
+// are assigned InputClass and OutputClass. If a layout is configured,
+// node positions are set to the centers of the laid out nodes.
+// If a run result is configured, node run statuses, execution
+// durations and errors are stored in the node data, the nodes are
+// assigned the classes named after their statuses and their labels
+// include execution durations.
 func (m *Marshaler) Marshal(g hypher.Graph) ([]byte, error) {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return nil, fmt.Errorf("unsupported graph: %T", g)
+	}
+
 	c := cytoscapejs.Elements{
 		Nodes: make([]cytoscapejs.Node, 0, g.Nodes().Len()),
 		Edges: make([]cytoscapejs.Edge, 0, g.Edges().Len()),
 	}
 
-	nodes := g.Nodes()
-	for nodes.Next() {
-		n := nodes.Node().(hypher.Node)
+	inputs, outputs := hg.Inputs(), hg.Outputs()
+
+	for _, n := range sortedNodes(hg) {
+		attrs := cloneAttrs(n.Attrs())
+		attrs[LabelKey] = n.Label()
+		attrs[DOTIDKey] = n.DOTID()
 
-		ndata := cytoscapejs.NodeData{
-			ID:         fmt.Sprint(n.ID()),
-			Attributes: n.Attrs(),
+		var classes []string
+		if slices.Contains(inputs, n) {
+			classes = append(classes, InputClass)
+		}
+		if slices.Contains(outputs, n) {
+			classes = append(classes, OutputClass)
+		}
+		if m.run != nil {
+			s := dot.RunState(m.run, n)
+			classes = append(classes, string(s.Status))
+			attrs[StatusKey] = string(s.Status)
+			if elapsed := s.Elapsed(); elapsed != "" {
+				attrs[LabelKey] = n.Label() + "\n" + elapsed
+				attrs[DurationKey] = float64(s.Duration) / float64(time.Millisecond)
+			}
+			if s.Err != nil {
+				attrs[ErrorKey] = s.Err.Error()
+			}
 		}
 
-		c.Nodes = append(c.Nodes, cytoscapejs.Node{
-			Data:       ndata,
+		node := cytoscapejs.Node{
+			Data: cytoscapejs.NodeData{
+				ID:         n.UID(),
+				Attributes: attrs,
+			},
 			Selectable: true,
-		})
-	}
+			Classes:    strings.Join(classes, " "),
+		}
+		if m.layout != nil {
+			if p, ok := m.layout.Nodes[n.UID()]; ok {
+				node.Position = &cytoscapejs.Position{X: p.X, Y: p.Y}
+			}
+		}
 
-	edges := g.Edges()
-	for edges.Next() {
-		e := edges.Edge().(hypher.Edge)
+		c.Nodes = append(c.Nodes, node)
+	}
 
-		edata := cytoscapejs.EdgeData{
-			ID:         e.UID(),
-			Source:     fmt.Sprint(e.From().ID()),
-			Target:     fmt.Sprint(e.To().ID()),
-			Attributes: e.Attrs(),
-		}
+	for _, e := range sortedEdges(hg) {
+		attrs := cloneAttrs(e.Attrs())
+		attrs[LabelKey] = e.Label()
+		attrs[WeightKey] = e.Weight()
 
 		c.Edges = append(c.Edges, cytoscapejs.Edge{
-			Data:       edata,
+			Data: cytoscapejs.EdgeData{
+				ID:         e.UID(),
+				Source:     e.From().(*graph.Node).UID(),
+				Target:     e.To().(*graph.Node).UID(),
+				Attributes: attrs,
+			},
 			Selectable: true,
 		})
 	}
 
 	return json.MarshalIndent(c, m.prefix, m.indent)
 }
+
+// Unmarshaler implements hypher.Unmarshaler.
+type Unmarshaler struct{}
+
+// NewUnmarshaler creates a new Unmarshaler and returns it.
+func NewUnmarshaler() (*Unmarshaler, error) {
+	return &Unmarshaler{}, nil
+}
+
+// Unmarshal unmarshals CytoscapeJS JSON data into g which must be an empty *graph.Graph.
+// It accepts the elements written by Marshaler as well as the JSON exported
+// by cy.json() with the elements either grouped into nodes and edges or listed
+// in a single array. Element IDs are used as node and edge UIDs, the nodes of
+// InputClass and OutputClass become graph inputs and outputs. Node and edge data
+// are mapped to labels, DOT IDs, weights and attrs.
+func (u *Unmarshaler) Unmarshal(data []byte, g hypher.Graph) error {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return fmt.Errorf("unsupported graph: %T", g)
+	}
+	if hg.Nodes().Len() > 0 {
+		return fmt.Errorf("graph %s is not empty", hg.UID())
+	}
+
+	elems, err := decodeElements(data)
+	if err != nil {
+		return err
+	}
+
+	var inputs, outputs []*graph.Node
+
+	nodes := make(map[string]*graph.Node, len(elems.Nodes))
+	for _, cn := range elems.Nodes {
+		id := cn.Data.ID
+		if _, ok := nodes[id]; ok {
+			return fmt.Errorf("node %s: duplicate id", id)
+		}
+
+		attrs := cloneAttrs(cn.Data.Attributes)
+		if cn.Data.Parent != "" {
+			attrs[ParentKey] = cn.Data.Parent
+		}
+
+		opts := []hypher.Option{
+			hypher.WithUID(id),
+			hypher.WithDotID(id),
+		}
+		if label, ok := attrs[LabelKey].(string); ok {
+			opts = append(opts, hypher.WithLabel(label))
+		}
+		if dotid, ok := attrs[DOTIDKey].(string); ok && dotid != "" {
+			opts = append(opts, hypher.WithDotID(dotid))
+		}
+		delete(attrs, LabelKey)
+		delete(attrs, DOTIDKey)
+		opts = append(opts, hypher.WithAttrs(attrs))
+
+		n, err := hg.NewNode(opts...)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", id, err)
+		}
+		nodes[id] = n
+
+		classes := strings.Fields(cn.Classes)
+		if slices.Contains(classes, InputClass) {
+			inputs = append(inputs, n)
+		}
+		if slices.Contains(classes, OutputClass) {
+			outputs = append(outputs, n)
+		}
+	}
+
+	for _, ce := range elems.Edges {
+		if err := newEdge(hg, nodes, ce.Data); err != nil {
+			return fmt.Errorf("edge %s -> %s: %w", ce.Data.Source, ce.Data.Target, err)
+		}
+	}
+
+	hg.SetInputs(inputs)
+	hg.SetOutputs(outputs)
+
+	return nil
+}
+
+// newEdge creates a new edge in g from CytoscapeJS edge data.
+func newEdge(g *graph.Graph, nodes map[string]*graph.Node, data cytoscapejs.EdgeData) error {
+	from, ok := nodes[data.Source]
+	if !ok {
+		return fmt.Errorf("unknown source node: %s", data.Source)
+	}
+	to, ok := nodes[data.Target]
+	if !ok {
+		return fmt.Errorf("unknown target node: %s", data.Target)
+	}
+	if from.ID() == to.ID() {
+		return errors.New("cycle detected: self loop")
+	}
+
+	attrs := cloneAttrs(data.Attributes)
+
+	opts := []hypher.Option{
+		hypher.WithUID(data.ID),
+	}
+	if label, ok := attrs[LabelKey].(string); ok {
+		opts = append(opts, hypher.WithLabel(label))
+	}
+	if w, ok := attrs[WeightKey]; ok {
+		weight, ok := w.(float64)
+		if !ok {
+			return fmt.Errorf("invalid %s: %v", WeightKey, w)
+		}
+		opts = append(opts, hypher.WithWeight(weight))
+	}
+	delete(attrs, LabelKey)
+	delete(attrs, WeightKey)
+	opts = append(opts, hypher.WithAttrs(attrs))
+
+	e, err := g.NewEdge(from, to, opts...)
+	if err != nil {
+		return err
+	}
+
+	return g.SetEdge(e)
+}
+
+// decodeElements decodes CytoscapeJS elements from data.
+func decodeElements(data []byte) (cytoscapejs.Elements, error) {
+	var doc struct {
+		Elements json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return cytoscapejs.Elements{}, err
+	}
+
+	if doc.Elements == nil {
+		var elems cytoscapejs.Elements
+		err := json.Unmarshal(data, &elems)
+		return elems, err
+	}
+
+	var list []cytoscapejs.Element
+	if err := json.Unmarshal(doc.Elements, &list); err != nil {
+		var elems cytoscapejs.Elements
+		err := json.Unmarshal(doc.Elements, &elems)
+		return elems, err
+	}
+
+	var elems cytoscapejs.Elements
+	for _, el := range list {
+		switch {
+		case el.Data.Source == "" && el.Data.Target == "":
+			elems.Nodes = append(elems.Nodes, cytoscapejs.Node{
+				Data: cytoscapejs.NodeData{
+					ID:         el.Data.ID,
+					Parent:     el.Data.Parent,
+					Attributes: el.Data.Attributes,
+				},
+				Classes: el.Classes,
+			})
+		case el.Data.Source != "" && el.Data.Target != "":
+			elems.Edges = append(elems.Edges, cytoscapejs.Edge{
+				Data: cytoscapejs.EdgeData{
+					ID:         el.Data.ID,
+					Source:     el.Data.Source,
+					Target:     el.Data.Target,
+					Attributes: el.Data.Attributes,
+				},
+				Classes: el.Classes,
+			})
+		default:
+			return cytoscapejs.Elements{}, fmt.Errorf("element %s: incomplete edge", el.Data.ID)
+		}
+	}
+
+	return elems, nil
+}
+
+func sortedNodes(g *graph.Graph) []*graph.Node {
+	var nodes []*graph.Node
+	it := g.Nodes()
+	for it.Next() {
+		nodes = append(nodes, it.Node().(*graph.Node))
+	}
+	slices.SortFunc(nodes, func(a, b *graph.Node) int { return cmp.Compare(a.ID(), b.ID()) })
+	return nodes
+}
+
+func sortedEdges(g *graph.Graph) []*graph.Edge {
+	var edges []*graph.Edge
+	it := g.Edges()
+	for it.Next() {
+		edges = append(edges, it.Edge().(*graph.Edge))
+	}
+	slices.SortFunc(edges, func(a, b *graph.Edge) int {
+		return cmp.Or(cmp.Compare(a.From().ID(), b.From().ID()), cmp.Compare(a.To().ID(), b.To().ID()))
+	})
+	return edges
+}
+
+// cloneAttrs returns a copy of attrs which is never nil.
+func cloneAttrs(attrs map[string]any) map[string]any {
+	c := make(map[string]any, len(attrs))
+	maps.Copy(c, attrs)
+	return c
+}
@@ -0,0 +1,355 @@
+package cytoscape
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/layout"
+	"gonum.org/v1/gonum/graph/formats/cytoscapejs"
+)
+
+func MustGraph(t *testing.T, opts ...hypher.Option) *graph.Graph {
+	g, err := graph.NewGraph(opts...)
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	return g
+}
+
+// MustAgentGraph returns graph: in -> plan -> out.
+func MustAgentGraph(t *testing.T) *graph.Graph {
+	g := MustGraph(t)
+
+	newNode := func(uid string, attrs map[string]any) *graph.Node {
+		n, err := g.NewNode(
+			hypher.WithUID(uid),
+			hypher.WithDotID(uid+"_dot"),
+			hypher.WithLabel(strings.ToUpper(uid)),
+			hypher.WithAttrs(attrs),
+		)
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		return n
+	}
+
+	in := newNode("in", map[string]any{"name": "query"})
+	plan := newNode("plan", map[string]any{"temperature": 0.7})
+	out := newNode("out", nil)
+
+	for _, pair := range [][2]*graph.Node{{in, plan}, {plan, out}} {
+		e, err := g.NewEdge(pair[0], pair[1],
+			hypher.WithUID(pair[0].UID()+"-"+pair[1].UID()),
+			hypher.WithLabel("next"),
+			hypher.WithWeight(2.5),
+			hypher.WithAttrs(map[string]any{"cost": 1.5}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create edge: %v", err)
+		}
+		if err := g.SetEdge(e); err != nil {
+			t.Fatalf("failed to set edge: %v", err)
+		}
+	}
+
+	g.SetInputs([]*graph.Node{in})
+	g.SetOutputs([]*graph.Node{out})
+
+	return g
+}
+
+func MustMarshal(t *testing.T, g *graph.Graph) []byte {
+	m, err := NewMarshaler("", "", "  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+	return data
+}
+
+func MustUnmarshal(t *testing.T, data []byte) *graph.Graph {
+	u, err := NewUnmarshaler()
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+	g := MustGraph(t)
+	if err := u.Unmarshal(data, g); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	return g
+}
+
+func nodeByUID(t *testing.T, g *graph.Graph, uid string) *graph.Node {
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		if n.UID() == uid {
+			return n
+		}
+	}
+	t.Fatalf("node %s not found", uid)
+	return nil
+}
+
+func TestMarshal(t *testing.T) {
+	g := MustAgentGraph(t)
+	data := MustMarshal(t, g)
+
+	var elems cytoscapejs.Elements
+	if err := json.Unmarshal(data, &elems); err != nil {
+		t.Fatalf("failed to decode elements: %v", err)
+	}
+
+	if len(elems.Nodes) != 3 || len(elems.Edges) != 2 {
+		t.Fatalf("expected 3 nodes and 2 edges, got: %d, %d", len(elems.Nodes), len(elems.Edges))
+	}
+
+	in := elems.Nodes[0]
+	if in.Data.ID != "in" || in.Classes != InputClass {
+		t.Errorf("unexpected input node: %+v", in)
+	}
+	if in.Data.Attributes[LabelKey] != "IN" || in.Data.Attributes[DOTIDKey] != "in_dot" {
+		t.Errorf("unexpected input node data: %v", in.Data.Attributes)
+	}
+	if out := elems.Nodes[2]; out.Classes != OutputClass {
+		t.Errorf("expected class %s, got: %s", OutputClass, out.Classes)
+	}
+
+	e := elems.Edges[0]
+	if e.Data.ID != "in-plan" || e.Data.Source != "in" || e.Data.Target != "plan" {
+		t.Errorf("unexpected edge: %+v", e.Data)
+	}
+	if e.Data.Attributes[LabelKey] != "next" || e.Data.Attributes[WeightKey] != 2.5 {
+		t.Errorf("unexpected edge data: %v", e.Data.Attributes)
+	}
+
+	// marshaling must not modify the graph attributes
+	if attrs := nodeByUID(t, g, "in").Attrs(); !reflect.DeepEqual(attrs, map[string]any{"name": "query"}) {
+		t.Errorf("graph node attrs modified: %v", attrs)
+	}
+}
+
+func TestMarshalLayout(t *testing.T) {
+	g := MustAgentGraph(t)
+
+	s, err := layout.NewSugiyama()
+	if err != nil {
+		t.Fatalf("failed to create layout: %v", err)
+	}
+	l, err := s.Layout(g)
+	if err != nil {
+		t.Fatalf("failed to compute layout: %v", err)
+	}
+
+	m, err := NewMarshaler("", "", "  ", WithLayout(l))
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	var elems cytoscapejs.Elements
+	if err := json.Unmarshal(data, &elems); err != nil {
+		t.Fatalf("failed to decode elements: %v", err)
+	}
+
+	for _, n := range elems.Nodes {
+		want := l.Nodes[n.Data.ID]
+		if n.Position == nil || n.Position.X != want.X || n.Position.Y != want.Y {
+			t.Errorf("node %s: expected position: %v, got: %v", n.Data.ID, want, n.Position)
+		}
+	}
+}
+
+func TestMarshalRunResult(t *testing.T) {
+	g := MustAgentGraph(t)
+
+	start := time.Now()
+	r := &graph.RunResult{
+		Nodes: map[string]*graph.NodeResult{
+			"in":   {Start: start, End: start.Add(12 * time.Millisecond)},
+			"plan": {Start: start, End: start.Add(1500 * time.Millisecond), Err: errors.New("rate limited")},
+		},
+	}
+
+	m, err := NewMarshaler("", "", "  ", WithRunResult(r))
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	var elems cytoscapejs.Elements
+	if err := json.Unmarshal(data, &elems); err != nil {
+		t.Fatalf("failed to decode elements: %v", err)
+	}
+
+	testCases := []struct {
+		classes string
+		data    map[string]any
+	}{
+		{"input succeeded", map[string]any{StatusKey: "succeeded", LabelKey: "IN\n12ms", DurationKey: 12.0}},
+		{"failed", map[string]any{StatusKey: "failed", LabelKey: "PLAN\n1.5s", DurationKey: 1500.0, ErrorKey: "rate limited"}},
+		{"output pending", map[string]any{StatusKey: "pending", LabelKey: "OUT"}},
+	}
+	for i, tc := range testCases {
+		n := elems.Nodes[i]
+		if n.Classes != tc.classes {
+			t.Errorf("node %s: expected classes: %q, got: %q", n.Data.ID, tc.classes, n.Classes)
+		}
+		for k, v := range tc.data {
+			if n.Data.Attributes[k] != v {
+				t.Errorf("node %s: expected %s: %v, got: %v", n.Data.ID, k, v, n.Data.Attributes[k])
+			}
+		}
+		if _, ok := tc.data[ErrorKey]; !ok && n.Data.Attributes[ErrorKey] != nil {
+			t.Errorf("node %s: unexpected error: %v", n.Data.ID, n.Data.Attributes[ErrorKey])
+		}
+	}
+
+	if l := nodeByUID(t, g, "in").Label(); l != "IN" {
+		t.Errorf("graph node label modified: %s", l)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	g := MustUnmarshal(t, MustMarshal(t, MustAgentGraph(t)))
+
+	in := nodeByUID(t, g, "in")
+	plan := nodeByUID(t, g, "plan")
+	out := nodeByUID(t, g, "out")
+
+	if in.Label() != "IN" || in.DOTID() != "in_dot" {
+		t.Errorf("unexpected node label: %s, dotid: %s", in.Label(), in.DOTID())
+	}
+	if !reflect.DeepEqual(in.Attrs(), map[string]any{"name": "query"}) {
+		t.Errorf("unexpected node attrs: %v", in.Attrs())
+	}
+	if v := plan.Attrs()["temperature"]; v != 0.7 {
+		t.Errorf("expected temperature: 0.7, got: %v", v)
+	}
+
+	e := g.Edge(in.ID(), plan.ID()).(*graph.Edge)
+	if e.UID() != "in-plan" || e.Label() != "next" || e.Weight() != 2.5 {
+		t.Errorf("unexpected edge uid: %s, label: %s, weight: %f", e.UID(), e.Label(), e.Weight())
+	}
+	if !reflect.DeepEqual(e.Attrs(), map[string]any{"cost": 1.5}) {
+		t.Errorf("unexpected edge attrs: %v", e.Attrs())
+	}
+
+	if inputs := g.Inputs(); len(inputs) != 1 || inputs[0] != in {
+		t.Errorf("expected inputs: [in], got: %v", inputs)
+	}
+	if outputs := g.Outputs(); len(outputs) != 1 || outputs[0] != out {
+		t.Errorf("expected outputs: [out], got: %v", outputs)
+	}
+}
+
+func TestUnmarshalElementList(t *testing.T) {
+	// cy.json() output with the elements in a single array
+	data := `{
+  "elements": [
+    {"group": "nodes", "data": {"id": "a", "label": "A", "parent": "grp"}, "classes": "input"},
+    {"group": "nodes", "data": {"id": "b"}, "classes": "output highlighted"},
+    {"group": "edges", "data": {"id": "ab", "source": "a", "target": "b", "weight": 3}}
+  ]
+}`
+
+	g := MustUnmarshal(t, []byte(data))
+
+	a, b := nodeByUID(t, g, "a"), nodeByUID(t, g, "b")
+	if a.Label() != "A" || a.DOTID() != "a" {
+		t.Errorf("unexpected node label: %s, dotid: %s", a.Label(), a.DOTID())
+	}
+	if v := a.Attrs()[ParentKey]; v != "grp" {
+		t.Errorf("expected parent: grp, got: %v", v)
+	}
+	if b.Label() != graph.DefaultNodeLabel {
+		t.Errorf("expected default label, got: %s", b.Label())
+	}
+
+	e := g.Edge(a.ID(), b.ID()).(*graph.Edge)
+	if e.UID() != "ab" || e.Weight() != 3 {
+		t.Errorf("unexpected edge uid: %s, weight: %f", e.UID(), e.Weight())
+	}
+
+	if inputs := g.Inputs(); len(inputs) != 1 || inputs[0] != a {
+		t.Errorf("expected inputs: [a], got: %v", inputs)
+	}
+	if outputs := g.Outputs(); len(outputs) != 1 || outputs[0] != b {
+		t.Errorf("expected outputs: [b], got: %v", outputs)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "cycle",
+			data: `{"nodes": [{"data": {"id": "a"}}, {"data": {"id": "b"}}],
+				"edges": [{"data": {"id": "ab", "source": "a", "target": "b"}},
+					{"data": {"id": "ba", "source": "b", "target": "a"}}]}`,
+			want: "edge b -> a",
+		},
+		{
+			name: "self loop",
+			data: `{"nodes": [{"data": {"id": "a"}}],
+				"edges": [{"data": {"id": "aa", "source": "a", "target": "a"}}]}`,
+			want: "edge a -> a",
+		},
+		{
+			name: "unknown node",
+			data: `{"nodes": [{"data": {"id": "a"}}],
+				"edges": [{"data": {"id": "ax", "source": "a", "target": "x"}}]}`,
+			want: "unknown target node: x",
+		},
+		{
+			name: "duplicate node",
+			data: `{"nodes": [{"data": {"id": "a"}}, {"data": {"id": "a"}}]}`,
+			want: "duplicate id",
+		},
+		{
+			name: "invalid weight",
+			data: `{"nodes": [{"data": {"id": "a"}}, {"data": {"id": "b"}}],
+				"edges": [{"data": {"id": "ab", "source": "a", "target": "b", "weight": "x"}}]}`,
+			want: "invalid weight",
+		},
+		{
+			name: "incomplete edge",
+			data: `{"elements": [{"data": {"id": "ab", "source": "a"}}]}`,
+			want: "incomplete edge",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := NewUnmarshaler()
+			if err != nil {
+				t.Fatalf("failed to create unmarshaler: %v", err)
+			}
+			err = u.Unmarshal([]byte(tc.data), MustGraph(t))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package cytoscape
+
+import (
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/layout"
+)
+
+// Options configure marshaler.
+type Options struct {
+	// Layout configures node positions.
+	Layout *layout.Layout
+	// RunResult configures the run whose state is overlaid on the graph.
+	RunResult *graph.RunResult
+}
+
+// Option is functional marshaler option.
+type Option func(*Options)
+
+// WithLayout sets the layout whose node centers are used as node positions.
+func WithLayout(l *layout.Layout) Option {
+	return func(o *Options) {
+		o.Layout = l
+	}
+}
+
+// WithRunResult sets the run whose state is overlaid on the graph.
+func WithRunResult(r *graph.RunResult) Option {
+	return func(o *Options) {
+		o.RunResult = r
+	}
+}
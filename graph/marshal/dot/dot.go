@@ -1,6 +1,8 @@
 package dot
 
 import (
+	"fmt"
+
 	"github.com/milosgajdos/go-hypher"
 	"github.com/milosgajdos/go-hypher/graph"
 
@@ -15,6 +17,9 @@ type Marshaler struct {
 	nodeStyle  Style
 	edgeStyle  Style
 	graphStyle Style
+	nodeRules  []NodeRule
+	edgeRules  []EdgeRule
+	run        *graph.RunResult
 }
 
 // NewMarshaler creates a new DOT graph marshaler and returns it.
@@ -36,40 +41,24 @@ func NewMarshaler(name, prefix, indent string, opts ...Option) (*Marshaler, erro
 		nodeStyle:  dotOpts.NodeStyle,
 		edgeStyle:  dotOpts.EdgeStyle,
 		graphStyle: dotOpts.GraphStyle,
+		nodeRules:  dotOpts.NodeRules,
+		edgeRules:  dotOpts.EdgeRules,
+		run:        dotOpts.RunResult,
 	}, nil
 }
 
 // Marshal marshal g into DOT and returns it.
+// Marshal styles a view of g, leaving g unmodified.
+// Node and edge rules are applied after the default
+// styles in the order they were configured.
+// If a run result is configured, the nodes are colored
+// by their run statuses, their labels include execution
+// durations and their tooltips show execution errors.
 func (m *Marshaler) Marshal(g hypher.Graph) ([]byte, error) {
-	// Apply DOT styling
-
-	hg := g.(*graph.Graph)
-	hg.Attrs()["label"] = hg.Label()
-	for k, v := range m.graphStyle.Attrs {
-		hg.Attrs()[k] = v
-	}
-
-	nodes := g.Nodes()
-	for nodes.Next() {
-		n := nodes.Node().(*graph.Node)
-		n.Attrs()["label"] = n.Label()
-		n.Attrs()["shape"] = m.nodeStyle.Shape
-		n.Attrs()["style"] = m.nodeStyle.Type
-		for k, v := range m.nodeStyle.Attrs {
-			n.Attrs()[k] = v
-		}
-	}
-
-	edges := g.Edges()
-	for edges.Next() {
-		e := edges.Edge().(*graph.Edge)
-		e.Attrs()["label"] = e.Label()
-		e.Attrs()["shape"] = m.edgeStyle.Shape
-		e.Attrs()["style"] = m.edgeStyle.Type
-		for k, v := range m.edgeStyle.Attrs {
-			e.Attrs()[k] = v
-		}
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return nil, fmt.Errorf("unsupported graph: %T", g)
 	}
 
-	return dot.Marshal(g, m.name, m.prefix, m.indent)
+	return dot.Marshal(m.newView(hg), m.name, m.prefix, m.indent)
 }
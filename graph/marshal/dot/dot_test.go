@@ -0,0 +1,296 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func MustMarshaler(t *testing.T, opts ...Option) *Marshaler {
+	m, err := NewMarshaler("", "", "  ", opts...)
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	return m
+}
+
+// MustPipeline returns graph: in -> greet -> out.
+func MustPipeline(t *testing.T, greet hypher.Op) *graph.Graph {
+	g, err := graph.NewGraph(hypher.WithLabel("Pipeline"))
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+
+	var nodes []*graph.Node
+	for _, n := range []struct {
+		dotid string
+		op    hypher.Op
+	}{
+		{"in", graph.NoOp{}},
+		{"greet", greet},
+		{"out", graph.NoOp{}},
+	} {
+		node, err := g.NewNode(
+			hypher.WithDotID(n.dotid),
+			hypher.WithLabel(n.dotid),
+			hypher.WithOp(n.op),
+			hypher.WithAttrs(map[string]any{"kind": n.dotid}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	for i := 1; i < len(nodes); i++ {
+		e, err := g.NewEdge(nodes[i-1], nodes[i], hypher.WithLabel("next"))
+		if err != nil {
+			t.Fatalf("failed to create edge: %v", err)
+		}
+		if err := g.SetEdge(e); err != nil {
+			t.Fatalf("failed to set edge: %v", err)
+		}
+	}
+
+	g.SetInputs(nodes[:1])
+	g.SetOutputs(nodes[2:])
+
+	return g
+}
+
+func TestMarshalNoSideEffects(t *testing.T) {
+	g := MustPipeline(t, &greetOp{Greeting: "hi"})
+
+	graphAttrs := maps.Clone(g.Attrs())
+	nodeAttrs := map[int64]map[string]any{}
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		nodeAttrs[n.ID()] = maps.Clone(n.Attrs())
+	}
+	edgeAttrs := map[string]map[string]any{}
+	edges := g.Edges()
+	for edges.Next() {
+		e := edges.Edge().(*graph.Edge)
+		edgeAttrs[e.UID()] = maps.Clone(e.Attrs())
+	}
+
+	m := MustMarshaler(t, WithNodeRule(IsInput(), Style{Shape: "circle"}))
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+	if !strings.Contains(string(data), `shape=circle`) {
+		t.Errorf("expected styled node in:\n%s", data)
+	}
+
+	if !maps.Equal(g.Attrs(), graphAttrs) {
+		t.Errorf("graph attrs modified: %v", g.Attrs())
+	}
+	nodes = g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		if !maps.Equal(n.Attrs(), nodeAttrs[n.ID()]) {
+			t.Errorf("node %s attrs modified: %v", n.DOTID(), n.Attrs())
+		}
+	}
+	edges = g.Edges()
+	for edges.Next() {
+		e := edges.Edge().(*graph.Edge)
+		if !maps.Equal(e.Attrs(), edgeAttrs[e.UID()]) {
+			t.Errorf("edge %s attrs modified: %v", e.UID(), e.Attrs())
+		}
+	}
+
+	again, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("expected deterministic output, got:\n%s\nand:\n%s", data, again)
+	}
+}
+
+func TestMarshalStyle(t *testing.T) {
+	g := MustPipeline(t, &greetOp{Greeting: "hi"})
+
+	m := MustMarshaler(t,
+		WithNodeStyle(Style{Shape: "box", Color: color.RGBA{R: 1, G: 2, B: 3}}),
+		WithEdgeStyle(Style{Color: color.RGBA{R: 255}, Attrs: map[string]any{"arrowhead": "vee"}}),
+	)
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	out := string(data)
+	for _, want := range []string{
+		`label=Pipeline`,
+		`color="#010203"`,
+		`shape=box`,
+		`color="#ff0000"`,
+		`arrowhead=vee`,
+		`kind=greet`,
+		`label=next`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in:\n%s", want, out)
+		}
+	}
+}
+
+func TestMarshalRules(t *testing.T) {
+	g := MustPipeline(t, &greetOp{Greeting: "hi"})
+
+	m := MustMarshaler(t,
+		WithNodeRule(IsInput(), Style{Shape: "invhouse"}),
+		WithNodeRule(IsOutput(), Style{Shape: "house"}),
+		WithNodeRule(OpType("GreetOp"), Style{Shape: "box"}),
+		WithNodeRule(And(OpType("GreetOp"), HasAttr("kind", "greet")), Style{Type: "bold"}),
+		WithNodeRule(Not(Or(IsInput(), IsOutput())), Style{Attrs: map[string]any{"penwidth": "2"}}),
+		WithEdgeRule(EdgeHasAttr("missing", nil), Style{Type: "dotted"}),
+	)
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	testCases := []struct {
+		dotid   string
+		want    []string
+		notWant []string
+	}{
+		{"in", []string{"shape=invhouse"}, []string{"penwidth"}},
+		{"greet", []string{"shape=box", "style=bold", "penwidth=2"}, nil},
+		{"out", []string{"shape=house"}, []string{"penwidth"}},
+	}
+
+	blocks := nodeBlocks(string(data))
+	for _, tc := range testCases {
+		block, ok := blocks[tc.dotid]
+		if !ok {
+			t.Fatalf("node %s not found in:\n%s", tc.dotid, data)
+		}
+		for _, w := range tc.want {
+			if !strings.Contains(block, w) {
+				t.Errorf("node %s: expected %s in:\n%s", tc.dotid, w, block)
+			}
+		}
+		for _, w := range tc.notWant {
+			if strings.Contains(block, w) {
+				t.Errorf("node %s: unexpected %s in:\n%s", tc.dotid, w, block)
+			}
+		}
+	}
+
+	if strings.Contains(string(data), "dotted") {
+		t.Errorf("unexpected edge style in:\n%s", data)
+	}
+}
+
+func TestMarshalStatus(t *testing.T) {
+	g := MustPipeline(t, graph.FuncOp("FailOp", "", func(context.Context, ...hypher.Value) ([]hypher.Value, error) {
+		return nil, errors.New("greet failed")
+	}))
+
+	rec := NewStatusRecorder()
+	if err := g.Run(context.Background(), nil, hypher.WithObserver(rec)); err == nil {
+		t.Fatal("expected run error")
+	}
+
+	for dotid, want := range map[string]Status{
+		"in":    StatusSucceeded,
+		"greet": StatusFailed,
+		"out":   StatusPending,
+	} {
+		if s := rec.Status(nodeByDOTID(t, g, dotid)); s != want {
+			t.Errorf("node %s: expected status: %s, got: %s", dotid, want, s)
+		}
+	}
+
+	m := MustMarshaler(t,
+		WithNodeRule(rec.Is(StatusSucceeded), Style{Color: color.RGBA{G: 255}}),
+		WithNodeRule(rec.Is(StatusFailed), Style{Color: color.RGBA{R: 255}}),
+	)
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	blocks := nodeBlocks(string(data))
+	if !strings.Contains(blocks["in"], `color="#00ff00"`) {
+		t.Errorf("expected succeeded color in:\n%s", blocks["in"])
+	}
+	if !strings.Contains(blocks["greet"], `color="#ff0000"`) {
+		t.Errorf("expected failed color in:\n%s", blocks["greet"])
+	}
+}
+
+func TestMarshalRunResult(t *testing.T) {
+	g := MustPipeline(t, graph.FuncOp("FailOp", "", func(context.Context, ...hypher.Value) ([]hypher.Value, error) {
+		return nil, errors.New("greet failed")
+	}))
+
+	rec := graph.NewRecorder()
+	if err := g.Run(context.Background(), nil, hypher.WithObserver(rec)); err == nil {
+		t.Fatal("expected run error")
+	}
+
+	data, err := MustMarshaler(t, WithRunResult(rec.Result())).Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	blocks := nodeBlocks(string(data))
+	for dotid, want := range map[string][]string{
+		"in":    {`color="#c8e6c9"`, `label="in\n`, `tooltip=succeeded`},
+		"greet": {`color="#ffcdd2"`, `label="greet\n`, `tooltip="`, `greet failed"`},
+		"out":   {`color="#e6e6e6"`, `label=out`, `tooltip=pending`},
+	} {
+		for _, w := range want {
+			if !strings.Contains(blocks[dotid], w) {
+				t.Errorf("node %s: expected %s in:\n%s", dotid, w, blocks[dotid])
+			}
+		}
+	}
+
+	if l := nodeByDOTID(t, g, "greet").Label(); l != "greet" {
+		t.Errorf("graph node label modified: %s", l)
+	}
+}
+
+func TestMarshalUnsupportedGraph(t *testing.T) {
+	m := MustMarshaler(t)
+	if _, err := m.Marshal(nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// nodeBlocks returns DOT node definitions keyed by node DOT ID.
+func nodeBlocks(data string) map[string]string {
+	blocks := make(map[string]string)
+	var (
+		id    string
+		block strings.Builder
+	)
+	for _, l := range strings.Split(data, "\n") {
+		l = strings.TrimSpace(l)
+		switch {
+		case strings.HasSuffix(l, " [") && !strings.Contains(l, "->"):
+			id = strings.TrimSuffix(l, " [")
+			block.Reset()
+		case id != "" && strings.HasPrefix(l, "]"):
+			blocks[id] = block.String()
+			id = ""
+		case id != "":
+			block.WriteString(l + "\n")
+		}
+	}
+	return blocks
+}
@@ -1,5 +1,7 @@
 package dot
 
+import "github.com/milosgajdos/go-hypher/graph"
+
 // Options configure graph.
 type Options struct {
 	// NodeStyle configures Node style.
@@ -8,6 +10,14 @@ type Options struct {
 	EdgeStyle Style
 	// GraphStyle configures Graphe style.
 	GraphStyle Style
+	// NodeRules configures Node styling rules.
+	NodeRules []NodeRule
+	// EdgeRules configures Edge styling rules.
+	EdgeRules []EdgeRule
+	// OpRegistry configures the registry used to create node Ops.
+	OpRegistry *graph.OpRegistry
+	// RunResult configures the run whose state is overlaid on the graph.
+	RunResult *graph.RunResult
 }
 
 // Option is functional graph option.
@@ -33,3 +43,33 @@ func WithGraphStyle(s Style) Option {
 		o.GraphStyle = s
 	}
 }
+
+// WithNodeRule adds a Node styling rule.
+// Node matched by p is styled with s.
+func WithNodeRule(p NodePredicate, s Style) Option {
+	return func(o *Options) {
+		o.NodeRules = append(o.NodeRules, NodeRule{Match: p, Style: s})
+	}
+}
+
+// WithEdgeRule adds an Edge styling rule.
+// Edge matched by p is styled with s.
+func WithEdgeRule(p EdgePredicate, s Style) Option {
+	return func(o *Options) {
+		o.EdgeRules = append(o.EdgeRules, EdgeRule{Match: p, Style: s})
+	}
+}
+
+// WithOpRegistry sets OpRegistry.
+func WithOpRegistry(r *graph.OpRegistry) Option {
+	return func(o *Options) {
+		o.OpRegistry = r
+	}
+}
+
+// WithRunResult sets the run whose state is overlaid on the graph.
+func WithRunResult(r *graph.RunResult) Option {
+	return func(o *Options) {
+		o.RunResult = r
+	}
+}
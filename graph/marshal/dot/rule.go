@@ -0,0 +1,111 @@
+package dot
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// NodePredicate reports whether node n matches.
+type NodePredicate func(n *graph.Node) bool
+
+// EdgePredicate reports whether edge e matches.
+type EdgePredicate func(e *graph.Edge) bool
+
+// NodeRule styles the nodes matched by its predicate.
+type NodeRule struct {
+	// Match selects the styled nodes.
+	Match NodePredicate
+	// Style is applied to the matched nodes.
+	Style Style
+}
+
+// EdgeRule styles the edges matched by its predicate.
+type EdgeRule struct {
+	// Match selects the styled edges.
+	Match EdgePredicate
+	// Style is applied to the matched edges.
+	Style Style
+}
+
+// OpType matches nodes whose Op type is one of types.
+func OpType(types ...string) NodePredicate {
+	return func(n *graph.Node) bool {
+		return slices.Contains(types, n.Op().Type())
+	}
+}
+
+// IsInput matches graph input nodes.
+func IsInput() NodePredicate {
+	return func(n *graph.Node) bool {
+		g, ok := n.Graph().(*graph.Graph)
+		return ok && slices.Contains(g.Inputs(), n)
+	}
+}
+
+// IsOutput matches graph output nodes.
+func IsOutput() NodePredicate {
+	return func(n *graph.Node) bool {
+		g, ok := n.Graph().(*graph.Graph)
+		return ok && slices.Contains(g.Outputs(), n)
+	}
+}
+
+// HasAttr matches nodes whose attribute key is equal to val.
+// If val is nil it matches all nodes which have the attribute key.
+func HasAttr(key string, val any) NodePredicate {
+	return func(n *graph.Node) bool {
+		return hasAttr(n.Attrs(), key, val)
+	}
+}
+
+// EdgeHasAttr matches edges whose attribute key is equal to val.
+// If val is nil it matches all edges which have the attribute key.
+func EdgeHasAttr(key string, val any) EdgePredicate {
+	return func(e *graph.Edge) bool {
+		return hasAttr(e.Attrs(), key, val)
+	}
+}
+
+// Not negates node predicate p.
+func Not(p NodePredicate) NodePredicate {
+	return func(n *graph.Node) bool {
+		return !p(n)
+	}
+}
+
+// And matches nodes matched by all predicates.
+func And(preds ...NodePredicate) NodePredicate {
+	return func(n *graph.Node) bool {
+		for _, p := range preds {
+			if !p(n) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches nodes matched by any of predicates.
+func Or(preds ...NodePredicate) NodePredicate {
+	return func(n *graph.Node) bool {
+		for _, p := range preds {
+			if p(n) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func hasAttr(attrs map[string]any, key string, val any) bool {
+	v, ok := attrs[key]
+	if !ok {
+		return false
+	}
+	if val == nil {
+		return true
+	}
+	return reflect.DeepEqual(v, val)
+}
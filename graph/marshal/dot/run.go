@@ -0,0 +1,57 @@
+package dot
+
+import (
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// NodeRun is the state of a node in a graph run.
+type NodeRun struct {
+	// Status is the node status.
+	Status Status
+	// Duration is the node execution duration.
+	// It is zero if the node execution has not finished.
+	Duration time.Duration
+	// Err is the node execution error.
+	Err error
+}
+
+// RunState returns the state of node n in run r.
+// Nodes which were not reached by the run are pending.
+func RunState(r *graph.RunResult, n hypher.Node) NodeRun {
+	for _, uid := range r.Skipped {
+		if uid == n.UID() {
+			return NodeRun{Status: StatusSkipped}
+		}
+	}
+
+	res, ok := r.Nodes[n.UID()]
+	switch {
+	case !ok:
+		return NodeRun{Status: StatusPending}
+	case res.Start.IsZero():
+		return NodeRun{Status: StatusScheduled}
+	case res.End.IsZero():
+		return NodeRun{Status: StatusRunning}
+	case res.Err != nil:
+		return NodeRun{Status: StatusFailed, Duration: res.Duration(), Err: res.Err}
+	}
+
+	return NodeRun{Status: StatusSucceeded, Duration: res.Duration()}
+}
+
+// Elapsed returns the node execution duration rounded for display.
+// It returns empty string if the node execution has not finished.
+func (r NodeRun) Elapsed() string {
+	switch {
+	case r.Status != StatusSucceeded && r.Status != StatusFailed:
+		return ""
+	case r.Duration >= time.Second:
+		return r.Duration.Round(time.Millisecond).String()
+	case r.Duration >= time.Millisecond:
+		return r.Duration.Round(10 * time.Microsecond).String()
+	}
+	return r.Duration.Round(time.Microsecond).String()
+}
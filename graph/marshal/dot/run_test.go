@@ -0,0 +1,59 @@
+package dot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func TestRunState(t *testing.T) {
+	g := MustPipeline(t, graph.NoOp{})
+
+	start := time.Now()
+	r := &graph.RunResult{
+		Nodes: map[string]*graph.NodeResult{
+			"ok":        {Start: start, End: start.Add(1234567 * time.Microsecond)},
+			"failed":    {Start: start, End: start.Add(12345 * time.Microsecond), Err: errors.New("boom")},
+			"running":   {Start: start},
+			"scheduled": {Scheduled: start},
+		},
+		Skipped: []string{"skipped"},
+	}
+
+	testCases := []struct {
+		uid     string
+		status  Status
+		elapsed string
+		err     bool
+	}{
+		{"ok", StatusSucceeded, "1.235s", false},
+		{"failed", StatusFailed, "12.35ms", true},
+		{"running", StatusRunning, "", false},
+		{"scheduled", StatusScheduled, "", false},
+		{"skipped", StatusSkipped, "", false},
+		{"missing", StatusPending, "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.uid, func(t *testing.T) {
+			n, err := g.NewNode(hypher.WithUID(tc.uid))
+			if err != nil {
+				t.Fatalf("failed to create node: %v", err)
+			}
+
+			s := RunState(r, n)
+			if s.Status != tc.status {
+				t.Errorf("expected status: %s, got: %s", tc.status, s.Status)
+			}
+			if e := s.Elapsed(); e != tc.elapsed {
+				t.Errorf("expected elapsed: %q, got: %q", tc.elapsed, e)
+			}
+			if (s.Err != nil) != tc.err {
+				t.Errorf("unexpected error: %v", s.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package dot
+
+import (
+	"context"
+	"image/color"
+	"slices"
+	"sync"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// Status is node run status.
+type Status string
+
+const (
+	// StatusPending is the status of nodes which have not been scheduled.
+	StatusPending Status = "pending"
+	// StatusScheduled is the status of scheduled nodes.
+	StatusScheduled Status = "scheduled"
+	// StatusRunning is the status of running nodes.
+	StatusRunning Status = "running"
+	// StatusSucceeded is the status of successfully executed nodes.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed is the status of nodes whose execution failed.
+	StatusFailed Status = "failed"
+	// StatusSkipped is the status of skipped nodes.
+	StatusSkipped Status = "skipped"
+)
+
+// StatusColors are default colors of node statuses.
+var StatusColors = map[Status]color.RGBA{
+	StatusPending:   {R: 230, G: 230, B: 230},
+	StatusScheduled: {R: 255, G: 243, B: 196},
+	StatusRunning:   {R: 187, G: 222, B: 251},
+	StatusSucceeded: {R: 200, G: 230, B: 201},
+	StatusFailed:    {R: 255, G: 205, B: 210},
+	StatusSkipped:   {R: 245, G: 245, B: 245},
+}
+
+// StatusRecorder is hypher.Observer which records
+// the status of the nodes of the observed graph runs.
+// Node statuses are reset when a new run starts.
+type StatusRecorder struct {
+	status map[string]Status
+	mu     sync.RWMutex
+}
+
+// NewStatusRecorder creates a new StatusRecorder and returns it.
+func NewStatusRecorder() *StatusRecorder {
+	return &StatusRecorder{
+		status: make(map[string]Status),
+	}
+}
+
+// Observe implements hypher.Observer.
+func (r *StatusRecorder) Observe(_ context.Context, e hypher.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Type {
+	case hypher.RunStarted:
+		clear(r.status)
+	case hypher.NodeScheduled:
+		r.status[e.Node.UID()] = StatusScheduled
+	case hypher.NodeSkipped:
+		r.status[e.Node.UID()] = StatusSkipped
+	case hypher.NodeStarted:
+		r.status[e.Node.UID()] = StatusRunning
+	case hypher.NodeFinished:
+		if e.Err != nil {
+			r.status[e.Node.UID()] = StatusFailed
+			return
+		}
+		r.status[e.Node.UID()] = StatusSucceeded
+	}
+}
+
+// Status returns the status of node n.
+func (r *StatusRecorder) Status(n hypher.Node) Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if s, ok := r.status[n.UID()]; ok {
+		return s
+	}
+	return StatusPending
+}
+
+// Is matches nodes whose status is one of statuses.
+func (r *StatusRecorder) Is(statuses ...Status) NodePredicate {
+	return func(n *graph.Node) bool {
+		return slices.Contains(statuses, r.Status(n))
+	}
+}
@@ -0,0 +1,365 @@
+package dot
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+
+	gonum "gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
+	"gonum.org/v1/gonum/graph/encoding/dot"
+	"gonum.org/v1/gonum/graph/simple"
+
+	dotparser "gonum.org/v1/gonum/graph/formats/dot"
+	"gonum.org/v1/gonum/graph/formats/dot/ast"
+)
+
+const (
+	// LabelAttr is DOT attribute mapped to graph, node and edge label.
+	LabelAttr = "label"
+	// WeightAttr is DOT attribute mapped to edge weight.
+	WeightAttr = "weight"
+	// InputAttr is DOT attribute which marks graph input nodes.
+	InputAttr = "hypher_input"
+	// OutputAttr is DOT attribute which marks graph output nodes.
+	OutputAttr = "hypher_output"
+	// OpAttr is DOT attribute which sets node Op type.
+	OpAttr = "op"
+	// OpSpecAttr is DOT attribute which sets node Op spec.
+	OpSpecAttr = "op_spec"
+)
+
+// Unmarshaler is used for unmarshaling DOT into graph.
+type Unmarshaler struct {
+	ops *graph.OpRegistry
+}
+
+// NewUnmarshaler creates a new DOT graph unmarshaler and returns it.
+func NewUnmarshaler(opts ...Option) (*Unmarshaler, error) {
+	dotOpts := Options{}
+	for _, apply := range opts {
+		apply(&dotOpts)
+	}
+
+	return &Unmarshaler{
+		ops: dotOpts.OpRegistry,
+	}, nil
+}
+
+// Unmarshal unmarshals DOT data into g which must be an empty *graph.Graph.
+// DOT node IDs are mapped to node DOT IDs, label attributes to labels,
+// weight attributes to edge weights and all the other attributes to attrs.
+// Nodes with InputAttr or OutputAttr set to true become graph inputs or outputs.
+// If the Unmarshaler has an OpRegistry, node Ops are created through it
+// from OpAttr and OpSpecAttr, otherwise the nodes run NoOp.
+// It returns error if the DOT graph is not directed or if it contains a cycle.
+func (u *Unmarshaler) Unmarshal(data []byte, g hypher.Graph) error {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return fmt.Errorf("unsupported graph: %T", g)
+	}
+	if hg.Nodes().Len() > 0 {
+		return fmt.Errorf("graph %s is not empty", hg.UID())
+	}
+
+	file, err := dotparser.ParseBytes(data)
+	if err != nil {
+		return err
+	}
+	if len(file.Graphs) != 1 {
+		return fmt.Errorf("invalid number of graphs: %d", len(file.Graphs))
+	}
+	if !file.Graphs[0].Directed {
+		return errors.New("undirected graphs are not supported")
+	}
+
+	b := newBuilder()
+	if err := dot.Unmarshal(data, b); err != nil {
+		return err
+	}
+
+	// gonum ignores top level graph attribute statements
+	// such as label="Graph"; so we collect them from the AST.
+	for _, stmt := range file.Graphs[0].Stmts {
+		if a, ok := stmt.(*ast.Attr); ok {
+			b.attrs[unquote(a.Key)] = unquote(a.Val)
+		}
+	}
+
+	if b.dotid != "" {
+		hg.SetDOTID(b.dotid)
+	}
+	if label, ok := b.attrs[LabelAttr]; ok {
+		hg.SetLabel(label)
+		delete(b.attrs, LabelAttr)
+	}
+	for k, v := range b.attrs {
+		hg.SetAttr(k, v)
+	}
+
+	var inputs, outputs []*graph.Node
+
+	nodes := make(map[int64]*graph.Node, len(b.nodes))
+	for _, dn := range b.nodes {
+		n, err := u.newNode(hg, dn)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", dn.dotid, err)
+		}
+		nodes[dn.id] = n
+
+		isInput, err := parseBool(dn.attrs, InputAttr)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", dn.dotid, err)
+		}
+		if isInput {
+			inputs = append(inputs, n)
+		}
+
+		isOutput, err := parseBool(dn.attrs, OutputAttr)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", dn.dotid, err)
+		}
+		if isOutput {
+			outputs = append(outputs, n)
+		}
+	}
+
+	for _, de := range b.edges {
+		from, to := nodes[de.from.id], nodes[de.to.id]
+		if err := newEdge(hg, from, to, de.attrs); err != nil {
+			return fmt.Errorf("edge %s -> %s: %w", de.from.dotid, de.to.dotid, err)
+		}
+	}
+
+	hg.SetInputs(inputs)
+	hg.SetOutputs(outputs)
+
+	return nil
+}
+
+// newNode creates a new node in g from the DOT node dn.
+func (u *Unmarshaler) newNode(g *graph.Graph, dn *dotNode) (*graph.Node, error) {
+	attrs := maps.Clone(dn.attrs)
+	delete(attrs, InputAttr)
+	delete(attrs, OutputAttr)
+
+	opts := []hypher.Option{
+		hypher.WithDotID(dn.dotid),
+	}
+
+	if label, ok := attrs[LabelAttr]; ok {
+		opts = append(opts, hypher.WithLabel(label))
+		delete(attrs, LabelAttr)
+	}
+
+	if opType, ok := attrs[OpAttr]; ok {
+		var spec []byte
+		if s, ok := attrs[OpSpecAttr]; ok {
+			spec = []byte(s)
+		}
+		delete(attrs, OpAttr)
+		delete(attrs, OpSpecAttr)
+
+		if u.ops != nil {
+			op, err := u.ops.New(opType, spec)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, hypher.WithOp(op))
+		}
+	}
+
+	opts = append(opts, hypher.WithAttrs(toAny(attrs)))
+
+	return g.NewNode(opts...)
+}
+
+// newEdge links from and to nodes in g with a new edge created from DOT attrs.
+func newEdge(g *graph.Graph, from, to *graph.Node, dotAttrs map[string]string) error {
+	if from.ID() == to.ID() {
+		return errors.New("cycle detected: self loop")
+	}
+
+	attrs := maps.Clone(dotAttrs)
+
+	var opts []hypher.Option
+
+	if label, ok := attrs[LabelAttr]; ok {
+		opts = append(opts, hypher.WithLabel(label))
+		delete(attrs, LabelAttr)
+	}
+
+	if w, ok := attrs[WeightAttr]; ok {
+		weight, err := strconv.ParseFloat(w, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", WeightAttr, err)
+		}
+		opts = append(opts, hypher.WithWeight(weight))
+		delete(attrs, WeightAttr)
+	}
+
+	opts = append(opts, hypher.WithAttrs(toAny(attrs)))
+
+	e, err := g.NewEdge(from, to, opts...)
+	if err != nil {
+		return err
+	}
+
+	return g.SetEdge(e)
+}
+
+func parseBool(attrs map[string]string, key string) (bool, error) {
+	v, ok := attrs[key]
+	if !ok {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// unquote unquotes s if it's a quoted DOT ID.
+func unquote(s string) string {
+	if t, err := strconv.Unquote(s); err == nil {
+		return t
+	}
+	return s
+}
+
+func toAny(attrs map[string]string) map[string]any {
+	res := make(map[string]any, len(attrs))
+	for k, v := range attrs {
+		res[k] = v
+	}
+	return res
+}
+
+// attrMap is a DOT attribute setter.
+type attrMap map[string]string
+
+// SetAttribute implements encoding.AttributeSetter.
+func (m attrMap) SetAttribute(attr encoding.Attribute) error {
+	m[attr.Key] = attr.Value
+	return nil
+}
+
+// dotNode is a node decoded from DOT.
+type dotNode struct {
+	id    int64
+	dotid string
+	attrs attrMap
+}
+
+// ID implements gonum graph.Node.
+func (n *dotNode) ID() int64 { return n.id }
+
+// SetDOTID implements dot.DOTIDSetter.
+func (n *dotNode) SetDOTID(id string) { n.dotid = id }
+
+// SetAttribute implements encoding.AttributeSetter.
+func (n *dotNode) SetAttribute(attr encoding.Attribute) error {
+	return n.attrs.SetAttribute(attr)
+}
+
+// dotEdge is an edge decoded from DOT.
+type dotEdge struct {
+	from  *dotNode
+	to    *dotNode
+	attrs attrMap
+}
+
+// From implements gonum graph.Edge.
+func (e *dotEdge) From() gonum.Node { return e.from }
+
+// To implements gonum graph.Edge.
+func (e *dotEdge) To() gonum.Node { return e.to }
+
+// ReversedEdge implements gonum graph.Edge.
+func (e *dotEdge) ReversedEdge() gonum.Edge {
+	return &dotEdge{from: e.to, to: e.from, attrs: e.attrs}
+}
+
+// SetAttribute implements encoding.AttributeSetter.
+func (e *dotEdge) SetAttribute(attr encoding.Attribute) error {
+	return e.attrs.SetAttribute(attr)
+}
+
+// builder implements gonum encoding.Builder.
+// It records DOT nodes and edges in the order they
+// are decoded so that they can be added to hypher graph
+// which validates them e.g. rejects edges creating cycles.
+// Global node and edge attributes apply to the nodes
+// and edges declared after them as per DOT semantics.
+type builder struct {
+	*simple.DirectedGraph
+	dotid     string
+	attrs     attrMap
+	nodeAttrs attrMap
+	edgeAttrs attrMap
+	nodes     []*dotNode
+	edges     []*dotEdge
+}
+
+func newBuilder() *builder {
+	return &builder{
+		DirectedGraph: simple.NewDirectedGraph(),
+		attrs:         make(attrMap),
+		nodeAttrs:     make(attrMap),
+		edgeAttrs:     make(attrMap),
+	}
+}
+
+// SetDOTID implements dot.DOTIDSetter.
+func (b *builder) SetDOTID(id string) { b.dotid = id }
+
+// DOTAttributeSetters implements dot.AttributeSetters.
+func (b *builder) DOTAttributeSetters() (g, n, e encoding.AttributeSetter) {
+	return b.attrs, b.nodeAttrs, b.edgeAttrs
+}
+
+// NewNode implements gonum graph.NodeAdder.
+func (b *builder) NewNode() gonum.Node {
+	return &dotNode{
+		id:    b.DirectedGraph.NewNode().ID(),
+		attrs: maps.Clone(b.nodeAttrs),
+	}
+}
+
+// AddNode implements gonum graph.NodeAdder.
+func (b *builder) AddNode(n gonum.Node) {
+	b.DirectedGraph.AddNode(n)
+	b.nodes = append(b.nodes, n.(*dotNode))
+}
+
+// NewEdge implements gonum graph.EdgeAdder.
+// It returns the existing edge if from and to are already linked.
+func (b *builder) NewEdge(from, to gonum.Node) gonum.Edge {
+	if e := b.Edge(from.ID(), to.ID()); e != nil {
+		return e
+	}
+	return &dotEdge{
+		from:  from.(*dotNode),
+		to:    to.(*dotNode),
+		attrs: maps.Clone(b.edgeAttrs),
+	}
+}
+
+// SetEdge implements gonum graph.EdgeAdder.
+// Self loops are recorded, but not added to the underlying
+// simple graph which does not allow them; they are rejected
+// when the edges are added to hypher graph.
+func (b *builder) SetEdge(e gonum.Edge) {
+	if b.Edge(e.From().ID(), e.To().ID()) != nil {
+		return
+	}
+	if e.From().ID() != e.To().ID() {
+		b.DirectedGraph.SetEdge(e)
+	}
+	b.edges = append(b.edges, e.(*dotEdge))
+}
@@ -0,0 +1,262 @@
+package dot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// greetOp is a test Op with a spec.
+type greetOp struct {
+	Greeting string `json:"greeting"`
+}
+
+func (op *greetOp) Type() string   { return "GreetOp" }
+func (op *greetOp) Desc() string   { return "GreetOp greets" }
+func (op *greetOp) String() string { return op.Type() }
+func (op *greetOp) Do(_ context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	return []hypher.Value{{"greeting": op.Greeting}}, nil
+}
+
+func MustOpRegistry(t *testing.T) *graph.OpRegistry {
+	r := graph.NewOpRegistry()
+	if err := r.Register("GreetOp", func(spec []byte) (hypher.Op, error) {
+		op := &greetOp{}
+		if err := json.Unmarshal(spec, op); err != nil {
+			return nil, err
+		}
+		return op, nil
+	}); err != nil {
+		t.Fatalf("failed to register op: %v", err)
+	}
+	return r
+}
+
+func MustGraph(t *testing.T) *graph.Graph {
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	return g
+}
+
+func MustUnmarshal(t *testing.T, data string, opts ...Option) *graph.Graph {
+	u, err := NewUnmarshaler(opts...)
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+	g := MustGraph(t)
+	if err := u.Unmarshal([]byte(data), g); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	return g
+}
+
+func nodeByDOTID(t *testing.T, g *graph.Graph, dotid string) *graph.Node {
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		if n.DOTID() == dotid {
+			return n
+		}
+	}
+	t.Fatalf("node %s not found", dotid)
+	return nil
+}
+
+const agentDOT = `digraph agent {
+	label="Agent";
+	rankdir=LR;
+	node [shape=box];
+	in [label="Input", hypher_input=true];
+	greet [label="Greet", op=GreetOp, op_spec="{\"greeting\":\"hello\"}", color=red];
+	out [label="Output", hypher_output=true];
+	in -> greet [label="prompt", weight=2.5, style=dashed];
+	greet -> out;
+}`
+
+func TestUnmarshal(t *testing.T) {
+	g := MustUnmarshal(t, agentDOT, WithOpRegistry(MustOpRegistry(t)))
+
+	if g.DOTID() != "agent" {
+		t.Errorf("expected DOT ID: agent, got: %s", g.DOTID())
+	}
+	if g.Label() != "Agent" {
+		t.Errorf("expected label: Agent, got: %s", g.Label())
+	}
+	if v := g.Attrs()["rankdir"]; v != "LR" {
+		t.Errorf("expected rankdir attr: LR, got: %v", v)
+	}
+
+	if n := g.Nodes().Len(); n != 3 {
+		t.Fatalf("expected 3 nodes, got: %d", n)
+	}
+	if e := g.Edges().Len(); e != 2 {
+		t.Fatalf("expected 2 edges, got: %d", e)
+	}
+
+	in := nodeByDOTID(t, g, "in")
+	greet := nodeByDOTID(t, g, "greet")
+	out := nodeByDOTID(t, g, "out")
+
+	if greet.Label() != "Greet" {
+		t.Errorf("expected label: Greet, got: %s", greet.Label())
+	}
+	if v := greet.Attrs()["color"]; v != "red" {
+		t.Errorf("expected color attr: red, got: %v", v)
+	}
+	if v := greet.Attrs()["shape"]; v != "box" {
+		t.Errorf("expected global shape attr: box, got: %v", v)
+	}
+	for _, k := range []string{LabelAttr, OpAttr, OpSpecAttr} {
+		if _, ok := greet.Attrs()[k]; ok {
+			t.Errorf("unexpected attr: %s", k)
+		}
+	}
+	op, ok := greet.Op().(*greetOp)
+	if !ok {
+		t.Fatalf("expected *greetOp, got: %T", greet.Op())
+	}
+	if op.Greeting != "hello" {
+		t.Errorf("expected greeting: hello, got: %s", op.Greeting)
+	}
+
+	if _, ok := in.Attrs()[InputAttr]; ok {
+		t.Errorf("unexpected attr: %s", InputAttr)
+	}
+	if inputs := g.Inputs(); len(inputs) != 1 || inputs[0] != in {
+		t.Errorf("expected inputs: [in], got: %v", inputs)
+	}
+	if outputs := g.Outputs(); len(outputs) != 1 || outputs[0] != out {
+		t.Errorf("expected outputs: [out], got: %v", outputs)
+	}
+
+	e := g.Edge(in.ID(), greet.ID()).(*graph.Edge)
+	if e.Label() != "prompt" {
+		t.Errorf("expected edge label: prompt, got: %s", e.Label())
+	}
+	if e.Weight() != 2.5 {
+		t.Errorf("expected edge weight: 2.5, got: %f", e.Weight())
+	}
+	if v := e.Attrs()["style"]; v != "dashed" {
+		t.Errorf("expected style attr: dashed, got: %v", v)
+	}
+	for _, k := range []string{LabelAttr, WeightAttr} {
+		if _, ok := e.Attrs()[k]; ok {
+			t.Errorf("unexpected edge attr: %s", k)
+		}
+	}
+
+	e = g.Edge(greet.ID(), out.ID()).(*graph.Edge)
+	if e.Weight() != graph.DefaultEdgeWeight {
+		t.Errorf("expected default edge weight, got: %f", e.Weight())
+	}
+
+	if err := g.Run(context.Background(), nil); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+}
+
+func TestUnmarshalNilAttrs(t *testing.T) {
+	u, err := NewUnmarshaler(WithOpRegistry(MustOpRegistry(t)))
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+	g, err := graph.NewGraph(hypher.WithAttrs(nil))
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	if err := u.Unmarshal([]byte(agentDOT), g); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if v := g.Attrs()["rankdir"]; v != "LR" {
+		t.Errorf("expected rankdir attr: LR, got: %v", v)
+	}
+}
+
+func TestUnmarshalNoRegistry(t *testing.T) {
+	g := MustUnmarshal(t, agentDOT)
+
+	greet := nodeByDOTID(t, g, "greet")
+	if _, ok := greet.Op().(graph.NoOp); !ok {
+		t.Errorf("expected NoOp, got: %T", greet.Op())
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "cycle",
+			data: `digraph { a -> b; b -> c; c -> a; }`,
+			want: "edge c -> a",
+		},
+		{
+			name: "self loop",
+			data: `digraph { a -> a; }`,
+			want: "edge a -> a",
+		},
+		{
+			name: "undirected",
+			data: `graph { a -- b; }`,
+			want: "undirected",
+		},
+		{
+			name: "invalid weight",
+			data: `digraph { a -> b [weight=heavy]; }`,
+			want: "invalid weight",
+		},
+		{
+			name: "invalid input",
+			data: `digraph { a [hypher_input=maybe]; }`,
+			want: "node a: invalid hypher_input",
+		},
+		{
+			name: "unknown op",
+			data: `digraph { a [op=UnknownOp]; }`,
+			want: "node a:",
+		},
+		{
+			name: "syntax",
+			data: `digraph { a -> }`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := NewUnmarshaler(WithOpRegistry(MustOpRegistry(t)))
+			if err != nil {
+				t.Fatalf("failed to create unmarshaler: %v", err)
+			}
+			err = u.Unmarshal([]byte(tc.data), MustGraph(t))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalNonEmptyGraph(t *testing.T) {
+	g := MustGraph(t)
+	if _, err := g.NewNode(); err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+
+	u, err := NewUnmarshaler()
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+	if err := u.Unmarshal([]byte(agentDOT), g); err == nil {
+		t.Fatal("expected error")
+	}
+}
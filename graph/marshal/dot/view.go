@@ -0,0 +1,204 @@
+package dot
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+
+	"github.com/milosgajdos/go-hypher/graph"
+
+	gonum "gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
+	"gonum.org/v1/gonum/graph/iterator"
+)
+
+// graphView is a styled read-only view of a graph.
+// It carries its own DOT attributes so marshaling
+// the view does not modify the viewed graph.
+type graphView struct {
+	*graph.Graph
+	attrs []encoding.Attribute
+	nodes map[int64]*nodeView
+	edges map[int64]map[int64]*edgeView
+}
+
+// nodeView is a styled view of a node.
+type nodeView struct {
+	*graph.Node
+	attrs []encoding.Attribute
+}
+
+// Attributes implements encoding.Attributer.
+func (n *nodeView) Attributes() []encoding.Attribute { return n.attrs }
+
+// edgeView is a styled view of an edge.
+type edgeView struct {
+	*graph.Edge
+	from  *nodeView
+	to    *nodeView
+	attrs []encoding.Attribute
+}
+
+// From implements gonum graph.Edge.
+func (e *edgeView) From() gonum.Node { return e.from }
+
+// To implements gonum graph.Edge.
+func (e *edgeView) To() gonum.Node { return e.to }
+
+// Attributes implements encoding.Attributer.
+func (e *edgeView) Attributes() []encoding.Attribute { return e.attrs }
+
+// newView creates a styled view of g.
+func (m *Marshaler) newView(g *graph.Graph) *graphView {
+	attrs := graph.AttrsToStringMap(g.Attrs())
+	attrs["label"] = g.Label()
+	applyStyle(attrs, m.graphStyle, "bgcolor")
+
+	v := &graphView{
+		Graph: g,
+		attrs: toAttributes(attrs),
+		nodes: make(map[int64]*nodeView),
+		edges: make(map[int64]map[int64]*edgeView),
+	}
+
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+
+		attrs := graph.AttrsToStringMap(n.Attrs())
+		attrs["label"] = n.Label()
+		applyStyle(attrs, m.nodeStyle, "color")
+		for _, r := range m.nodeRules {
+			if r.Match(n) {
+				applyStyle(attrs, r.Style, "color")
+			}
+		}
+		if m.run != nil {
+			applyRunState(attrs, RunState(m.run, n))
+		}
+
+		v.nodes[n.ID()] = &nodeView{
+			Node:  n,
+			attrs: toAttributes(attrs),
+		}
+	}
+
+	edges := g.Edges()
+	for edges.Next() {
+		e := edges.Edge().(*graph.Edge)
+
+		attrs := graph.AttrsToStringMap(e.Attrs())
+		attrs["label"] = e.Label()
+		applyStyle(attrs, m.edgeStyle, "color")
+		for _, r := range m.edgeRules {
+			if r.Match(e) {
+				applyStyle(attrs, r.Style, "color")
+			}
+		}
+
+		from, to := e.From().ID(), e.To().ID()
+		if v.edges[from] == nil {
+			v.edges[from] = make(map[int64]*edgeView)
+		}
+		v.edges[from][to] = &edgeView{
+			Edge:  e,
+			from:  v.nodes[from],
+			to:    v.nodes[to],
+			attrs: toAttributes(attrs),
+		}
+	}
+
+	return v
+}
+
+// DOTAttributers implements dot.Attributers.
+func (v *graphView) DOTAttributers() (g, n, e encoding.Attributer) {
+	return v, nil, nil
+}
+
+// Attributes implements encoding.Attributer.
+func (v *graphView) Attributes() []encoding.Attribute { return v.attrs }
+
+// Node returns the view of the node with the given ID.
+func (v *graphView) Node(id int64) gonum.Node {
+	if n, ok := v.nodes[id]; ok {
+		return n
+	}
+	return nil
+}
+
+// Nodes returns the views of all graph nodes.
+func (v *graphView) Nodes() gonum.Nodes {
+	return v.nodesOf(v.Graph.Nodes())
+}
+
+// From returns the views of the nodes reachable from the node with the given ID.
+func (v *graphView) From(id int64) gonum.Nodes {
+	return v.nodesOf(v.Graph.From(id))
+}
+
+// To returns the views of the nodes reaching the node with the given ID.
+func (v *graphView) To(id int64) gonum.Nodes {
+	return v.nodesOf(v.Graph.To(id))
+}
+
+// Edge returns the view of the edge from uid to vid.
+func (v *graphView) Edge(uid, vid int64) gonum.Edge {
+	if e, ok := v.edges[uid][vid]; ok {
+		return e
+	}
+	return nil
+}
+
+func (v *graphView) nodesOf(it gonum.Nodes) gonum.Nodes {
+	nodes := make([]gonum.Node, 0, it.Len())
+	for it.Next() {
+		nodes = append(nodes, v.nodes[it.Node().ID()])
+	}
+	return iterator.NewOrderedNodes(nodes)
+}
+
+// applyStyle applies style s to DOT attrs.
+// Style color is set as colorKey attribute.
+// Empty style fields are not applied.
+func applyStyle(attrs map[string]string, s Style, colorKey string) {
+	if s.Shape != "" {
+		attrs["shape"] = s.Shape
+	}
+	if s.Type != "" {
+		attrs["style"] = s.Type
+	}
+	if s.Color != (color.RGBA{}) {
+		attrs[colorKey] = fmt.Sprintf("#%02x%02x%02x", s.Color.R, s.Color.G, s.Color.B)
+	}
+	for k, v := range graph.AttrsToStringMap(s.Attrs) {
+		attrs[k] = v
+	}
+}
+
+// applyRunState applies node run state s to DOT attrs.
+func applyRunState(attrs map[string]string, s NodeRun) {
+	applyStyle(attrs, Style{Color: StatusColors[s.Status]}, "color")
+	if elapsed := s.Elapsed(); elapsed != "" {
+		attrs["label"] += "\n" + elapsed
+	}
+	attrs["tooltip"] = string(s.Status)
+	if s.Err != nil {
+		attrs["tooltip"] = s.Err.Error()
+	}
+}
+
+// toAttributes returns attrs as DOT attributes sorted by key.
+func toAttributes(attrs map[string]string) []encoding.Attribute {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	res := make([]encoding.Attribute, 0, len(attrs))
+	for _, k := range keys {
+		res = append(res, encoding.Attribute{Key: k, Value: attrs[k]})
+	}
+	return res
+}
@@ -0,0 +1,387 @@
+// Package graphml provides GraphML encoding of hypher graphs.
+package graphml
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// Namespace is GraphML XML namespace.
+const Namespace = "http://graphml.graphdrawing.org/xmlns"
+
+// Key domains.
+const (
+	ForGraph = "graph"
+	ForNode  = "node"
+	ForEdge  = "edge"
+	ForAll   = "all"
+)
+
+// Key types.
+const (
+	TypeBoolean = "boolean"
+	TypeInt     = "int"
+	TypeLong    = "long"
+	TypeFloat   = "float"
+	TypeDouble  = "double"
+	TypeString  = "string"
+)
+
+// Reserved key names.
+// Attributes with reserved names are not encoded.
+const (
+	LabelKey  = "label"
+	WeightKey = "weight"
+	UIDKey    = "uid"
+	DOTIDKey  = "dotid"
+	InputKey  = "hypher_input"
+	OutputKey = "hypher_output"
+)
+
+// GraphML is GraphML document.
+type GraphML struct {
+	XMLName xml.Name `xml:"graphml"`
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	Keys    []Key    `xml:"key"`
+	Graphs  []Graph  `xml:"graph"`
+}
+
+// Key declares GraphML data key.
+type Key struct {
+	ID      string  `xml:"id,attr"`
+	For     string  `xml:"for,attr,omitempty"`
+	Name    string  `xml:"attr.name,attr,omitempty"`
+	Type    string  `xml:"attr.type,attr,omitempty"`
+	Default *string `xml:"default,omitempty"`
+}
+
+// Graph is GraphML graph.
+type Graph struct {
+	ID          string `xml:"id,attr,omitempty"`
+	EdgeDefault string `xml:"edgedefault,attr,omitempty"`
+	Data        []Data `xml:"data"`
+	Nodes       []Node `xml:"node"`
+	Edges       []Edge `xml:"edge"`
+}
+
+// Node is GraphML node.
+type Node struct {
+	ID   string `xml:"id,attr"`
+	Data []Data `xml:"data"`
+}
+
+// Edge is GraphML edge.
+type Edge struct {
+	ID     string `xml:"id,attr,omitempty"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	Data   []Data `xml:"data"`
+}
+
+// Data is GraphML data.
+type Data struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Marshaler implements hypher.Marshaler.
+type Marshaler struct {
+	prefix string
+	indent string
+}
+
+// NewMarshaler creates a new Marshaler and returns it.
+func NewMarshaler(prefix, indent string) (*Marshaler, error) {
+	return &Marshaler{
+		prefix: prefix,
+		indent: indent,
+	}, nil
+}
+
+// Marshal marshals g into GraphML.
+// Graph, node and edge labels, node and edge UIDs, node DOT IDs and
+// edge weights are encoded as data of the reserved keys. Graph input
+// and output nodes are marked with InputKey and OutputKey boolean data
+// which default to false.
+// Attributes are encoded as data of typed keys: booleans, integers and
+// floats get the matching GraphML type, all the other values are encoded
+// as strings. Attributes whose values have different types are encoded
+// with the widest of the types.
+func (m *Marshaler) Marshal(g hypher.Graph) ([]byte, error) {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return nil, fmt.Errorf("unsupported graph: %T", g)
+	}
+
+	var nodes []*graph.Node
+	nit := hg.Nodes()
+	for nit.Next() {
+		nodes = append(nodes, nit.Node().(*graph.Node))
+	}
+	slices.SortFunc(nodes, func(a, b *graph.Node) int { return cmp.Compare(a.ID(), b.ID()) })
+
+	var edges []*graph.Edge
+	eit := hg.Edges()
+	for eit.Next() {
+		edges = append(edges, eit.Edge().(*graph.Edge))
+	}
+	slices.SortFunc(edges, func(a, b *graph.Edge) int {
+		return cmp.Or(cmp.Compare(a.From().ID(), b.From().ID()), cmp.Compare(a.To().ID(), b.To().ID()))
+	})
+
+	keys := newKeySet()
+	keys.add(ForGraph, LabelKey, TypeString)
+	keys.add(ForNode, UIDKey, TypeString)
+	keys.add(ForNode, DOTIDKey, TypeString)
+	keys.add(ForNode, LabelKey, TypeString)
+	keys.add(ForNode, InputKey, TypeBoolean)
+	keys.add(ForNode, OutputKey, TypeBoolean)
+	keys.setDefault(ForNode, InputKey, "false")
+	keys.setDefault(ForNode, OutputKey, "false")
+	keys.add(ForEdge, UIDKey, TypeString)
+	keys.add(ForEdge, LabelKey, TypeString)
+	keys.add(ForEdge, WeightKey, TypeDouble)
+
+	keys.addAttrs(ForGraph, hg.Attrs())
+	for _, n := range nodes {
+		keys.addAttrs(ForNode, n.Attrs())
+	}
+	for _, e := range edges {
+		keys.addAttrs(ForEdge, e.Attrs())
+	}
+
+	gg := Graph{
+		ID:          "G",
+		EdgeDefault: "directed",
+		Data:        []Data{keys.data(ForGraph, LabelKey, hg.Label())},
+		Nodes:       make([]Node, 0, len(nodes)),
+		Edges:       make([]Edge, 0, len(edges)),
+	}
+	attrs, err := keys.encodeAttrs(ForGraph, hg.Attrs())
+	if err != nil {
+		return nil, fmt.Errorf("graph: %w", err)
+	}
+	gg.Data = append(gg.Data, attrs...)
+
+	inputs := make(map[int64]bool)
+	for _, n := range hg.Inputs() {
+		inputs[n.ID()] = true
+	}
+	outputs := make(map[int64]bool)
+	for _, n := range hg.Outputs() {
+		outputs[n.ID()] = true
+	}
+
+	for _, n := range nodes {
+		data := []Data{
+			keys.data(ForNode, UIDKey, n.UID()),
+			keys.data(ForNode, DOTIDKey, n.DOTID()),
+			keys.data(ForNode, LabelKey, n.Label()),
+		}
+		if inputs[n.ID()] {
+			data = append(data, keys.data(ForNode, InputKey, "true"))
+		}
+		if outputs[n.ID()] {
+			data = append(data, keys.data(ForNode, OutputKey, "true"))
+		}
+		attrs, err := keys.encodeAttrs(ForNode, n.Attrs())
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.UID(), err)
+		}
+		gg.Nodes = append(gg.Nodes, Node{
+			ID:   nodeID(n),
+			Data: append(data, attrs...),
+		})
+	}
+
+	for i, e := range edges {
+		data := []Data{
+			keys.data(ForEdge, UIDKey, e.UID()),
+			keys.data(ForEdge, LabelKey, e.Label()),
+			keys.data(ForEdge, WeightKey, strconv.FormatFloat(e.Weight(), 'g', -1, 64)),
+		}
+		attrs, err := keys.encodeAttrs(ForEdge, e.Attrs())
+		if err != nil {
+			return nil, fmt.Errorf("edge %s: %w", e.UID(), err)
+		}
+		gg.Edges = append(gg.Edges, Edge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: nodeID(e.From().(*graph.Node)),
+			Target: nodeID(e.To().(*graph.Node)),
+			Data:   append(data, attrs...),
+		})
+	}
+
+	doc := GraphML{
+		XMLNS:  Namespace,
+		Keys:   keys.keys,
+		Graphs: []Graph{gg},
+	}
+
+	out, err := xml.MarshalIndent(doc, m.prefix, m.indent)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+func nodeID(n *graph.Node) string {
+	return fmt.Sprintf("n%d", n.ID())
+}
+
+// keySet is a set of GraphML keys.
+type keySet struct {
+	keys  []Key
+	index map[[2]string]int
+}
+
+func newKeySet() *keySet {
+	return &keySet{
+		index: make(map[[2]string]int),
+	}
+}
+
+// add adds a new key or widens the type of an existing key.
+func (s *keySet) add(domain, name, typ string) {
+	if i, ok := s.index[[2]string{domain, name}]; ok {
+		s.keys[i].Type = widen(s.keys[i].Type, typ)
+		return
+	}
+	s.index[[2]string{domain, name}] = len(s.keys)
+	s.keys = append(s.keys, Key{
+		ID:   fmt.Sprintf("d%d", len(s.keys)),
+		For:  domain,
+		Name: name,
+		Type: typ,
+	})
+}
+
+// setDefault sets the default value of an existing key.
+func (s *keySet) setDefault(domain, name, val string) {
+	s.keys[s.index[[2]string{domain, name}]].Default = &val
+}
+
+// addAttrs adds keys for attrs of the given domain.
+// Attributes are added in the order of their names.
+func (s *keySet) addAttrs(domain string, attrs map[string]any) {
+	for _, k := range sortedKeys(attrs) {
+		if reserved(domain, k) {
+			continue
+		}
+		s.add(domain, k, typeOf(attrs[k]))
+	}
+}
+
+// data returns data of the key with the given domain and name.
+func (s *keySet) data(domain, name, val string) Data {
+	return Data{
+		Key:   s.keys[s.index[[2]string{domain, name}]].ID,
+		Value: val,
+	}
+}
+
+// encodeAttrs encodes attrs of the given domain into data.
+func (s *keySet) encodeAttrs(domain string, attrs map[string]any) ([]Data, error) {
+	data := make([]Data, 0, len(attrs))
+	for _, k := range sortedKeys(attrs) {
+		if reserved(domain, k) {
+			continue
+		}
+		val, err := encodeValue(attrs[k])
+		if err != nil {
+			return nil, fmt.Errorf("attr %s: %w", k, err)
+		}
+		data = append(data, s.data(domain, k, val))
+	}
+	return data, nil
+}
+
+// reserved returns true if name is a reserved key name of domain.
+func reserved(domain, name string) bool {
+	switch domain {
+	case ForGraph:
+		return name == LabelKey
+	case ForNode:
+		return slices.Contains([]string{UIDKey, DOTIDKey, LabelKey, InputKey, OutputKey}, name)
+	case ForEdge:
+		return slices.Contains([]string{UIDKey, LabelKey, WeightKey}, name)
+	}
+	return false
+}
+
+// typeOf returns GraphML type of v.
+func typeOf(v any) string {
+	switch v.(type) {
+	case bool:
+		return TypeBoolean
+	case int, int8, int16, int32, uint8, uint16:
+		return TypeInt
+	case int64, uint, uint32, uint64:
+		return TypeLong
+	case float32:
+		return TypeFloat
+	case float64:
+		return TypeDouble
+	default:
+		return TypeString
+	}
+}
+
+// widen returns the type which can hold values of both a and b.
+func widen(a, b string) string {
+	if a == b {
+		return a
+	}
+	rank := map[string]int{TypeInt: 1, TypeLong: 2, TypeFloat: 3, TypeDouble: 4}
+	ra, okA := rank[a]
+	rb, okB := rank[b]
+	switch {
+	case !okA || !okB:
+		return TypeString
+	case ra >= 3 || rb >= 3:
+		return TypeDouble
+	default:
+		return TypeLong
+	}
+}
+
+// encodeValue encodes v into GraphML data value.
+func encodeValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func sortedKeys(attrs map[string]any) []string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
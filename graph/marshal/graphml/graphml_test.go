@@ -0,0 +1,326 @@
+package graphml
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func MustGraph(t *testing.T, opts ...hypher.Option) *graph.Graph {
+	g, err := graph.NewGraph(opts...)
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	return g
+}
+
+// MustAgentGraph returns graph: in -> plan -> out.
+func MustAgentGraph(t *testing.T) *graph.Graph {
+	g := MustGraph(t,
+		hypher.WithLabel("Agent"),
+		hypher.WithAttrs(map[string]any{"version": 2}),
+	)
+
+	newNode := func(uid string, attrs map[string]any) *graph.Node {
+		n, err := g.NewNode(
+			hypher.WithUID(uid),
+			hypher.WithDotID(uid+"_dot"),
+			hypher.WithLabel(strings.ToUpper(uid)),
+			hypher.WithAttrs(attrs),
+		)
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		return n
+	}
+
+	in := newNode("in", map[string]any{"name": "query", "retries": 3, "cached": true})
+	plan := newNode("plan", map[string]any{"temperature": 0.7, "retries": int64(5), "tags": []string{"llm"}})
+	out := newNode("out", map[string]any{"cached": false, "score": float32(0.5)})
+
+	for _, pair := range [][2]*graph.Node{{in, plan}, {plan, out}} {
+		e, err := g.NewEdge(pair[0], pair[1],
+			hypher.WithUID(pair[0].UID()+"-"+pair[1].UID()),
+			hypher.WithLabel("next"),
+			hypher.WithWeight(2.5),
+			hypher.WithAttrs(map[string]any{"cost": 1.5}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create edge: %v", err)
+		}
+		if err := g.SetEdge(e); err != nil {
+			t.Fatalf("failed to set edge: %v", err)
+		}
+	}
+
+	g.SetInputs([]*graph.Node{in})
+	g.SetOutputs([]*graph.Node{out})
+
+	return g
+}
+
+func MustUnmarshal(t *testing.T, data []byte) *graph.Graph {
+	u, err := NewUnmarshaler()
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+	g := MustGraph(t)
+	if err := u.Unmarshal(data, g); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	return g
+}
+
+func nodeByDOTID(t *testing.T, g *graph.Graph, dotid string) *graph.Node {
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		if n.DOTID() == dotid {
+			return n
+		}
+	}
+	t.Fatalf("node %s not found", dotid)
+	return nil
+}
+
+func TestMarshalKeys(t *testing.T) {
+	m, err := NewMarshaler("", "  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+
+	data, err := m.Marshal(MustAgentGraph(t))
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("missing XML header")
+	}
+
+	var doc GraphML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to unmarshal GraphML: %v", err)
+	}
+
+	types := make(map[string]string)
+	for _, k := range doc.Keys {
+		types[k.For+"."+k.Name] = k.Type
+	}
+
+	want := map[string]string{
+		"graph.label":        TypeString,
+		"graph.version":      TypeInt,
+		"node.uid":           TypeString,
+		"node.dotid":         TypeString,
+		"node.label":         TypeString,
+		"node.hypher_input":  TypeBoolean,
+		"node.hypher_output": TypeBoolean,
+		"node.name":          TypeString,
+		"node.retries":       TypeLong,
+		"node.cached":        TypeBoolean,
+		"node.temperature":   TypeDouble,
+		"node.tags":          TypeString,
+		"node.score":         TypeFloat,
+		"edge.uid":           TypeString,
+		"edge.label":         TypeString,
+		"edge.weight":        TypeDouble,
+		"edge.cost":          TypeDouble,
+	}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("expected keys:\n%v\ngot:\n%v", want, types)
+	}
+
+	if g := doc.Graphs[0]; g.EdgeDefault != "directed" || len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Errorf("unexpected graph: %+v", g)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	m, err := NewMarshaler("", "  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+
+	data, err := m.Marshal(MustAgentGraph(t))
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	g := MustUnmarshal(t, data)
+
+	if g.Label() != "Agent" {
+		t.Errorf("expected label: Agent, got: %s", g.Label())
+	}
+	if v := g.Attrs()["version"]; v != 2 {
+		t.Errorf("expected version: 2, got: %#v", v)
+	}
+
+	in := nodeByDOTID(t, g, "in_dot")
+	plan := nodeByDOTID(t, g, "plan_dot")
+	out := nodeByDOTID(t, g, "out_dot")
+
+	if in.UID() != "in" || in.Label() != "IN" {
+		t.Errorf("unexpected node uid: %s, label: %s", in.UID(), in.Label())
+	}
+
+	testCases := []struct {
+		node  *graph.Node
+		attrs map[string]any
+	}{
+		{in, map[string]any{"name": "query", "retries": int64(3), "cached": true}},
+		{plan, map[string]any{"temperature": 0.7, "retries": int64(5), "tags": `["llm"]`}},
+		{out, map[string]any{"cached": false, "score": float32(0.5)}},
+	}
+	for _, tc := range testCases {
+		if !reflect.DeepEqual(tc.node.Attrs(), tc.attrs) {
+			t.Errorf("node %s: expected attrs: %#v, got: %#v", tc.node.UID(), tc.attrs, tc.node.Attrs())
+		}
+	}
+
+	e := g.Edge(in.ID(), plan.ID()).(*graph.Edge)
+	if e.UID() != "in-plan" || e.Label() != "next" || e.Weight() != 2.5 {
+		t.Errorf("unexpected edge uid: %s, label: %s, weight: %f", e.UID(), e.Label(), e.Weight())
+	}
+	if v := e.Attrs()["cost"]; v != 1.5 {
+		t.Errorf("expected cost: 1.5, got: %#v", v)
+	}
+	if g.Edge(plan.ID(), out.ID()) == nil {
+		t.Error("missing edge plan -> out")
+	}
+
+	if inputs := g.Inputs(); len(inputs) != 1 || inputs[0] != in {
+		t.Errorf("expected inputs: [in], got: %v", inputs)
+	}
+	if outputs := g.Outputs(); len(outputs) != 1 || outputs[0] != out {
+		t.Errorf("expected outputs: [out], got: %v", outputs)
+	}
+}
+
+func TestUnmarshalNilAttrs(t *testing.T) {
+	m, err := NewMarshaler("", "  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(MustAgentGraph(t))
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	u, err := NewUnmarshaler()
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+	g := MustGraph(t, hypher.WithAttrs(nil))
+	if err := u.Unmarshal(data, g); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if v := g.Attrs()["version"]; v != 2 {
+		t.Errorf("expected version: 2, got: %#v", v)
+	}
+}
+
+func TestUnmarshalForeign(t *testing.T) {
+	// GraphML as written by NetworkX and yEd
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns"
+         xmlns:y="http://www.yworks.com/xml/graphml">
+  <key id="d0" for="node" attr.name="color" attr.type="string"><default>gray</default></key>
+  <key id="d1" for="edge" attr.name="weight" attr.type="double"/>
+  <key id="d2" for="node" yfiles.type="nodegraphics"/>
+  <key id="d3" for="all" attr.name="rank" attr.type="int"/>
+  <graph id="G" edgedefault="directed">
+    <node id="a">
+      <data key="d0">red</data>
+      <data key="d2"><y:ShapeNode><y:NodeLabel>A</y:NodeLabel></y:ShapeNode></data>
+    </node>
+    <node id="b"><data key="d3"> 7 </data></node>
+    <edge source="a" target="b"><data key="d1">0.25</data></edge>
+  </graph>
+</graphml>`
+
+	g := MustUnmarshal(t, []byte(data))
+
+	a, b := nodeByDOTID(t, g, "a"), nodeByDOTID(t, g, "b")
+	if !reflect.DeepEqual(a.Attrs(), map[string]any{"color": "red"}) {
+		t.Errorf("unexpected node a attrs: %#v", a.Attrs())
+	}
+	if !reflect.DeepEqual(b.Attrs(), map[string]any{"color": "gray", "rank": 7}) {
+		t.Errorf("unexpected node b attrs: %#v", b.Attrs())
+	}
+	if a.Label() != graph.DefaultNodeLabel {
+		t.Errorf("expected default label, got: %s", a.Label())
+	}
+	if w := g.Edge(a.ID(), b.ID()).(*graph.Edge).Weight(); w != 0.25 {
+		t.Errorf("expected weight: 0.25, got: %f", w)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	const key = `<key id="d0" for="node" attr.name="n" attr.type="int"/>`
+
+	testCases := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "undirected",
+			data: `<graphml><graph edgedefault="undirected"/></graphml>`,
+			want: "undirected",
+		},
+		{
+			name: "cycle",
+			data: `<graphml><graph edgedefault="directed">
+				<node id="a"/><node id="b"/>
+				<edge source="a" target="b"/><edge source="b" target="a"/>
+			</graph></graphml>`,
+			want: "edge b -> a",
+		},
+		{
+			name: "unknown node",
+			data: `<graphml><graph><node id="a"/><edge source="a" target="x"/></graph></graphml>`,
+			want: "unknown target node: x",
+		},
+		{
+			name: "duplicate node",
+			data: `<graphml><graph><node id="a"/><node id="a"/></graph></graphml>`,
+			want: "duplicate id",
+		},
+		{
+			name: "unknown key",
+			data: `<graphml><graph><node id="a"><data key="d9">1</data></node></graph></graphml>`,
+			want: "unknown key: d9",
+		},
+		{
+			name: "invalid value",
+			data: `<graphml>` + key + `<graph><node id="a"><data key="d0">x</data></node></graph></graphml>`,
+			want: "node a: attr n",
+		},
+		{
+			name: "graphs",
+			data: `<graphml/>`,
+			want: "invalid number of graphs",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := NewUnmarshaler()
+			if err != nil {
+				t.Fatalf("failed to create unmarshaler: %v", err)
+			}
+			err = u.Unmarshal([]byte(tc.data), MustGraph(t))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
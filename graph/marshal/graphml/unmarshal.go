@@ -0,0 +1,247 @@
+package graphml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// Unmarshaler implements hypher.Unmarshaler.
+type Unmarshaler struct{}
+
+// NewUnmarshaler creates a new Unmarshaler and returns it.
+func NewUnmarshaler() (*Unmarshaler, error) {
+	return &Unmarshaler{}, nil
+}
+
+// Unmarshal unmarshals GraphML data into g which must be an empty *graph.Graph.
+// Data of the reserved keys are mapped to labels, UIDs, DOT IDs, edge weights
+// and graph input and output nodes. GraphML node IDs are used as DOT IDs of the
+// nodes which have no DOT ID data. All the other data are decoded into attrs
+// as per their key types. Data of the keys which declare no attribute name,
+// such as yFiles graphics, are ignored.
+// It returns error if the GraphML graph is undirected or if it contains a cycle.
+func (u *Unmarshaler) Unmarshal(data []byte, g hypher.Graph) error {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return fmt.Errorf("unsupported graph: %T", g)
+	}
+	if hg.Nodes().Len() > 0 {
+		return fmt.Errorf("graph %s is not empty", hg.UID())
+	}
+
+	var doc GraphML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	if len(doc.Graphs) != 1 {
+		return fmt.Errorf("invalid number of graphs: %d", len(doc.Graphs))
+	}
+	gg := doc.Graphs[0]
+	if gg.EdgeDefault == "undirected" {
+		return errors.New("undirected graphs are not supported")
+	}
+
+	keys := make(map[string]Key, len(doc.Keys))
+	for _, k := range doc.Keys {
+		keys[k.ID] = k
+	}
+
+	gdata, err := decodeData(keys, ForGraph, gg.Data)
+	if err != nil {
+		return fmt.Errorf("graph: %w", err)
+	}
+	if label, ok := gdata[LabelKey].(string); ok {
+		hg.SetLabel(label)
+		delete(gdata, LabelKey)
+	}
+	for k, v := range gdata {
+		hg.SetAttr(k, v)
+	}
+
+	var inputs, outputs []*graph.Node
+
+	nodes := make(map[string]*graph.Node, len(gg.Nodes))
+	for _, gn := range gg.Nodes {
+		if _, ok := nodes[gn.ID]; ok {
+			return fmt.Errorf("node %s: duplicate id", gn.ID)
+		}
+
+		attrs, err := decodeData(keys, ForNode, gn.Data)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", gn.ID, err)
+		}
+
+		opts := []hypher.Option{
+			hypher.WithDotID(gn.ID),
+		}
+		if uid, ok := attrs[UIDKey].(string); ok && uid != "" {
+			opts = append(opts, hypher.WithUID(uid))
+		}
+		if dotid, ok := attrs[DOTIDKey].(string); ok && dotid != "" {
+			opts = append(opts, hypher.WithDotID(dotid))
+		}
+		if label, ok := attrs[LabelKey].(string); ok {
+			opts = append(opts, hypher.WithLabel(label))
+		}
+		isInput, _ := attrs[InputKey].(bool)
+		isOutput, _ := attrs[OutputKey].(bool)
+
+		for _, k := range []string{UIDKey, DOTIDKey, LabelKey, InputKey, OutputKey} {
+			delete(attrs, k)
+		}
+		opts = append(opts, hypher.WithAttrs(attrs))
+
+		n, err := hg.NewNode(opts...)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", gn.ID, err)
+		}
+		nodes[gn.ID] = n
+
+		if isInput {
+			inputs = append(inputs, n)
+		}
+		if isOutput {
+			outputs = append(outputs, n)
+		}
+	}
+
+	for _, ge := range gg.Edges {
+		if err := newEdge(hg, nodes, keys, ge); err != nil {
+			return fmt.Errorf("edge %s -> %s: %w", ge.Source, ge.Target, err)
+		}
+	}
+
+	hg.SetInputs(inputs)
+	hg.SetOutputs(outputs)
+
+	return nil
+}
+
+// newEdge creates a new edge in g from GraphML edge ge.
+func newEdge(g *graph.Graph, nodes map[string]*graph.Node, keys map[string]Key, ge Edge) error {
+	from, ok := nodes[ge.Source]
+	if !ok {
+		return fmt.Errorf("unknown source node: %s", ge.Source)
+	}
+	to, ok := nodes[ge.Target]
+	if !ok {
+		return fmt.Errorf("unknown target node: %s", ge.Target)
+	}
+	if from.ID() == to.ID() {
+		return errors.New("cycle detected: self loop")
+	}
+
+	attrs, err := decodeData(keys, ForEdge, ge.Data)
+	if err != nil {
+		return err
+	}
+
+	var opts []hypher.Option
+	if uid, ok := attrs[UIDKey].(string); ok && uid != "" {
+		opts = append(opts, hypher.WithUID(uid))
+	}
+	if label, ok := attrs[LabelKey].(string); ok {
+		opts = append(opts, hypher.WithLabel(label))
+	}
+	if w, ok := attrs[WeightKey]; ok {
+		weight, err := toFloat(w)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", WeightKey, err)
+		}
+		opts = append(opts, hypher.WithWeight(weight))
+	}
+
+	for _, k := range []string{UIDKey, LabelKey, WeightKey} {
+		delete(attrs, k)
+	}
+	opts = append(opts, hypher.WithAttrs(attrs))
+
+	e, err := g.NewEdge(from, to, opts...)
+	if err != nil {
+		return err
+	}
+
+	return g.SetEdge(e)
+}
+
+// decodeData decodes data of domain into attrs keyed by attribute names.
+// Key defaults are applied to the attributes missing in data.
+func decodeData(keys map[string]Key, domain string, data []Data) (map[string]any, error) {
+	attrs := make(map[string]any)
+
+	for _, k := range keys {
+		if k.Default == nil || k.Name == "" || (k.For != domain && k.For != ForAll) {
+			continue
+		}
+		v, err := decodeValue(k.Type, strings.TrimSpace(*k.Default))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: default: %w", k.ID, err)
+		}
+		attrs[k.Name] = v
+	}
+
+	for _, d := range data {
+		k, ok := keys[d.Key]
+		if !ok {
+			return nil, fmt.Errorf("unknown key: %s", d.Key)
+		}
+		if k.Name == "" {
+			continue
+		}
+		val := d.Value
+		if k.Type != "" && k.Type != TypeString {
+			val = strings.TrimSpace(val)
+		}
+		v, err := decodeValue(k.Type, val)
+		if err != nil {
+			return nil, fmt.Errorf("attr %s: %w", k.Name, err)
+		}
+		attrs[k.Name] = v
+	}
+
+	return attrs, nil
+}
+
+// decodeValue decodes GraphML data value s of type typ.
+func decodeValue(typ, s string) (any, error) {
+	switch typ {
+	case TypeBoolean:
+		return strconv.ParseBool(s)
+	case TypeInt:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return int(v), err
+	case TypeLong:
+		return strconv.ParseInt(s, 10, 64)
+	case TypeFloat:
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	case TypeDouble:
+		return strconv.ParseFloat(s, 64)
+	case TypeString, "":
+		return s, nil
+	}
+	return nil, fmt.Errorf("unsupported type: %s", typ)
+}
+
+func toFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return 0, fmt.Errorf("unsupported value: %v", v)
+}
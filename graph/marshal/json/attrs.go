@@ -0,0 +1,173 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Attribute value types.
+const (
+	TypeString   = "string"
+	TypeBool     = "bool"
+	TypeInt      = "int"
+	TypeInt8     = "int8"
+	TypeInt16    = "int16"
+	TypeInt32    = "int32"
+	TypeInt64    = "int64"
+	TypeUint     = "uint"
+	TypeUint8    = "uint8"
+	TypeUint16   = "uint16"
+	TypeUint32   = "uint32"
+	TypeUint64   = "uint64"
+	TypeFloat32  = "float32"
+	TypeFloat64  = "float64"
+	TypeTime     = "time"
+	TypeDuration = "duration"
+	TypeBytes    = "bytes"
+	// TypeJSON is used for all the other values.
+	// They are decoded into generic JSON values.
+	TypeJSON = "json"
+)
+
+// Attr is an attribute value with its type hint.
+type Attr struct {
+	// Type is the value type.
+	Type string `json:"type"`
+	// Value is JSON encoded value.
+	Value json.RawMessage `json:"value"`
+}
+
+// encodeAttrs encodes attrs with their type hints.
+func encodeAttrs(attrs map[string]any) (map[string]Attr, error) {
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+
+	res := make(map[string]Attr, len(attrs))
+	for k, v := range attrs {
+		a, err := encodeAttr(v)
+		if err != nil {
+			return nil, fmt.Errorf("attr %s: %w", k, err)
+		}
+		res[k] = a
+	}
+
+	return res, nil
+}
+
+func encodeAttr(v any) (Attr, error) {
+	var typ string
+
+	switch val := v.(type) {
+	case string:
+		typ = TypeString
+	case bool:
+		typ = TypeBool
+	case int:
+		typ = TypeInt
+	case int8:
+		typ = TypeInt8
+	case int16:
+		typ = TypeInt16
+	case int32:
+		typ = TypeInt32
+	case int64:
+		typ = TypeInt64
+	case uint:
+		typ = TypeUint
+	case uint8:
+		typ = TypeUint8
+	case uint16:
+		typ = TypeUint16
+	case uint32:
+		typ = TypeUint32
+	case uint64:
+		typ = TypeUint64
+	case float32:
+		typ = TypeFloat32
+	case float64:
+		typ = TypeFloat64
+	case time.Time:
+		typ = TypeTime
+	case time.Duration:
+		typ, v = TypeDuration, val.String()
+	case []byte:
+		typ = TypeBytes
+	default:
+		typ = TypeJSON
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Attr{}, err
+	}
+
+	return Attr{Type: typ, Value: b}, nil
+}
+
+// decodeAttrs decodes attrs using their type hints.
+func decodeAttrs(attrs map[string]Attr) (map[string]any, error) {
+	res := make(map[string]any, len(attrs))
+	for k, a := range attrs {
+		v, err := decodeAttr(a)
+		if err != nil {
+			return nil, fmt.Errorf("attr %s: %w", k, err)
+		}
+		res[k] = v
+	}
+	return res, nil
+}
+
+func decodeAttr(a Attr) (any, error) {
+	switch a.Type {
+	case TypeString:
+		return decode[string](a.Value)
+	case TypeBool:
+		return decode[bool](a.Value)
+	case TypeInt:
+		return decode[int](a.Value)
+	case TypeInt8:
+		return decode[int8](a.Value)
+	case TypeInt16:
+		return decode[int16](a.Value)
+	case TypeInt32:
+		return decode[int32](a.Value)
+	case TypeInt64:
+		return decode[int64](a.Value)
+	case TypeUint:
+		return decode[uint](a.Value)
+	case TypeUint8:
+		return decode[uint8](a.Value)
+	case TypeUint16:
+		return decode[uint16](a.Value)
+	case TypeUint32:
+		return decode[uint32](a.Value)
+	case TypeUint64:
+		return decode[uint64](a.Value)
+	case TypeFloat32:
+		return decode[float32](a.Value)
+	case TypeFloat64:
+		return decode[float64](a.Value)
+	case TypeTime:
+		return decode[time.Time](a.Value)
+	case TypeDuration:
+		s, err := decode[string](a.Value)
+		if err != nil {
+			return nil, err
+		}
+		return time.ParseDuration(s)
+	case TypeBytes:
+		return decode[[]byte](a.Value)
+	case TypeJSON:
+		return decode[any](a.Value)
+	default:
+		return nil, fmt.Errorf("unknown type: %q", a.Type)
+	}
+}
+
+func decode[T any](data json.RawMessage) (T, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
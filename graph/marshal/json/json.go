@@ -0,0 +1,309 @@
+// Package json provides lossless JSON encoding of hypher graphs.
+package json
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// Graph is JSON encoded graph.
+type Graph struct {
+	UID     string          `json:"uid"`
+	DOTID   string          `json:"dotid,omitempty"`
+	Label   string          `json:"label"`
+	Attrs   map[string]Attr `json:"attrs,omitempty"`
+	Nodes   []Node          `json:"nodes"`
+	Edges   []Edge          `json:"edges"`
+	Inputs  []int64         `json:"inputs,omitempty"`
+	Outputs []int64         `json:"outputs,omitempty"`
+}
+
+// Node is JSON encoded node.
+type Node struct {
+	ID      int64           `json:"id"`
+	UID     string          `json:"uid"`
+	DOTID   string          `json:"dotid,omitempty"`
+	Label   string          `json:"label"`
+	Attrs   map[string]Attr `json:"attrs,omitempty"`
+	Op      *Op             `json:"op,omitempty"`
+	Timeout string          `json:"timeout,omitempty"`
+}
+
+// Op is JSON encoded node Op.
+type Op struct {
+	Type string          `json:"type"`
+	Spec json.RawMessage `json:"spec,omitempty"`
+}
+
+// Edge is JSON encoded edge.
+type Edge struct {
+	UID    string          `json:"uid"`
+	From   int64           `json:"from"`
+	To     int64           `json:"to"`
+	Label  string          `json:"label"`
+	Weight float64         `json:"weight"`
+	Attrs  map[string]Attr `json:"attrs,omitempty"`
+}
+
+// Marshaler implements hypher.Marshaler.
+type Marshaler struct {
+	prefix string
+	indent string
+}
+
+// NewMarshaler creates a new Marshaler and returns it.
+func NewMarshaler(prefix, indent string) (*Marshaler, error) {
+	return &Marshaler{
+		prefix: prefix,
+		indent: indent,
+	}, nil
+}
+
+// Marshal marshals g into JSON.
+// Nodes and edges are sorted by their IDs.
+func (m *Marshaler) Marshal(g hypher.Graph) ([]byte, error) {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return nil, fmt.Errorf("unsupported graph: %T", g)
+	}
+
+	attrs, err := encodeAttrs(hg.Attrs())
+	if err != nil {
+		return nil, fmt.Errorf("graph: %w", err)
+	}
+
+	jg := Graph{
+		UID:   hg.UID(),
+		DOTID: hg.DOTID(),
+		Label: hg.Label(),
+		Attrs: attrs,
+		Nodes: []Node{},
+		Edges: []Edge{},
+	}
+
+	nodes := hg.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		jn, err := encodeNode(n)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.UID(), err)
+		}
+		jg.Nodes = append(jg.Nodes, jn)
+	}
+	slices.SortFunc(jg.Nodes, func(a, b Node) int { return cmp.Compare(a.ID, b.ID) })
+
+	edges := hg.Edges()
+	for edges.Next() {
+		e := edges.Edge().(*graph.Edge)
+		attrs, err := encodeAttrs(e.Attrs())
+		if err != nil {
+			return nil, fmt.Errorf("edge %s: %w", e.UID(), err)
+		}
+		jg.Edges = append(jg.Edges, Edge{
+			UID:    e.UID(),
+			From:   e.From().ID(),
+			To:     e.To().ID(),
+			Label:  e.Label(),
+			Weight: e.Weight(),
+			Attrs:  attrs,
+		})
+	}
+	slices.SortFunc(jg.Edges, func(a, b Edge) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+
+	for _, n := range hg.Inputs() {
+		jg.Inputs = append(jg.Inputs, n.ID())
+	}
+	for _, n := range hg.Outputs() {
+		jg.Outputs = append(jg.Outputs, n.ID())
+	}
+
+	return json.MarshalIndent(jg, m.prefix, m.indent)
+}
+
+func encodeNode(n *graph.Node) (Node, error) {
+	attrs, err := encodeAttrs(n.Attrs())
+	if err != nil {
+		return Node{}, err
+	}
+
+	jn := Node{
+		ID:    n.ID(),
+		UID:   n.UID(),
+		DOTID: n.DOTID(),
+		Label: n.Label(),
+		Attrs: attrs,
+	}
+
+	if t := n.Timeout(); t > 0 {
+		jn.Timeout = t.String()
+	}
+
+	opType, spec, err := graph.MarshalOp(n.Op())
+	if err != nil {
+		return Node{}, err
+	}
+	if opType != "" {
+		jn.Op = &Op{Type: opType, Spec: spec}
+	}
+
+	return jn, nil
+}
+
+// Unmarshaler implements hypher.Unmarshaler.
+type Unmarshaler struct {
+	ops *graph.OpRegistry
+}
+
+// NewUnmarshaler creates a new Unmarshaler and returns it.
+func NewUnmarshaler(opts ...Option) (*Unmarshaler, error) {
+	uopts := Options{}
+	for _, apply := range opts {
+		apply(&uopts)
+	}
+
+	return &Unmarshaler{
+		ops: uopts.OpRegistry,
+	}, nil
+}
+
+// Unmarshal unmarshals JSON data into g which must be an empty *graph.Graph.
+// The graph UID, DOT ID, label and attributes of g are replaced.
+// If the Unmarshaler has an OpRegistry, node Ops are restored
+// through it, otherwise the nodes run NoOp.
+func (u *Unmarshaler) Unmarshal(data []byte, g hypher.Graph) error {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return fmt.Errorf("unsupported graph: %T", g)
+	}
+	if hg.Nodes().Len() > 0 {
+		return fmt.Errorf("graph %s is not empty", hg.UID())
+	}
+
+	var jg Graph
+	if err := json.Unmarshal(data, &jg); err != nil {
+		return err
+	}
+
+	attrs, err := decodeAttrs(jg.Attrs)
+	if err != nil {
+		return fmt.Errorf("graph: %w", err)
+	}
+
+	hg.SetUID(jg.UID)
+	hg.SetDOTID(jg.DOTID)
+	hg.SetLabel(jg.Label)
+	for k, v := range attrs {
+		hg.SetAttr(k, v)
+	}
+
+	nodes := make(map[int64]*graph.Node, len(jg.Nodes))
+	for _, jn := range jg.Nodes {
+		if _, ok := nodes[jn.ID]; ok {
+			return fmt.Errorf("node %s: duplicate id: %d", jn.UID, jn.ID)
+		}
+		n, err := u.decodeNode(jn)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", jn.UID, err)
+		}
+		if err := hg.AddNode(n); err != nil {
+			return fmt.Errorf("node %s: %w", jn.UID, err)
+		}
+		nodes[jn.ID] = n
+	}
+
+	for _, je := range jg.Edges {
+		from, ok := nodes[je.From]
+		if !ok {
+			return fmt.Errorf("edge %s: unknown from node: %d", je.UID, je.From)
+		}
+		to, ok := nodes[je.To]
+		if !ok {
+			return fmt.Errorf("edge %s: unknown to node: %d", je.UID, je.To)
+		}
+
+		attrs, err := decodeAttrs(je.Attrs)
+		if err != nil {
+			return fmt.Errorf("edge %s: %w", je.UID, err)
+		}
+
+		e, err := graph.NewEdge(from, to,
+			hypher.WithUID(je.UID),
+			hypher.WithLabel(je.Label),
+			hypher.WithWeight(je.Weight),
+			hypher.WithAttrs(attrs),
+		)
+		if err != nil {
+			return fmt.Errorf("edge %s: %w", je.UID, err)
+		}
+		if err := hg.SetEdge(e); err != nil {
+			return fmt.Errorf("edge %s: %w", je.UID, err)
+		}
+	}
+
+	inputs, err := lookupNodes(nodes, jg.Inputs)
+	if err != nil {
+		return fmt.Errorf("inputs: %w", err)
+	}
+	hg.SetInputs(inputs)
+
+	outputs, err := lookupNodes(nodes, jg.Outputs)
+	if err != nil {
+		return fmt.Errorf("outputs: %w", err)
+	}
+	hg.SetOutputs(outputs)
+
+	return nil
+}
+
+func (u *Unmarshaler) decodeNode(jn Node) (*graph.Node, error) {
+	attrs, err := decodeAttrs(jn.Attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []hypher.Option{
+		hypher.WithID(jn.ID),
+		hypher.WithUID(jn.UID),
+		hypher.WithDotID(jn.DOTID),
+		hypher.WithLabel(jn.Label),
+		hypher.WithAttrs(attrs),
+	}
+
+	if jn.Timeout != "" {
+		t, err := time.ParseDuration(jn.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		opts = append(opts, hypher.WithTimeout(t))
+	}
+
+	if jn.Op != nil && u.ops != nil {
+		op, err := u.ops.New(jn.Op.Type, jn.Op.Spec)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, hypher.WithOp(op))
+	}
+
+	return graph.NewNode(opts...)
+}
+
+func lookupNodes(nodes map[int64]*graph.Node, ids []int64) ([]*graph.Node, error) {
+	res := make([]*graph.Node, 0, len(ids))
+	for _, id := range ids {
+		n, ok := nodes[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown node: %d", id)
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
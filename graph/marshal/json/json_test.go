@@ -0,0 +1,316 @@
+package json
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// greetOp is a test Op with a spec.
+type greetOp struct {
+	Greeting string `json:"greeting"`
+}
+
+func (op *greetOp) Type() string   { return "GreetOp" }
+func (op *greetOp) Desc() string   { return "GreetOp greets" }
+func (op *greetOp) String() string { return op.Type() }
+func (op *greetOp) Spec() ([]byte, error) {
+	return json.Marshal(op)
+}
+func (op *greetOp) Do(_ context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	return []hypher.Value{{"greeting": op.Greeting}}, nil
+}
+
+func MustOpRegistry(t *testing.T) *graph.OpRegistry {
+	r := graph.NewOpRegistry()
+	if err := r.Register("GreetOp", func(spec []byte) (hypher.Op, error) {
+		op := &greetOp{}
+		if err := json.Unmarshal(spec, op); err != nil {
+			return nil, err
+		}
+		return op, nil
+	}); err != nil {
+		t.Fatalf("failed to register op: %v", err)
+	}
+	return r
+}
+
+func MustGraph(t *testing.T) *graph.Graph {
+	g, err := graph.NewGraph(
+		hypher.WithUID("g"),
+		hypher.WithDotID("gdot"),
+		hypher.WithLabel("Graph"),
+		hypher.WithAttrs(map[string]any{
+			"string":   "s",
+			"int":      1,
+			"int64":    int64(2),
+			"uint8":    uint8(3),
+			"float32":  float32(1.5),
+			"float64":  2.5,
+			"bool":     true,
+			"time":     time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC),
+			"duration": 90 * time.Second,
+			"bytes":    []byte("raw"),
+			"json":     map[string]any{"k": []any{"v", 1.0}},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+
+	// NOTE: node IDs are not contiguous on purpose
+	nodes := make([]*graph.Node, 3)
+	for i, id := range []int64{3, 7, 11} {
+		opts := []hypher.Option{
+			hypher.WithID(id),
+			hypher.WithUID("n" + string(rune('a'+i))),
+			hypher.WithDotID("dot" + string(rune('a'+i))),
+			hypher.WithLabel("Node"),
+			hypher.WithAttrs(map[string]any{"pos": i}),
+		}
+		switch i {
+		case 0:
+			opts = append(opts, hypher.WithOp(&greetOp{Greeting: "hi"}))
+		case 1:
+			opts = append(opts, hypher.WithTimeout(time.Second))
+		}
+		n, err := graph.NewNode(opts...)
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		if err := g.AddNode(n); err != nil {
+			t.Fatalf("failed to add node: %v", err)
+		}
+		nodes[i] = n
+	}
+
+	for i, pair := range [][2]int{{0, 1}, {0, 2}, {1, 2}} {
+		e, err := graph.NewEdge(nodes[pair[0]], nodes[pair[1]],
+			hypher.WithUID("e"+string(rune('a'+i))),
+			hypher.WithLabel("Edge"),
+			hypher.WithWeight(float64(i)+0.5),
+			hypher.WithAttrs(map[string]any{"flag": i%2 == 0}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create edge: %v", err)
+		}
+		if err := g.SetEdge(e); err != nil {
+			t.Fatalf("failed to set edge: %v", err)
+		}
+	}
+
+	g.SetInputs([]*graph.Node{nodes[0]})
+	g.SetOutputs([]*graph.Node{nodes[2]})
+
+	return g
+}
+
+// assertEqual asserts that graphs g1 and g2 are structurally equal.
+func assertEqual(t *testing.T, g1, g2 *graph.Graph) {
+	t.Helper()
+
+	if g1.UID() != g2.UID() || g1.DOTID() != g2.DOTID() || g1.Label() != g2.Label() {
+		t.Errorf("graph mismatch: %s/%s/%s != %s/%s/%s",
+			g1.UID(), g1.DOTID(), g1.Label(), g2.UID(), g2.DOTID(), g2.Label())
+	}
+	if !reflect.DeepEqual(g1.Attrs(), g2.Attrs()) {
+		t.Errorf("graph attrs mismatch: %#v != %#v", g1.Attrs(), g2.Attrs())
+	}
+
+	if g1.Nodes().Len() != g2.Nodes().Len() {
+		t.Fatalf("nodes mismatch: %d != %d", g1.Nodes().Len(), g2.Nodes().Len())
+	}
+
+	nodes := g1.Nodes()
+	for nodes.Next() {
+		n1 := nodes.Node().(*graph.Node)
+		n2, ok := g2.Node(n1.ID()).(*graph.Node)
+		if !ok {
+			t.Fatalf("node %d not found", n1.ID())
+		}
+		if n1.UID() != n2.UID() || n1.DOTID() != n2.DOTID() || n1.Label() != n2.Label() || n1.Timeout() != n2.Timeout() {
+			t.Errorf("node %d mismatch: %v != %v", n1.ID(), n1, n2)
+		}
+		if !reflect.DeepEqual(n1.Attrs(), n2.Attrs()) {
+			t.Errorf("node %d attrs mismatch: %#v != %#v", n1.ID(), n1.Attrs(), n2.Attrs())
+		}
+		if !reflect.DeepEqual(n1.Op(), n2.Op()) {
+			t.Errorf("node %d op mismatch: %#v != %#v", n1.ID(), n1.Op(), n2.Op())
+		}
+	}
+
+	if g1.Edges().Len() != g2.Edges().Len() {
+		t.Fatalf("edges mismatch: %d != %d", g1.Edges().Len(), g2.Edges().Len())
+	}
+
+	edges := g1.Edges()
+	for edges.Next() {
+		e1 := edges.Edge().(*graph.Edge)
+		e2, ok := g2.WeightedEdge(e1.From().ID(), e1.To().ID()).(*graph.Edge)
+		if !ok {
+			t.Fatalf("edge %s not found", e1.UID())
+		}
+		if e1.UID() != e2.UID() || e1.Label() != e2.Label() || e1.Weight() != e2.Weight() {
+			t.Errorf("edge mismatch: %v != %v", e1, e2)
+		}
+		if !reflect.DeepEqual(e1.Attrs(), e2.Attrs()) {
+			t.Errorf("edge %s attrs mismatch: %#v != %#v", e1.UID(), e1.Attrs(), e2.Attrs())
+		}
+	}
+
+	ids := func(nodes []*graph.Node) []int64 {
+		var res []int64
+		for _, n := range nodes {
+			res = append(res, n.ID())
+		}
+		return res
+	}
+	if !reflect.DeepEqual(ids(g1.Inputs()), ids(g2.Inputs())) {
+		t.Errorf("inputs mismatch: %v != %v", ids(g1.Inputs()), ids(g2.Inputs()))
+	}
+	if !reflect.DeepEqual(ids(g1.Outputs()), ids(g2.Outputs())) {
+		t.Errorf("outputs mismatch: %v != %v", ids(g1.Outputs()), ids(g2.Outputs()))
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	g := MustGraph(t)
+
+	m, err := NewMarshaler("", "  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	u, err := NewUnmarshaler(WithOpRegistry(MustOpRegistry(t)))
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+
+	g2, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+
+	if err := u.Unmarshal(data, g2); err != nil {
+		t.Fatalf("failed to unmarshal graph: %v", err)
+	}
+
+	assertEqual(t, g, g2)
+
+	data2, err := m.Marshal(g2)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+	if string(data) != string(data2) {
+		t.Errorf("expected:\n%s\ngot:\n%s", data, data2)
+	}
+
+	if err := u.Unmarshal(data, g2); err == nil {
+		t.Error("expected non-empty graph error")
+	}
+}
+
+func TestUnmarshalNoRegistry(t *testing.T) {
+	m, err := NewMarshaler("", "")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+
+	data, err := m.Marshal(MustGraph(t))
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	u, err := NewUnmarshaler()
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	if err := u.Unmarshal(data, g); err != nil {
+		t.Fatalf("failed to unmarshal graph: %v", err)
+	}
+
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if op := nodes.Node().(*graph.Node).Op(); op.Type() != "NoOp" {
+			t.Errorf("expected NoOp, got: %s", op.Type())
+		}
+	}
+}
+
+func TestUnmarshalNilAttrs(t *testing.T) {
+	m, err := NewMarshaler("", "")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+
+	data, err := m.Marshal(MustGraph(t))
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	u, err := NewUnmarshaler(WithOpRegistry(MustOpRegistry(t)))
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+
+	g, err := graph.NewGraph(hypher.WithAttrs(nil))
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	if err := u.Unmarshal(data, g); err != nil {
+		t.Fatalf("failed to unmarshal graph: %v", err)
+	}
+
+	if v := g.Attrs()["string"]; v != "s" {
+		t.Errorf("expected attr: s, got: %v", v)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"InvalidJSON", `{`},
+		{"UnknownAttrType", `{"uid": "g", "attrs": {"a": {"type": "complex", "value": 1}}}`},
+		{"InvalidAttrValue", `{"uid": "g", "attrs": {"a": {"type": "int", "value": "x"}}}`},
+		{"DuplicateNode", `{"uid": "g", "nodes": [{"id": 1, "uid": "a"}, {"id": 1, "uid": "b"}]}`},
+		{"UnknownEdgeNode", `{"uid": "g", "nodes": [{"id": 1, "uid": "a"}], "edges": [{"uid": "e", "from": 1, "to": 2}]}`},
+		{"Cycle", `{"uid": "g", "nodes": [{"id": 1, "uid": "a"}, {"id": 2, "uid": "b"}], "edges": [{"uid": "e1", "from": 1, "to": 2}, {"uid": "e2", "from": 2, "to": 1}]}`},
+		{"UnknownInput", `{"uid": "g", "nodes": [{"id": 1, "uid": "a"}], "inputs": [2]}`},
+		{"InvalidTimeout", `{"uid": "g", "nodes": [{"id": 1, "uid": "a", "timeout": "soon"}]}`},
+		{"UnknownOp", `{"uid": "g", "nodes": [{"id": 1, "uid": "a", "op": {"type": "Unknown"}}]}`},
+	}
+
+	u, err := NewUnmarshaler(WithOpRegistry(graph.NewOpRegistry()))
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := graph.NewGraph()
+			if err != nil {
+				t.Fatalf("failed to create graph: %v", err)
+			}
+			if err := u.Unmarshal([]byte(tc.data), g); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package json
+
+import "github.com/milosgajdos/go-hypher/graph"
+
+// Options configure Unmarshaler.
+type Options struct {
+	// OpRegistry configures the registry used to restore node Ops.
+	OpRegistry *graph.OpRegistry
+}
+
+// Option is functional Unmarshaler option.
+type Option func(*Options)
+
+// WithOpRegistry sets OpRegistry.
+func WithOpRegistry(r *graph.OpRegistry) Option {
+	return func(o *Options) {
+		o.OpRegistry = r
+	}
+}
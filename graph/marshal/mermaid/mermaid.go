@@ -0,0 +1,253 @@
+// Package mermaid provides Mermaid flowchart encoding of hypher graphs.
+package mermaid
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/marshal/dot"
+)
+
+const (
+	// GroupAttr is node attribute which groups nodes into subgraphs.
+	GroupAttr = "group"
+	// InputClass is the class of graph input nodes.
+	InputClass = "input"
+	// OutputClass is the class of graph output nodes.
+	OutputClass = "output"
+	// InputClassDef is the style of graph input nodes.
+	InputClassDef = "fill:#d4f4dd,stroke:#2e7d32"
+	// OutputClassDef is the style of graph output nodes.
+	OutputClassDef = "fill:#dde8fb,stroke:#1565c0"
+)
+
+// idRe matches node DOT IDs which are valid Mermaid node IDs.
+var idRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reserved are words which can't be used as Mermaid node IDs.
+var reserved = map[string]bool{
+	"end":       true,
+	"graph":     true,
+	"flowchart": true,
+	"subgraph":  true,
+	"class":     true,
+	"classDef":  true,
+	"style":     true,
+	"linkStyle": true,
+	"click":     true,
+	"direction": true,
+}
+
+// Marshaler implements hypher.Marshaler.
+type Marshaler struct {
+	indent string
+	run    *graph.RunResult
+}
+
+// NewMarshaler creates a new Mermaid flowchart marshaler and returns it.
+func NewMarshaler(indent string, opts ...Option) (*Marshaler, error) {
+	mOpts := Options{}
+
+	for _, apply := range opts {
+		apply(&mOpts)
+	}
+
+	return &Marshaler{
+		indent: indent,
+		run:    mOpts.RunResult,
+	}, nil
+}
+
+// Marshal marshals g into Mermaid flowchart.
+// Nodes are identified by their DOT IDs if they are valid Mermaid IDs,
+// otherwise by their graph IDs suffixed if needed to keep them unique.
+// Default edge labels are omitted. Edge weights other than the default one
+// are appended to the edge labels as (w=weight). Nodes which have
+// GroupAttr are placed into subgraphs named after the attribute value.
+// Graph input and output nodes are assigned InputClass and OutputClass.
+// If a run result is configured, nodes are assigned the classes named
+// after their run statuses and colored by dot.StatusColors, their labels
+// include execution durations and failed nodes get their execution errors
+// as tooltips.
+func (m *Marshaler) Marshal(g hypher.Graph) ([]byte, error) {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return nil, fmt.Errorf("unsupported graph: %T", g)
+	}
+
+	var nodes []*graph.Node
+	it := hg.Nodes()
+	for it.Next() {
+		nodes = append(nodes, it.Node().(*graph.Node))
+	}
+	slices.SortFunc(nodes, func(a, b *graph.Node) int { return cmp.Compare(a.ID(), b.ID()) })
+
+	ids := make(map[int64]string, len(nodes))
+	seen := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		id := n.DOTID()
+		if !idRe.MatchString(id) || reserved[id] || seen[id] {
+			id = fmt.Sprintf("n%d", n.ID())
+			// the fallback ID may be another node's DOT ID
+			for i := 1; seen[id]; i++ {
+				id = fmt.Sprintf("n%d_%d", n.ID(), i)
+			}
+		}
+		ids[n.ID()] = id
+		seen[id] = true
+	}
+
+	var b strings.Builder
+
+	if label := hg.Label(); label != "" {
+		fmt.Fprintf(&b, "---\ntitle: %q\n---\n", label)
+	}
+	b.WriteString("flowchart TD\n")
+
+	var groups []string
+	members := make(map[string][]*graph.Node)
+	for _, n := range nodes {
+		group, ok := n.Attrs()[GroupAttr].(string)
+		if !ok || group == "" {
+			fmt.Fprintf(&b, "%s%s\n", m.indent, nodeStmt(ids[n.ID()], m.nodeLabel(n)))
+			continue
+		}
+		if _, ok := members[group]; !ok {
+			groups = append(groups, group)
+		}
+		members[group] = append(members[group], n)
+	}
+
+	for i, group := range groups {
+		if idRe.MatchString(group) && !reserved[group] && !seen[group] {
+			fmt.Fprintf(&b, "%ssubgraph %s\n", m.indent, group)
+		} else {
+			fmt.Fprintf(&b, "%ssubgraph g%d [\"%s\"]\n", m.indent, i, escape(group))
+		}
+		for _, n := range members[group] {
+			fmt.Fprintf(&b, "%s%s%s\n", m.indent, m.indent, nodeStmt(ids[n.ID()], m.nodeLabel(n)))
+		}
+		fmt.Fprintf(&b, "%send\n", m.indent)
+	}
+
+	var edges []*graph.Edge
+	eit := hg.Edges()
+	for eit.Next() {
+		edges = append(edges, eit.Edge().(*graph.Edge))
+	}
+	slices.SortFunc(edges, func(a, b *graph.Edge) int {
+		return cmp.Or(cmp.Compare(a.From().ID(), b.From().ID()), cmp.Compare(a.To().ID(), b.To().ID()))
+	})
+
+	for _, e := range edges {
+		from, to := ids[e.From().ID()], ids[e.To().ID()]
+		label := e.Label()
+		if label == graph.DefaultEdgeLabel {
+			label = ""
+		}
+		if w := e.Weight(); w != graph.DefaultEdgeWeight {
+			label = strings.TrimSpace(label + " (w=" + strconv.FormatFloat(w, 'g', -1, 64) + ")")
+		}
+		if label == "" {
+			fmt.Fprintf(&b, "%s%s --> %s\n", m.indent, from, to)
+			continue
+		}
+		fmt.Fprintf(&b, "%s%s -->|\"%s\"| %s\n", m.indent, from, escape(label), to)
+	}
+
+	inputs, outputs := hg.Inputs(), hg.Outputs()
+	if len(inputs) > 0 {
+		fmt.Fprintf(&b, "%sclassDef %s %s\n", m.indent, InputClass, InputClassDef)
+	}
+	if len(outputs) > 0 {
+		fmt.Fprintf(&b, "%sclassDef %s %s\n", m.indent, OutputClass, OutputClassDef)
+	}
+	if len(inputs) > 0 {
+		fmt.Fprintf(&b, "%sclass %s %s\n", m.indent, classIDs(ids, inputs), InputClass)
+	}
+	if len(outputs) > 0 {
+		fmt.Fprintf(&b, "%sclass %s %s\n", m.indent, classIDs(ids, outputs), OutputClass)
+	}
+
+	if m.run != nil {
+		m.writeRunState(&b, ids, nodes)
+	}
+
+	return []byte(b.String()), nil
+}
+
+// nodeLabel returns the label of node n.
+// Node labels include execution durations
+// if a run result is configured.
+func (m *Marshaler) nodeLabel(n *graph.Node) string {
+	if m.run == nil {
+		return n.Label()
+	}
+	if elapsed := dot.RunState(m.run, n).Elapsed(); elapsed != "" {
+		return n.Label() + "<br/>" + elapsed
+	}
+	return n.Label()
+}
+
+// writeRunState writes the classes and tooltips of node run states into b.
+// Status classes are written after the input and output classes so their
+// fill colors take precedence.
+func (m *Marshaler) writeRunState(b *strings.Builder, ids map[int64]string, nodes []*graph.Node) {
+	var (
+		statuses []dot.Status
+		tooltips []string
+	)
+	members := make(map[dot.Status][]*graph.Node)
+
+	for _, n := range nodes {
+		s := dot.RunState(m.run, n)
+		if _, ok := members[s.Status]; !ok {
+			statuses = append(statuses, s.Status)
+		}
+		members[s.Status] = append(members[s.Status], n)
+		if s.Err != nil {
+			// tooltips must fit on a single line
+			tooltip := strings.Join(strings.Fields(s.Err.Error()), " ")
+			tooltips = append(tooltips, fmt.Sprintf("click %s callback \"%s\"", ids[n.ID()], escape(tooltip)))
+		}
+	}
+
+	for _, s := range statuses {
+		c := dot.StatusColors[s]
+		fmt.Fprintf(b, "%sclassDef %s fill:#%02x%02x%02x\n", m.indent, s, c.R, c.G, c.B)
+	}
+	for _, s := range statuses {
+		fmt.Fprintf(b, "%sclass %s %s\n", m.indent, classIDs(ids, members[s]), s)
+	}
+	for _, tooltip := range tooltips {
+		fmt.Fprintf(b, "%s%s\n", m.indent, tooltip)
+	}
+}
+
+func nodeStmt(id, label string) string {
+	return fmt.Sprintf("%s[\"%s\"]", id, escape(label))
+}
+
+func classIDs(ids map[int64]string, nodes []*graph.Node) string {
+	res := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		res = append(res, ids[n.ID()])
+	}
+	return strings.Join(res, ",")
+}
+
+// escape escapes s so it can be used in a quoted Mermaid string.
+func escape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+// unescape reverts escape.
+func unescape(s string) string {
+	return strings.ReplaceAll(s, "#quot;", `"`)
+}
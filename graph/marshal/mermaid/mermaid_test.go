@@ -0,0 +1,347 @@
+package mermaid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func MustGraph(t *testing.T, opts ...hypher.Option) *graph.Graph {
+	g, err := graph.NewGraph(opts...)
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	return g
+}
+
+// MustAgentGraph returns graph: in -> plan -> out, in -> out.
+func MustAgentGraph(t *testing.T) *graph.Graph {
+	g := MustGraph(t, hypher.WithLabel("Agent"))
+
+	newNode := func(dotid, label string, attrs map[string]any) *graph.Node {
+		n, err := g.NewNode(
+			hypher.WithDotID(dotid),
+			hypher.WithLabel(label),
+			hypher.WithAttrs(attrs),
+		)
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		return n
+	}
+	newEdge := func(from, to *graph.Node, opts ...hypher.Option) {
+		e, err := g.NewEdge(from, to, opts...)
+		if err != nil {
+			t.Fatalf("failed to create edge: %v", err)
+		}
+		if err := g.SetEdge(e); err != nil {
+			t.Fatalf("failed to set edge: %v", err)
+		}
+	}
+
+	in := newNode("in", `User "query"`, nil)
+	plan := newNode("plan", "Plan", map[string]any{GroupAttr: "llm"})
+	out := newNode("d7c6e4b2-uuid", "Answer", map[string]any{GroupAttr: "final step"})
+
+	newEdge(in, plan, hypher.WithLabel("prompt"), hypher.WithWeight(2.5))
+	newEdge(plan, out)
+	newEdge(in, out, hypher.WithWeight(0.5))
+
+	g.SetInputs([]*graph.Node{in})
+	g.SetOutputs([]*graph.Node{out})
+
+	return g
+}
+
+func MustUnmarshal(t *testing.T, data string) *graph.Graph {
+	u, err := NewUnmarshaler()
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+	g := MustGraph(t)
+	if err := u.Unmarshal([]byte(data), g); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	return g
+}
+
+func nodeByDOTID(t *testing.T, g *graph.Graph, dotid string) *graph.Node {
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		if n.DOTID() == dotid {
+			return n
+		}
+	}
+	t.Fatalf("node %s not found", dotid)
+	return nil
+}
+
+func TestMarshal(t *testing.T) {
+	m, err := NewMarshaler("    ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+
+	data, err := m.Marshal(MustAgentGraph(t))
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	want := `---
+title: "Agent"
+---
+flowchart TD
+    in["User #quot;query#quot;"]
+    subgraph llm
+        plan["Plan"]
+    end
+    subgraph g1 ["final step"]
+        n2["Answer"]
+    end
+    in -->|"prompt (w=2.5)"| plan
+    in -->|"(w=0.5)"| n2
+    plan --> n2
+    classDef input fill:#d4f4dd,stroke:#2e7d32
+    classDef output fill:#dde8fb,stroke:#1565c0
+    class in input
+    class n2 output
+`
+	if string(data) != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, data)
+	}
+}
+
+func TestMarshalRunResult(t *testing.T) {
+	g := MustAgentGraph(t)
+	in, plan := nodeByDOTID(t, g, "in"), nodeByDOTID(t, g, "plan")
+
+	start := time.Now()
+	r := &graph.RunResult{
+		Nodes: map[string]*graph.NodeResult{
+			in.UID():   {Start: start, End: start.Add(12 * time.Millisecond)},
+			plan.UID(): {Start: start, End: start.Add(2 * time.Second), Err: errors.New("rate \"limited\"\nretry")},
+		},
+	}
+
+	m, err := NewMarshaler("  ", WithRunResult(r))
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		`in["User #quot;query#quot;<br/>12ms"]`,
+		`plan["Plan<br/>2s"]`,
+		`n2["Answer"]`,
+		"classDef succeeded fill:#c8e6c9",
+		"classDef failed fill:#ffcdd2",
+		"classDef pending fill:#e6e6e6",
+		"class in succeeded",
+		"class plan failed",
+		"class n2 pending",
+		`click plan callback "rate #quot;limited#quot; retry"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q:\n%s", want, out)
+		}
+	}
+
+	// the overlay must not break unmarshaling
+	if g := MustUnmarshal(t, out); g.Nodes().Len() != 3 {
+		t.Errorf("expected 3 nodes, got: %d", g.Nodes().Len())
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	m, err := NewMarshaler("  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+
+	data, err := m.Marshal(MustAgentGraph(t))
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	g := MustUnmarshal(t, string(data))
+
+	if g.Label() != "Agent" {
+		t.Errorf("expected label: Agent, got: %s", g.Label())
+	}
+	if n := g.Nodes().Len(); n != 3 {
+		t.Fatalf("expected 3 nodes, got: %d", n)
+	}
+	if e := g.Edges().Len(); e != 3 {
+		t.Fatalf("expected 3 edges, got: %d", e)
+	}
+
+	in := nodeByDOTID(t, g, "in")
+	plan := nodeByDOTID(t, g, "plan")
+	out := nodeByDOTID(t, g, "n2")
+
+	if in.Label() != `User "query"` {
+		t.Errorf("expected label: %q, got: %q", `User "query"`, in.Label())
+	}
+	if v := plan.Attrs()[GroupAttr]; v != "llm" {
+		t.Errorf("expected group: llm, got: %v", v)
+	}
+	if v := out.Attrs()[GroupAttr]; v != "final step" {
+		t.Errorf("expected group: final step, got: %v", v)
+	}
+
+	e := g.Edge(in.ID(), plan.ID()).(*graph.Edge)
+	if e.Label() != "prompt" || e.Weight() != 2.5 {
+		t.Errorf("unexpected edge label: %q, weight: %f", e.Label(), e.Weight())
+	}
+	e = g.Edge(in.ID(), out.ID()).(*graph.Edge)
+	if e.Label() != graph.DefaultEdgeLabel || e.Weight() != 0.5 {
+		t.Errorf("unexpected edge label: %q, weight: %f", e.Label(), e.Weight())
+	}
+	e = g.Edge(plan.ID(), out.ID()).(*graph.Edge)
+	if e.Weight() != graph.DefaultEdgeWeight {
+		t.Errorf("expected default weight, got: %f", e.Weight())
+	}
+
+	if inputs := g.Inputs(); len(inputs) != 1 || inputs[0] != in {
+		t.Errorf("expected inputs: [in], got: %v", inputs)
+	}
+	if outputs := g.Outputs(); len(outputs) != 1 || outputs[0] != out {
+		t.Errorf("expected outputs: [n2], got: %v", outputs)
+	}
+}
+
+func TestMarshalFallbackIDs(t *testing.T) {
+	g := MustGraph(t)
+	// node 1 falls back to n1 which is the DOT ID of node 0.
+	for _, dotid := range []string{"n1", "", "n1_1"} {
+		opts := []hypher.Option{hypher.WithLabel("node")}
+		if dotid != "" {
+			opts = append(opts, hypher.WithDotID(dotid))
+		}
+		if _, err := g.NewNode(opts...); err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+	}
+
+	m, err := NewMarshaler("  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	want := `flowchart TD
+  n1["node"]
+  n1_1["node"]
+  n2["node"]
+`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, data)
+	}
+
+	if n := MustUnmarshal(t, string(data)).Nodes().Len(); n != 3 {
+		t.Errorf("expected 3 nodes, got: %d", n)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := `%% agent sketch
+graph LR
+    A[Start]:::input --> B(Think) -- decide --> C{Route}
+    C -->|tool| D[[Tool]]; C --> E((Done)):::output
+    subgraph tools [Tool calls]
+        D --> F>Observe]
+    end
+    F -- "back to route" --> E
+    style A fill:#f9f
+    linkStyle 0 stroke:#ff3
+`
+	g := MustUnmarshal(t, data)
+
+	if n := g.Nodes().Len(); n != 6 {
+		t.Fatalf("expected 6 nodes, got: %d", n)
+	}
+	if e := g.Edges().Len(); e != 6 {
+		t.Fatalf("expected 6 edges, got: %d", e)
+	}
+
+	for dotid, label := range map[string]string{
+		"A": "Start",
+		"B": "Think",
+		"C": "Route",
+		"D": "Tool",
+		"E": "Done",
+		"F": "Observe",
+	} {
+		if l := nodeByDOTID(t, g, dotid).Label(); l != label {
+			t.Errorf("node %s: expected label: %s, got: %s", dotid, label, l)
+		}
+	}
+
+	if v := nodeByDOTID(t, g, "F").Attrs()[GroupAttr]; v != "Tool calls" {
+		t.Errorf("expected group: Tool calls, got: %v", v)
+	}
+	if _, ok := nodeByDOTID(t, g, "D").Attrs()[GroupAttr]; ok {
+		t.Error("expected node D outside of subgraph")
+	}
+
+	b, c := nodeByDOTID(t, g, "B"), nodeByDOTID(t, g, "C")
+	if l := g.Edge(b.ID(), c.ID()).(*graph.Edge).Label(); l != "decide" {
+		t.Errorf("expected edge label: decide, got: %s", l)
+	}
+	f, e := nodeByDOTID(t, g, "F"), nodeByDOTID(t, g, "E")
+	if l := g.Edge(f.ID(), e.ID()).(*graph.Edge).Label(); l != "back to route" {
+		t.Errorf("expected edge label: back to route, got: %s", l)
+	}
+
+	if inputs := g.Inputs(); len(inputs) != 1 || inputs[0].DOTID() != "A" {
+		t.Errorf("expected inputs: [A], got: %v", inputs)
+	}
+	if outputs := g.Outputs(); len(outputs) != 1 || outputs[0].DOTID() != "E" {
+		t.Errorf("expected outputs: [E], got: %v", outputs)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", "missing flowchart header"},
+		{"diagram", "sequenceDiagram\n A->>B: hi", "unsupported diagram"},
+		{"cycle", "flowchart TD\n a --> b\n b --> a", "line 3: edge b -> a"},
+		{"self loop", "flowchart TD\n a --> a", "edge a -> a"},
+		{"link", "flowchart TD\n a ==> b", "unsupported link"},
+		{"shape", "flowchart TD\n a[oops --> b", "node a"},
+		{"end", "flowchart TD\n end", "unexpected end"},
+		{"subgraph", "flowchart TD\n subgraph s\n a --> b", "unterminated subgraph"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := NewUnmarshaler()
+			if err != nil {
+				t.Fatalf("failed to create unmarshaler: %v", err)
+			}
+			err = u.Unmarshal([]byte(tc.data), MustGraph(t))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
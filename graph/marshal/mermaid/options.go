@@ -0,0 +1,19 @@
+package mermaid
+
+import "github.com/milosgajdos/go-hypher/graph"
+
+// Options configure marshaler.
+type Options struct {
+	// RunResult configures the run whose state is overlaid on the graph.
+	RunResult *graph.RunResult
+}
+
+// Option is functional marshaler option.
+type Option func(*Options)
+
+// WithRunResult sets the run whose state is overlaid on the graph.
+func WithRunResult(r *graph.RunResult) Option {
+	return func(o *Options) {
+		o.RunResult = r
+	}
+}
@@ -0,0 +1,495 @@
+package mermaid
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// weightRe matches edge labels which carry edge weight.
+var weightRe = regexp.MustCompile(`^(.*?)\s*\(w=([^()]*)\)$`)
+
+// shapes maps Mermaid node shape openings to their closings.
+// Longer openings must come first so they are matched before their prefixes.
+var shapes = []struct {
+	open  string
+	close []string
+}{
+	{"(((", []string{")))"}},
+	{"([", []string{"])"}},
+	{"[[", []string{"]]"}},
+	{"[(", []string{")]"}},
+	{"((", []string{"))"}},
+	{"{{", []string{"}}"}},
+	{"[/", []string{"/]", `\]`}},
+	{`[\`, []string{`\]`, "/]"}},
+	{">", []string{"]"}},
+	{"[", []string{"]"}},
+	{"(", []string{")"}},
+	{"{", []string{"}"}},
+}
+
+// Unmarshaler implements hypher.Unmarshaler.
+type Unmarshaler struct{}
+
+// NewUnmarshaler creates a new Mermaid flowchart unmarshaler and returns it.
+func NewUnmarshaler() (*Unmarshaler, error) {
+	return &Unmarshaler{}, nil
+}
+
+// Unmarshal unmarshals Mermaid flowchart data into g which must be an empty *graph.Graph.
+// It supports a subset of the flowchart syntax: node declarations with labels,
+// --> and -- label --> edges as well as -->|label| edges, subgraphs and classes.
+// Node IDs are mapped to node DOT IDs and nodes declared in subgraphs get
+// GroupAttr set to the subgraph title. Nodes of InputClass and OutputClass
+// become graph inputs and outputs. Edge weights are read from the edge labels
+// in the format written by Marshaler. Styling statements are ignored.
+func (u *Unmarshaler) Unmarshal(data []byte, g hypher.Graph) error {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return fmt.Errorf("unsupported graph: %T", g)
+	}
+	if hg.Nodes().Len() > 0 {
+		return fmt.Errorf("graph %s is not empty", hg.UID())
+	}
+
+	p := newParser()
+	if err := p.parse(data); err != nil {
+		return err
+	}
+
+	if p.title != "" {
+		hg.SetLabel(p.title)
+	}
+
+	var inputs, outputs []*graph.Node
+
+	nodes := make(map[string]*graph.Node, len(p.nodes))
+	for _, fn := range p.nodes {
+		attrs := make(map[string]any)
+		if fn.group != "" {
+			attrs[GroupAttr] = fn.group
+		}
+		label := fn.label
+		if label == "" {
+			label = fn.id
+		}
+		n, err := hg.NewNode(
+			hypher.WithDotID(fn.id),
+			hypher.WithLabel(label),
+			hypher.WithAttrs(attrs),
+		)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", fn.id, err)
+		}
+		nodes[fn.id] = n
+
+		if slices.Contains(fn.classes, InputClass) {
+			inputs = append(inputs, n)
+		}
+		if slices.Contains(fn.classes, OutputClass) {
+			outputs = append(outputs, n)
+		}
+	}
+
+	for _, fe := range p.edges {
+		if err := newEdge(hg, nodes[fe.from], nodes[fe.to], fe.label); err != nil {
+			return fmt.Errorf("line %d: edge %s -> %s: %w", fe.line, fe.from, fe.to, err)
+		}
+	}
+
+	hg.SetInputs(inputs)
+	hg.SetOutputs(outputs)
+
+	return nil
+}
+
+// newEdge links from and to nodes in g with a new edge with the given label.
+func newEdge(g *graph.Graph, from, to *graph.Node, label string) error {
+	if from.ID() == to.ID() {
+		return errors.New("cycle detected: self loop")
+	}
+
+	opts := []hypher.Option{}
+
+	if m := weightRe.FindStringSubmatch(label); m != nil {
+		if w, err := strconv.ParseFloat(m[2], 64); err == nil {
+			label = m[1]
+			opts = append(opts, hypher.WithWeight(w))
+		}
+	}
+	if label != "" {
+		opts = append(opts, hypher.WithLabel(label))
+	}
+
+	e, err := g.NewEdge(from, to, opts...)
+	if err != nil {
+		return err
+	}
+
+	return g.SetEdge(e)
+}
+
+// flowNode is a parsed flowchart node.
+type flowNode struct {
+	id      string
+	label   string
+	group   string
+	classes []string
+}
+
+// flowEdge is a parsed flowchart edge.
+type flowEdge struct {
+	from  string
+	to    string
+	label string
+	line  int
+}
+
+// parser parses Mermaid flowcharts.
+type parser struct {
+	title  string
+	nodes  []*flowNode
+	index  map[string]*flowNode
+	edges  []flowEdge
+	groups []string
+	line   int
+}
+
+func newParser() *parser {
+	return &parser{
+		index: make(map[string]*flowNode),
+	}
+}
+
+// parse parses flowchart data.
+func (p *parser) parse(data []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+
+	var (
+		header      bool
+		frontMatter bool
+	)
+
+	for sc.Scan() {
+		p.line++
+		line := strings.TrimSpace(sc.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "%%"):
+			continue
+		case line == "---" && !header:
+			frontMatter = !frontMatter
+			continue
+		case frontMatter:
+			if title, ok := strings.CutPrefix(line, "title:"); ok {
+				p.title = unquote(strings.TrimSpace(title))
+			}
+			continue
+		}
+
+		for _, stmt := range splitStmts(line) {
+			if !header {
+				if err := p.parseHeader(stmt); err != nil {
+					return fmt.Errorf("line %d: %w", p.line, err)
+				}
+				header = true
+				continue
+			}
+			if err := p.parseStmt(stmt); err != nil {
+				return fmt.Errorf("line %d: %w", p.line, err)
+			}
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	if !header {
+		return errors.New("missing flowchart header")
+	}
+	if len(p.groups) > 0 {
+		return fmt.Errorf("unterminated subgraph: %s", p.groups[len(p.groups)-1])
+	}
+
+	return nil
+}
+
+// parseHeader parses flowchart header.
+func (p *parser) parseHeader(stmt string) error {
+	fields := strings.Fields(stmt)
+	if fields[0] != "flowchart" && fields[0] != "graph" {
+		return fmt.Errorf("unsupported diagram: %s", fields[0])
+	}
+	return nil
+}
+
+// parseStmt parses a single flowchart statement.
+func (p *parser) parseStmt(stmt string) error {
+	keyword, rest, _ := strings.Cut(stmt, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch keyword {
+	case "classDef", "style", "linkStyle", "click", "direction":
+		return nil
+	case "end":
+		if len(p.groups) == 0 {
+			return errors.New("unexpected end")
+		}
+		p.groups = p.groups[:len(p.groups)-1]
+		return nil
+	case "subgraph":
+		return p.parseSubgraph(rest)
+	case "class":
+		return p.parseClass(rest)
+	}
+
+	return p.parseChain(stmt)
+}
+
+// parseSubgraph parses subgraph declaration.
+func (p *parser) parseSubgraph(decl string) error {
+	if decl == "" {
+		return errors.New("missing subgraph id")
+	}
+	title := decl
+	if id, t, ok := strings.Cut(decl, "["); ok {
+		if !strings.HasSuffix(t, "]") {
+			return fmt.Errorf("invalid subgraph: %s", decl)
+		}
+		title = strings.TrimSpace(strings.TrimSuffix(t, "]"))
+		if title == "" {
+			title = id
+		}
+	}
+	p.groups = append(p.groups, unescape(unquote(strings.TrimSpace(title))))
+	return nil
+}
+
+// parseClass parses class assignment.
+func (p *parser) parseClass(decl string) error {
+	fields := strings.Fields(decl)
+	if len(fields) != 2 {
+		return fmt.Errorf("invalid class statement: %s", decl)
+	}
+	for _, id := range strings.Split(fields[0], ",") {
+		n := p.node(strings.TrimSpace(id))
+		n.classes = append(n.classes, fields[1])
+	}
+	return nil
+}
+
+// parseChain parses a chain of nodes linked by edges.
+func (p *parser) parseChain(stmt string) error {
+	s := &scanner{s: stmt}
+
+	from, err := p.parseNode(s)
+	if err != nil {
+		return err
+	}
+
+	for {
+		s.skipSpace()
+		if s.eof() {
+			return nil
+		}
+
+		label, err := parseLink(s)
+		if err != nil {
+			return err
+		}
+
+		to, err := p.parseNode(s)
+		if err != nil {
+			return err
+		}
+
+		p.edges = append(p.edges, flowEdge{
+			from:  from,
+			to:    to,
+			label: label,
+			line:  p.line,
+		})
+		from = to
+	}
+}
+
+// parseNode parses node reference or declaration and returns its ID.
+func (p *parser) parseNode(s *scanner) (string, error) {
+	s.skipSpace()
+
+	id := s.ident()
+	if id == "" {
+		return "", fmt.Errorf("expected node id at %q", s.rest())
+	}
+	n := p.node(id)
+
+	for _, shape := range shapes {
+		if !s.consume(shape.open) {
+			continue
+		}
+		label, err := s.until(shape.close...)
+		if err != nil {
+			return "", fmt.Errorf("node %s: %w", id, err)
+		}
+		n.label = unescape(unquote(strings.TrimSpace(label)))
+		break
+	}
+
+	if s.consume(":::") {
+		class := s.ident()
+		if class == "" {
+			return "", fmt.Errorf("node %s: missing class", id)
+		}
+		n.classes = append(n.classes, class)
+	}
+
+	return id, nil
+}
+
+// parseLink parses an edge link and returns its label.
+func parseLink(s *scanner) (string, error) {
+	switch {
+	case s.consume("-->"):
+		s.skipSpace()
+		if !s.consume("|") {
+			return "", nil
+		}
+		label, err := s.until("|")
+		if err != nil {
+			return "", err
+		}
+		return unescape(unquote(strings.TrimSpace(label))), nil
+	case s.consume("--"):
+		label, err := s.until("-->")
+		if err != nil {
+			return "", err
+		}
+		return unescape(unquote(strings.TrimSpace(label))), nil
+	}
+
+	return "", fmt.Errorf("unsupported link at %q", s.rest())
+}
+
+// node returns the node with the given id creating it if it doesn't exist.
+// Nodes created inside subgraphs are assigned to the innermost subgraph.
+func (p *parser) node(id string) *flowNode {
+	if n, ok := p.index[id]; ok {
+		return n
+	}
+	n := &flowNode{id: id}
+	if len(p.groups) > 0 {
+		n.group = p.groups[len(p.groups)-1]
+	}
+	p.index[id] = n
+	p.nodes = append(p.nodes, n)
+	return n
+}
+
+// scanner scans a flowchart statement.
+type scanner struct {
+	s   string
+	pos int
+}
+
+func (s *scanner) eof() bool { return s.pos >= len(s.s) }
+
+func (s *scanner) rest() string { return s.s[s.pos:] }
+
+func (s *scanner) skipSpace() {
+	for !s.eof() && (s.s[s.pos] == ' ' || s.s[s.pos] == '\t') {
+		s.pos++
+	}
+}
+
+// consume consumes prefix if the remaining input starts with it.
+func (s *scanner) consume(prefix string) bool {
+	if strings.HasPrefix(s.rest(), prefix) {
+		s.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// ident scans an identifier.
+func (s *scanner) ident() string {
+	start := s.pos
+	for _, r := range s.rest() {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		s.pos += len(string(r))
+	}
+	return s.s[start:s.pos]
+}
+
+// until scans text until the first of delims outside of quotes
+// and consumes the delimiter. It returns the scanned text.
+func (s *scanner) until(delims ...string) (string, error) {
+	start := s.pos
+	quoted := false
+	for !s.eof() {
+		if s.s[s.pos] == '"' {
+			quoted = !quoted
+		}
+		if !quoted {
+			for _, d := range delims {
+				if strings.HasPrefix(s.rest(), d) {
+					text := s.s[start:s.pos]
+					s.pos += len(d)
+					return text, nil
+				}
+			}
+		}
+		s.pos++
+	}
+	return "", fmt.Errorf("expected %q", delims[0])
+}
+
+// splitStmts splits line into statements separated by semicolons outside of quotes.
+func splitStmts(line string) []string {
+	var (
+		stmts  []string
+		quoted bool
+		start  int
+	)
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			stmts = append(stmts, line[start:i])
+			start = i + 1
+		}
+	}
+	stmts = append(stmts, line[start:])
+
+	res := stmts[:0]
+	for _, stmt := range stmts {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			res = append(res, stmt)
+		}
+	}
+	return res
+}
+
+// unquote strips the double quotes surrounding s.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
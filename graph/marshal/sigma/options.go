@@ -0,0 +1,19 @@
+package sigma
+
+import "github.com/milosgajdos/go-hypher/graph/layout"
+
+// Options configure marshaler.
+type Options struct {
+	// Layout configures node positions.
+	Layout *layout.Layout
+}
+
+// Option is functional marshaler option.
+type Option func(*Options)
+
+// WithLayout sets the layout whose node centers are used as node positions.
+func WithLayout(l *layout.Layout) Option {
+	return func(o *Options) {
+		o.Layout = l
+	}
+}
@@ -1,58 +1,265 @@
 package sigma
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/layout"
 	"gonum.org/v1/gonum/graph/formats/sigmajs"
 )
 
+const (
+	// LabelKey is attribute key of node and edge labels.
+	LabelKey = "label"
+	// WeightKey is attribute key of edge weights.
+	WeightKey = "weight"
+	// DOTIDKey is attribute key of node DOT IDs.
+	DOTIDKey = "dotid"
+	// InputKey is attribute key which marks graph input nodes.
+	InputKey = "hypher_input"
+	// OutputKey is attribute key which marks graph output nodes.
+	OutputKey = "hypher_output"
+	// XKey is attribute key of node x coordinates.
+	XKey = "x"
+	// YKey is attribute key of node y coordinates.
+	YKey = "y"
+)
+
 // Marshaler implements graph.Marshaler.
 type Marshaler struct {
 	name   string
 	prefix string
 	indent string
+	layout *layout.Layout
 }
 
 // NewMarshaler creates a new Marshaler and returns it.
-func NewMarshaler(name, prefix, indent string) (*Marshaler, error) {
+func NewMarshaler(name, prefix, indent string, opts ...Option) (*Marshaler, error) {
+	mOpts := Options{}
+
+	for _, apply := range opts {
+		apply(&mOpts)
+	}
+
 	return &Marshaler{
 		name:   name,
 		prefix: prefix,
 		indent: indent,
+		layout: mOpts.Layout,
 	}, nil
 }
 
 // Marshal marshals g into format that can be used by
 // SigmaJS. See here for more: http://sigmajs.org/
+// Nodes and edges are identified by their UIDs. Node and edge
+// labels, node DOT IDs and edge weights are stored along with
+// the attributes. Graph input and output nodes have InputKey
+// and OutputKey attributes set to true. If a layout is configured,
+// the centers of the laid out nodes are stored in XKey and YKey
+// attributes.
 func (m *Marshaler) Marshal(g hypher.Graph) ([]byte, error) {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return nil, fmt.Errorf("unsupported graph: %T", g)
+	}
+
 	c := sigmajs.Graph{
 		Nodes: make([]sigmajs.Node, 0, g.Nodes().Len()),
 		Edges: make([]sigmajs.Edge, 0, g.Edges().Len()),
 	}
 
-	nodes := g.Nodes()
-	for nodes.Next() {
-		n := nodes.Node().(hypher.Node)
+	inputs, outputs := hg.Inputs(), hg.Outputs()
+
+	for _, n := range sortedNodes(hg) {
+		attrs := cloneAttrs(n.Attrs())
+		attrs[LabelKey] = n.Label()
+		attrs[DOTIDKey] = n.DOTID()
+		if slices.Contains(inputs, n) {
+			attrs[InputKey] = true
+		}
+		if slices.Contains(outputs, n) {
+			attrs[OutputKey] = true
+		}
+		if m.layout != nil {
+			if p, ok := m.layout.Nodes[n.UID()]; ok {
+				attrs[XKey] = p.X
+				attrs[YKey] = p.Y
+			}
+		}
 
 		c.Nodes = append(c.Nodes, sigmajs.Node{
-			ID:         fmt.Sprint(n.ID()),
-			Attributes: n.Attrs(),
+			ID:         n.UID(),
+			Attributes: attrs,
 		})
 	}
 
-	edges := g.Edges()
-	for edges.Next() {
-		e := edges.Edge().(hypher.Edge)
+	for _, e := range sortedEdges(hg) {
+		attrs := cloneAttrs(e.Attrs())
+		attrs[LabelKey] = e.Label()
+		attrs[WeightKey] = e.Weight()
 
 		c.Edges = append(c.Edges, sigmajs.Edge{
 			ID:         e.UID(),
-			Source:     fmt.Sprint(e.From().ID()),
-			Target:     fmt.Sprint(e.To().ID()),
-			Attributes: e.Attrs(),
+			Source:     e.From().(*graph.Node).UID(),
+			Target:     e.To().(*graph.Node).UID(),
+			Attributes: attrs,
 		})
 	}
 
 	return json.MarshalIndent(c, m.prefix, m.indent)
 }
+
+// Unmarshaler implements hypher.Unmarshaler.
+type Unmarshaler struct{}
+
+// NewUnmarshaler creates a new Unmarshaler and returns it.
+func NewUnmarshaler() (*Unmarshaler, error) {
+	return &Unmarshaler{}, nil
+}
+
+// Unmarshal unmarshals SigmaJS JSON data into g which must be an empty *graph.Graph.
+// Node and edge IDs are used as their UIDs. The nodes with InputKey or OutputKey
+// attributes set to true become graph inputs and outputs. Node and edge attributes
+// are mapped to labels, DOT IDs, weights and attrs.
+func (u *Unmarshaler) Unmarshal(data []byte, g hypher.Graph) error {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return fmt.Errorf("unsupported graph: %T", g)
+	}
+	if hg.Nodes().Len() > 0 {
+		return fmt.Errorf("graph %s is not empty", hg.UID())
+	}
+
+	var sg sigmajs.Graph
+	if err := json.Unmarshal(data, &sg); err != nil {
+		return err
+	}
+
+	var inputs, outputs []*graph.Node
+
+	nodes := make(map[string]*graph.Node, len(sg.Nodes))
+	for _, sn := range sg.Nodes {
+		if _, ok := nodes[sn.ID]; ok {
+			return fmt.Errorf("node %s: duplicate id", sn.ID)
+		}
+
+		attrs := cloneAttrs(sn.Attributes)
+
+		opts := []hypher.Option{
+			hypher.WithUID(sn.ID),
+			hypher.WithDotID(sn.ID),
+		}
+		if label, ok := attrs[LabelKey].(string); ok {
+			opts = append(opts, hypher.WithLabel(label))
+		}
+		if dotid, ok := attrs[DOTIDKey].(string); ok && dotid != "" {
+			opts = append(opts, hypher.WithDotID(dotid))
+		}
+		isInput, _ := attrs[InputKey].(bool)
+		isOutput, _ := attrs[OutputKey].(bool)
+		for _, k := range []string{LabelKey, DOTIDKey, InputKey, OutputKey} {
+			delete(attrs, k)
+		}
+		opts = append(opts, hypher.WithAttrs(attrs))
+
+		n, err := hg.NewNode(opts...)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", sn.ID, err)
+		}
+		nodes[sn.ID] = n
+
+		if isInput {
+			inputs = append(inputs, n)
+		}
+		if isOutput {
+			outputs = append(outputs, n)
+		}
+	}
+
+	for _, se := range sg.Edges {
+		if err := newEdge(hg, nodes, se); err != nil {
+			return fmt.Errorf("edge %s -> %s: %w", se.Source, se.Target, err)
+		}
+	}
+
+	hg.SetInputs(inputs)
+	hg.SetOutputs(outputs)
+
+	return nil
+}
+
+// newEdge creates a new edge in g from SigmaJS edge se.
+func newEdge(g *graph.Graph, nodes map[string]*graph.Node, se sigmajs.Edge) error {
+	from, ok := nodes[se.Source]
+	if !ok {
+		return fmt.Errorf("unknown source node: %s", se.Source)
+	}
+	to, ok := nodes[se.Target]
+	if !ok {
+		return fmt.Errorf("unknown target node: %s", se.Target)
+	}
+	if from.ID() == to.ID() {
+		return errors.New("cycle detected: self loop")
+	}
+
+	attrs := cloneAttrs(se.Attributes)
+
+	opts := []hypher.Option{
+		hypher.WithUID(se.ID),
+	}
+	if label, ok := attrs[LabelKey].(string); ok {
+		opts = append(opts, hypher.WithLabel(label))
+	}
+	if w, ok := attrs[WeightKey]; ok {
+		weight, ok := w.(float64)
+		if !ok {
+			return fmt.Errorf("invalid %s: %v", WeightKey, w)
+		}
+		opts = append(opts, hypher.WithWeight(weight))
+	}
+	delete(attrs, LabelKey)
+	delete(attrs, WeightKey)
+	opts = append(opts, hypher.WithAttrs(attrs))
+
+	e, err := g.NewEdge(from, to, opts...)
+	if err != nil {
+		return err
+	}
+
+	return g.SetEdge(e)
+}
+
+func sortedNodes(g *graph.Graph) []*graph.Node {
+	var nodes []*graph.Node
+	it := g.Nodes()
+	for it.Next() {
+		nodes = append(nodes, it.Node().(*graph.Node))
+	}
+	slices.SortFunc(nodes, func(a, b *graph.Node) int { return cmp.Compare(a.ID(), b.ID()) })
+	return nodes
+}
+
+func sortedEdges(g *graph.Graph) []*graph.Edge {
+	var edges []*graph.Edge
+	it := g.Edges()
+	for it.Next() {
+		edges = append(edges, it.Edge().(*graph.Edge))
+	}
+	slices.SortFunc(edges, func(a, b *graph.Edge) int {
+		return cmp.Or(cmp.Compare(a.From().ID(), b.From().ID()), cmp.Compare(a.To().ID(), b.To().ID()))
+	})
+	return edges
+}
+
+// cloneAttrs returns a copy of attrs which is never nil.
+func cloneAttrs(attrs map[string]any) map[string]any {
+	c := make(map[string]any, len(attrs))
+	maps.Copy(c, attrs)
+	return c
+}
@@ -0,0 +1,259 @@
+package sigma
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/layout"
+	"gonum.org/v1/gonum/graph/formats/sigmajs"
+)
+
+func MustGraph(t *testing.T, opts ...hypher.Option) *graph.Graph {
+	g, err := graph.NewGraph(opts...)
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	return g
+}
+
+// MustAgentGraph returns graph: in -> plan -> out.
+func MustAgentGraph(t *testing.T) *graph.Graph {
+	g := MustGraph(t)
+
+	newNode := func(uid string, attrs map[string]any) *graph.Node {
+		n, err := g.NewNode(
+			hypher.WithUID(uid),
+			hypher.WithDotID(uid+"_dot"),
+			hypher.WithLabel(strings.ToUpper(uid)),
+			hypher.WithAttrs(attrs),
+		)
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		return n
+	}
+
+	in := newNode("in", map[string]any{"name": "query"})
+	plan := newNode("plan", map[string]any{"temperature": 0.7})
+	out := newNode("out", nil)
+
+	for _, pair := range [][2]*graph.Node{{in, plan}, {plan, out}} {
+		e, err := g.NewEdge(pair[0], pair[1],
+			hypher.WithUID(pair[0].UID()+"-"+pair[1].UID()),
+			hypher.WithLabel("next"),
+			hypher.WithWeight(2.5),
+			hypher.WithAttrs(map[string]any{"cost": 1.5}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create edge: %v", err)
+		}
+		if err := g.SetEdge(e); err != nil {
+			t.Fatalf("failed to set edge: %v", err)
+		}
+	}
+
+	g.SetInputs([]*graph.Node{in})
+	g.SetOutputs([]*graph.Node{out})
+
+	return g
+}
+
+func MustMarshal(t *testing.T, g *graph.Graph) []byte {
+	m, err := NewMarshaler("", "", "  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+	return data
+}
+
+func MustUnmarshal(t *testing.T, data []byte) *graph.Graph {
+	u, err := NewUnmarshaler()
+	if err != nil {
+		t.Fatalf("failed to create unmarshaler: %v", err)
+	}
+	g := MustGraph(t)
+	if err := u.Unmarshal(data, g); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	return g
+}
+
+func nodeByUID(t *testing.T, g *graph.Graph, uid string) *graph.Node {
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		if n.UID() == uid {
+			return n
+		}
+	}
+	t.Fatalf("node %s not found", uid)
+	return nil
+}
+
+func TestMarshal(t *testing.T) {
+	g := MustAgentGraph(t)
+	data := MustMarshal(t, g)
+
+	var sg sigmajs.Graph
+	if err := json.Unmarshal(data, &sg); err != nil {
+		t.Fatalf("failed to decode graph: %v", err)
+	}
+
+	if len(sg.Nodes) != 3 || len(sg.Edges) != 2 {
+		t.Fatalf("expected 3 nodes and 2 edges, got: %d, %d", len(sg.Nodes), len(sg.Edges))
+	}
+
+	in := sg.Nodes[0]
+	want := map[string]any{
+		"name":   "query",
+		LabelKey: "IN",
+		DOTIDKey: "in_dot",
+		InputKey: true,
+	}
+	if in.ID != "in" || !reflect.DeepEqual(in.Attributes, want) {
+		t.Errorf("unexpected input node: %+v", in)
+	}
+	if v := sg.Nodes[2].Attributes[OutputKey]; v != true {
+		t.Errorf("expected %s: true, got: %v", OutputKey, v)
+	}
+
+	e := sg.Edges[0]
+	if e.ID != "in-plan" || e.Source != "in" || e.Target != "plan" {
+		t.Errorf("unexpected edge: %+v", e)
+	}
+	if e.Attributes[LabelKey] != "next" || e.Attributes[WeightKey] != 2.5 {
+		t.Errorf("unexpected edge attributes: %v", e.Attributes)
+	}
+
+	// marshaling must not modify the graph attributes
+	if attrs := nodeByUID(t, g, "in").Attrs(); !reflect.DeepEqual(attrs, map[string]any{"name": "query"}) {
+		t.Errorf("graph node attrs modified: %v", attrs)
+	}
+}
+
+func TestMarshalLayout(t *testing.T) {
+	g := MustAgentGraph(t)
+
+	s, err := layout.NewSugiyama()
+	if err != nil {
+		t.Fatalf("failed to create layout: %v", err)
+	}
+	l, err := s.Layout(g)
+	if err != nil {
+		t.Fatalf("failed to compute layout: %v", err)
+	}
+
+	m, err := NewMarshaler("", "", "  ", WithLayout(l))
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	var sg sigmajs.Graph
+	if err := json.Unmarshal(data, &sg); err != nil {
+		t.Fatalf("failed to decode graph: %v", err)
+	}
+
+	for _, n := range sg.Nodes {
+		want := l.Nodes[n.ID]
+		if n.Attributes[XKey] != want.X || n.Attributes[YKey] != want.Y {
+			t.Errorf("node %s: expected position: %v, got: %v, %v", n.ID, want, n.Attributes[XKey], n.Attributes[YKey])
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	g := MustUnmarshal(t, MustMarshal(t, MustAgentGraph(t)))
+
+	in := nodeByUID(t, g, "in")
+	plan := nodeByUID(t, g, "plan")
+	out := nodeByUID(t, g, "out")
+
+	if in.Label() != "IN" || in.DOTID() != "in_dot" {
+		t.Errorf("unexpected node label: %s, dotid: %s", in.Label(), in.DOTID())
+	}
+	if !reflect.DeepEqual(in.Attrs(), map[string]any{"name": "query"}) {
+		t.Errorf("unexpected node attrs: %v", in.Attrs())
+	}
+	if v := plan.Attrs()["temperature"]; v != 0.7 {
+		t.Errorf("expected temperature: 0.7, got: %v", v)
+	}
+
+	e := g.Edge(in.ID(), plan.ID()).(*graph.Edge)
+	if e.UID() != "in-plan" || e.Label() != "next" || e.Weight() != 2.5 {
+		t.Errorf("unexpected edge uid: %s, label: %s, weight: %f", e.UID(), e.Label(), e.Weight())
+	}
+	if !reflect.DeepEqual(e.Attrs(), map[string]any{"cost": 1.5}) {
+		t.Errorf("unexpected edge attrs: %v", e.Attrs())
+	}
+
+	if inputs := g.Inputs(); len(inputs) != 1 || inputs[0] != in {
+		t.Errorf("expected inputs: [in], got: %v", inputs)
+	}
+	if outputs := g.Outputs(); len(outputs) != 1 || outputs[0] != out {
+		t.Errorf("expected outputs: [out], got: %v", outputs)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "cycle",
+			data: `{"nodes": [{"id": "a"}, {"id": "b"}],
+				"edges": [{"id": "ab", "source": "a", "target": "b"}, {"id": "ba", "source": "b", "target": "a"}]}`,
+			want: "edge b -> a",
+		},
+		{
+			name: "self loop",
+			data: `{"nodes": [{"id": "a"}], "edges": [{"id": "aa", "source": "a", "target": "a"}]}`,
+			want: "edge a -> a",
+		},
+		{
+			name: "unknown node",
+			data: `{"nodes": [{"id": "a"}], "edges": [{"id": "ax", "source": "a", "target": "x"}]}`,
+			want: "unknown target node: x",
+		},
+		{
+			name: "duplicate node",
+			data: `{"nodes": [{"id": "a"}, {"id": "a"}]}`,
+			want: "duplicate id",
+		},
+		{
+			name: "invalid weight",
+			data: `{"nodes": [{"id": "a"}, {"id": "b"}],
+				"edges": [{"id": "ab", "source": "a", "target": "b", "weight": "x"}]}`,
+			want: "invalid weight",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := NewUnmarshaler()
+			if err != nil {
+				t.Fatalf("failed to create unmarshaler: %v", err)
+			}
+			err = u.Unmarshal([]byte(tc.data), MustGraph(t))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
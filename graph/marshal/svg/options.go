@@ -0,0 +1,36 @@
+package svg
+
+import (
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph/layout"
+	"github.com/milosgajdos/go-hypher/graph/marshal/dot"
+)
+
+// StatusFunc returns the run status of node n.
+type StatusFunc func(n hypher.Node) dot.Status
+
+// Options configure SVG marshaler.
+type Options struct {
+	// LayoutOptions configure the graph layout.
+	LayoutOptions []layout.Option
+	// Status configures node status source.
+	Status StatusFunc
+}
+
+// Option is functional SVG marshaler option.
+type Option func(*Options)
+
+// WithLayoutOptions sets graph layout options.
+func WithLayoutOptions(opts ...layout.Option) Option {
+	return func(o *Options) {
+		o.LayoutOptions = append(o.LayoutOptions, opts...)
+	}
+}
+
+// WithStatus sets node status source.
+// Nodes are colored by the status returned by fn.
+func WithStatus(fn StatusFunc) Option {
+	return func(o *Options) {
+		o.Status = fn
+	}
+}
@@ -0,0 +1,215 @@
+// Package svg renders hypher graphs into SVG without Graphviz.
+package svg
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/layout"
+	"github.com/milosgajdos/go-hypher/graph/marshal/dot"
+)
+
+const (
+	// Namespace is SVG XML namespace.
+	Namespace = "http://www.w3.org/2000/svg"
+	// FontSize is the font size of node and edge labels.
+	FontSize = 12
+)
+
+var (
+	// StrokeColor is the color of node borders and edges.
+	StrokeColor = color.RGBA{R: 51, G: 51, B: 51}
+	// TextColor is the color of node and edge labels.
+	TextColor = color.RGBA{R: 0, G: 0, B: 0}
+)
+
+// Marshaler implements hypher.Marshaler.
+type Marshaler struct {
+	layout *layout.Sugiyama
+	status StatusFunc
+}
+
+// NewMarshaler creates a new SVG marshaler and returns it.
+func NewMarshaler(opts ...Option) (*Marshaler, error) {
+	svgOpts := Options{}
+
+	for _, apply := range opts {
+		apply(&svgOpts)
+	}
+
+	l, err := layout.NewSugiyama(svgOpts.LayoutOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Marshaler{
+		layout: l,
+		status: svgOpts.Status,
+	}, nil
+}
+
+// Marshal renders g into SVG.
+// Nodes are laid out by layout.Sugiyama and drawn as boxes with their labels.
+// Edges are drawn as arrows annotated with their labels and weights; default
+// edge labels and weights are omitted. If a status source is configured, nodes
+// are filled with dot.StatusColors of their statuses, otherwise they are filled
+// with dot.DefaultNodeColor. Graph input and output nodes are drawn with bold
+// borders. Node UIDs and statuses are rendered as node tooltips.
+func (m *Marshaler) Marshal(g hypher.Graph) ([]byte, error) {
+	hg, ok := g.(*graph.Graph)
+	if !ok {
+		return nil, fmt.Errorf("unsupported graph: %T", g)
+	}
+
+	l, err := m.layout.Layout(hg)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, `<svg xmlns="%s" width="%s" height="%s" viewBox="0 0 %s %s" font-family="sans-serif" font-size="%d">`+"\n",
+		Namespace, num(l.Width), num(l.Height), num(l.Width), num(l.Height), FontSize)
+	if label := hg.Label(); label != "" {
+		fmt.Fprintf(&b, "  <title>%s</title>\n", escape(label))
+	}
+	fmt.Fprintf(&b, `  <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker></defs>`+"\n", hex(StrokeColor))
+
+	for _, e := range sortedEdges(hg) {
+		m.writeEdge(&b, l, e)
+	}
+
+	roles := make(map[int64][]string)
+	for _, n := range hg.Inputs() {
+		roles[n.ID()] = append(roles[n.ID()], "input")
+	}
+	for _, n := range hg.Outputs() {
+		roles[n.ID()] = append(roles[n.ID()], "output")
+	}
+
+	for _, n := range sortedNodes(hg) {
+		m.writeNode(&b, l, n, roles[n.ID()])
+	}
+
+	b.WriteString("</svg>\n")
+
+	return b.Bytes(), nil
+}
+
+// writeNode writes node n into b.
+func (m *Marshaler) writeNode(b *bytes.Buffer, l *layout.Layout, n *graph.Node, roles []string) {
+	p := l.Nodes[n.UID()]
+
+	fill := dot.DefaultNodeColor
+	tooltip := n.UID()
+	if m.status != nil {
+		s := m.status(n)
+		if c, ok := dot.StatusColors[s]; ok {
+			fill = c
+		}
+		tooltip += ": " + string(s)
+	}
+
+	strokeWidth := 1
+	if len(roles) > 0 {
+		strokeWidth = 2
+	}
+
+	fmt.Fprintf(b, `  <g class="%s" id="%s">`+"\n", strings.Join(append([]string{"node"}, roles...), " "), escape("node-"+n.UID()))
+	fmt.Fprintf(b, "    <title>%s</title>\n", escape(tooltip))
+	fmt.Fprintf(b, `    <rect x="%s" y="%s" width="%s" height="%s" rx="6" fill="%s" stroke="%s" stroke-width="%d"/>`+"\n",
+		num(p.X-l.NodeWidth/2), num(p.Y-l.NodeHeight/2), num(l.NodeWidth), num(l.NodeHeight), hex(fill), hex(StrokeColor), strokeWidth)
+	fmt.Fprintf(b, `    <text x="%s" y="%s" text-anchor="middle" dominant-baseline="central" fill="%s">%s</text>`+"\n",
+		num(p.X), num(p.Y), hex(TextColor), escape(n.Label()))
+	b.WriteString("  </g>\n")
+}
+
+// writeEdge writes edge e into b.
+// Edges start at the bottom of their source nodes
+// and end at the top of their target nodes.
+func (m *Marshaler) writeEdge(b *bytes.Buffer, l *layout.Layout, e *graph.Edge) {
+	points := slices.Clone(l.Edges[e.UID()])
+	if len(points) < 2 {
+		return
+	}
+	points[0].Y += l.NodeHeight / 2
+	points[len(points)-1].Y -= l.NodeHeight / 2
+
+	coords := make([]string, 0, len(points))
+	for _, p := range points {
+		coords = append(coords, num(p.X)+","+num(p.Y))
+	}
+
+	fmt.Fprintf(b, `  <g class="edge" id="%s">`+"\n", escape("edge-"+e.UID()))
+	fmt.Fprintf(b, `    <polyline points="%s" fill="none" stroke="%s" marker-end="url(#arrow)"/>`+"\n",
+		strings.Join(coords, " "), hex(StrokeColor))
+	if label := edgeLabel(e); label != "" {
+		// label the middle segment of the edge
+		i := (len(points) - 1) / 2
+		x, y := (points[i].X+points[i+1].X)/2, (points[i].Y+points[i+1].Y)/2
+		fmt.Fprintf(b, `    <text x="%s" y="%s" dx="4" dominant-baseline="central" fill="%s">%s</text>`+"\n",
+			num(x), num(y), hex(TextColor), escape(label))
+	}
+	b.WriteString("  </g>\n")
+}
+
+// edgeLabel returns the label drawn next to edge e.
+func edgeLabel(e *graph.Edge) string {
+	var parts []string
+	if label := e.Label(); label != "" && label != graph.DefaultEdgeLabel {
+		parts = append(parts, label)
+	}
+	if w := e.Weight(); w != graph.DefaultEdgeWeight {
+		parts = append(parts, "w="+strconv.FormatFloat(w, 'g', -1, 64))
+	}
+	return strings.Join(parts, " ")
+}
+
+// num formats v rounded to two decimal places.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// hex returns c as a hex color.
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// escape escapes s for use in XML text and attributes.
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func sortedNodes(g *graph.Graph) []*graph.Node {
+	var nodes []*graph.Node
+	it := g.Nodes()
+	for it.Next() {
+		nodes = append(nodes, it.Node().(*graph.Node))
+	}
+	slices.SortFunc(nodes, func(a, b *graph.Node) int { return cmp.Compare(a.ID(), b.ID()) })
+	return nodes
+}
+
+func sortedEdges(g *graph.Graph) []*graph.Edge {
+	var edges []*graph.Edge
+	it := g.Edges()
+	for it.Next() {
+		edges = append(edges, it.Edge().(*graph.Edge))
+	}
+	slices.SortFunc(edges, func(a, b *graph.Edge) int {
+		return cmp.Or(cmp.Compare(a.From().ID(), b.From().ID()), cmp.Compare(a.To().ID(), b.To().ID()))
+	})
+	return edges
+}
@@ -0,0 +1,139 @@
+package svg
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/layout"
+	"github.com/milosgajdos/go-hypher/graph/marshal/dot"
+)
+
+// MustAgentGraph returns graph: in -> plan -> out, in -> out.
+func MustAgentGraph(t *testing.T) *graph.Graph {
+	g, err := graph.NewGraph(hypher.WithLabel("Agent <v2>"))
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+
+	nodes := make(map[string]*graph.Node)
+	for _, uid := range []string{"in", "plan", "out"} {
+		n, err := g.NewNode(hypher.WithUID(uid), hypher.WithLabel(strings.ToUpper(uid)))
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		nodes[uid] = n
+	}
+
+	edges := []struct {
+		from, to string
+		opts     []hypher.Option
+	}{
+		{"in", "plan", []hypher.Option{hypher.WithLabel("ask"), hypher.WithWeight(2.5)}},
+		{"plan", "out", nil},
+		{"in", "out", nil},
+	}
+	for _, e := range edges {
+		opts := append([]hypher.Option{hypher.WithUID(e.from + "-" + e.to)}, e.opts...)
+		edge, err := g.NewEdge(nodes[e.from], nodes[e.to], opts...)
+		if err != nil {
+			t.Fatalf("failed to create edge: %v", err)
+		}
+		if err := g.SetEdge(edge); err != nil {
+			t.Fatalf("failed to set edge: %v", err)
+		}
+	}
+
+	g.SetInputs([]*graph.Node{nodes["in"]})
+	g.SetOutputs([]*graph.Node{nodes["out"]})
+
+	return g
+}
+
+func MustMarshal(t *testing.T, g *graph.Graph, opts ...Option) string {
+	m, err := NewMarshaler(opts...)
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+	data, err := m.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+
+	// the output must be well formed XML
+	dec := xml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		if _, err := dec.Token(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("invalid SVG: %v", err)
+			}
+			break
+		}
+	}
+
+	return string(data)
+}
+
+func TestMarshal(t *testing.T) {
+	out := MustMarshal(t, MustAgentGraph(t), WithLayoutOptions(layout.WithNodeSize(100, 30)))
+
+	for _, want := range []string{
+		`<title>Agent &lt;v2&gt;</title>`,
+		`<g class="node input" id="node-in">`,
+		`<g class="node output" id="node-out">`,
+		`<g class="node" id="node-plan">`,
+		`width="100" height="30"`,
+		`>PLAN</text>`,
+		`>ask w=2.5</text>`,
+		`fill="#e6e6e6"`,
+		`stroke-width="2"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected SVG to contain %q:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, graph.DefaultEdgeLabel) {
+		t.Errorf("expected default edge labels to be omitted:\n%s", out)
+	}
+	if n := strings.Count(out, "<polyline"); n != 3 {
+		t.Errorf("expected 3 edges, got: %d", n)
+	}
+}
+
+func TestMarshalStatus(t *testing.T) {
+	status := map[string]dot.Status{
+		"in":   dot.StatusSucceeded,
+		"plan": dot.StatusFailed,
+	}
+	fn := func(n hypher.Node) dot.Status {
+		if s, ok := status[n.UID()]; ok {
+			return s
+		}
+		return dot.StatusPending
+	}
+
+	out := MustMarshal(t, MustAgentGraph(t), WithStatus(fn))
+
+	for _, want := range []string{
+		"<title>in: succeeded</title>",
+		"<title>plan: failed</title>",
+		"<title>out: pending</title>",
+		`fill="#c8e6c9"`,
+		`fill="#ffcdd2"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected SVG to contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestNewMarshalerError(t *testing.T) {
+	if _, err := NewMarshaler(WithLayoutOptions(layout.WithNodeSize(-1, 10))); err == nil {
+		t.Fatal("expected error")
+	}
+}
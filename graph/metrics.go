@@ -0,0 +1,11 @@
+package graph
+
+import "time"
+
+// noopMetrics is used when no metrics are configured.
+type noopMetrics struct{}
+
+func (noopMetrics) RunStarted()                           {}
+func (noopMetrics) RunFinished(time.Duration, error)      {}
+func (noopMetrics) NodeExec(string, time.Duration, error) {}
+func (noopMetrics) NodeQueueWait(string, time.Duration)   {}
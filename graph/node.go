@@ -2,10 +2,12 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"gonum.org/v1/gonum/graph/encoding"
@@ -34,6 +36,8 @@ type Node struct {
 	graph hypher.Graph
 	// node Op
 	op hypher.Op
+	// Op execution timeout
+	timeout time.Duration
 	// Node I/O
 	inputs  []hypher.Value
 	outputs []hypher.Value
@@ -64,6 +68,7 @@ func NewNode(opts ...hypher.Option) (*Node, error) {
 		attrs:   nopts.Attrs,
 		graph:   nopts.Graph,
 		op:      nopts.Op,
+		timeout: nopts.Timeout,
 		inputs:  []hypher.Value{},
 		outputs: []hypher.Value{},
 	}
@@ -95,7 +100,11 @@ func (n *Node) SetUID(uid string) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
+	if g, ok := n.graph.(*Graph); ok {
+		g.changes.removeNode(n.uid)
+	}
 	n.uid = uid
+	n.touch()
 }
 
 // Label returns node label.
@@ -112,9 +121,12 @@ func (n *Node) SetLabel(l string) {
 	defer n.mu.Unlock()
 
 	n.label = l
+	n.touch()
 }
 
 // Attrs returns node attributes.
+// Changes made directly to the returned map
+// are not recorded in the node graph changes.
 func (n *Node) Attrs() map[string]any {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
@@ -122,6 +134,26 @@ func (n *Node) Attrs() map[string]any {
 	return n.attrs
 }
 
+// SetAttr sets node attribute key to val.
+func (n *Node) SetAttr(key string, val any) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.attrs == nil {
+		n.attrs = make(map[string]any)
+	}
+	n.attrs[key] = val
+	n.touch()
+}
+
+// touch records the change of the node in its graph.
+// It must be called with the node lock held.
+func (n *Node) touch() {
+	if g, ok := n.graph.(*Graph); ok {
+		g.changes.touchNode(n.uid)
+	}
+}
+
 // Graph returns the node graph.
 func (n *Node) Graph() hypher.Graph {
 	n.mu.RLock()
@@ -172,6 +204,15 @@ func (n *Node) Op() hypher.Op {
 	return n.op
 }
 
+// Timeout returns node Op execution timeout.
+// Zero timeout means no timeout.
+func (n *Node) Timeout() time.Duration {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return n.timeout
+}
+
 // DOTID returns GraphViz DOT ID.
 func (n *Node) DOTID() string {
 	n.mu.RLock()
@@ -186,6 +227,7 @@ func (n *Node) SetDOTID(dotid string) {
 	defer n.mu.Unlock()
 
 	n.dotid = dotid
+	n.touch()
 }
 
 // Attributes returns node DOT attributes.
@@ -247,19 +289,45 @@ func (n *Node) CloneTo(g *Graph) (*Node, error) {
 	return n2, nil
 }
 
+// graphMetrics returns the metrics of the node graph.
+// It must not be called with the node lock held as
+// graph locks must never be taken after node locks.
+func (n *Node) graphMetrics() hypher.Metrics {
+	if g, ok := n.Graph().(*Graph); ok {
+		return g.Metrics()
+	}
+	return noopMetrics{}
+}
+
 // Exec executes a node Op and returns its result.
 // It appends the output of the Op to its outputs.
+// The Op execution latency is recorded in the node graph Metrics.
+// If the node has a timeout, the Op context is canceled when it expires.
 func (n *Node) Exec(ctx context.Context, inputs ...hypher.Value) ([]hypher.Value, error) {
+	metrics := n.graphMetrics()
+
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
+	if n.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.timeout)
+		defer cancel()
+	}
+
 	opInputs := make([]hypher.Value, len(n.inputs)+len(inputs))
 	copy(opInputs, n.inputs)
 	copy(opInputs[len(n.inputs):], inputs)
 
+	start := time.Now()
 	outputs, err := n.op.Do(ctx, opInputs...)
+	metrics.NodeExec(n.op.Type(), time.Since(start), err)
 	if err != nil {
-		return nil, fmt.Errorf("node %s op: %s error: %v", n.UID(), n.op, err)
+		var cerr *ConversionError
+		if errors.As(err, &cerr) && cerr.Node == "" {
+			cerr.Node = n.uid
+		}
+		return nil, fmt.Errorf("node %s op: %s error: %w", n.uid, n.op, err)
 	}
 	n.outputs = append(n.outputs, outputs...)
 
@@ -1,8 +1,11 @@
 package graph
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/milosgajdos/go-hypher"
 )
@@ -183,3 +186,44 @@ func TestNodeSetInputs(t *testing.T) {
 		t.Errorf("expected inputs: %v, got: %v", inputs, n.Inputs())
 	}
 }
+
+func TestNodeExecTimeout(t *testing.T) {
+	n := MustNode(t, hypher.WithOp(blockOp{}), hypher.WithTimeout(10*time.Millisecond))
+
+	if n.Timeout() != 10*time.Millisecond {
+		t.Errorf("expected timeout: %v, got: %v", 10*time.Millisecond, n.Timeout())
+	}
+
+	if _, err := n.Exec(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got: %v", err)
+	}
+}
+
+func TestNodeExecGraphEdit(t *testing.T) {
+	g := MustGraph(t)
+	n := MustNode(t, hypher.WithOp(blockOp{}), hypher.WithTimeout(20*time.Millisecond))
+	if err := g.AddNode(n); err != nil {
+		t.Fatalf("failed to add node: %v", err)
+	}
+
+	exec, edit := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exec)
+		// nolint:errcheck
+		n.Exec(context.Background())
+	}()
+	go func() {
+		defer close(edit)
+		// edit the graph while the node is being executed
+		time.Sleep(5 * time.Millisecond)
+		g.SetInputs([]*Node{n})
+	}()
+
+	for _, done := range []chan struct{}{exec, edit} {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("node exec deadlocked with graph edit")
+		}
+	}
+}
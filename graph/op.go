@@ -17,3 +17,28 @@ func (op NoOp) String() string { return fmt.Sprintf("Op: %s, Desc: %s", op.Type(
 func (op NoOp) Do(_ context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
 	return []hypher.Value{}, nil
 }
+
+// funcOp is an Op which runs a function.
+type funcOp struct {
+	name string
+	desc string
+	fn   func(context.Context, ...hypher.Value) ([]hypher.Value, error)
+}
+
+// FuncOp returns an Op with the given name and description which runs fn.
+// The name is returned as the Op type.
+func FuncOp(name, desc string, fn func(ctx context.Context, inputs ...hypher.Value) ([]hypher.Value, error)) hypher.Op {
+	return &funcOp{
+		name: name,
+		desc: desc,
+		fn:   fn,
+	}
+}
+
+func (op *funcOp) Type() string   { return op.name }
+func (op *funcOp) Desc() string   { return op.desc }
+func (op *funcOp) String() string { return fmt.Sprintf("Op: %s, Desc: %s", op.Type(), op.Desc()) }
+
+func (op *funcOp) Do(ctx context.Context, inputs ...hypher.Value) ([]hypher.Value, error) {
+	return op.fn(ctx, inputs...)
+}
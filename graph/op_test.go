@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+func TestFuncOp(t *testing.T) {
+	op := FuncOp("Count", "Counts inputs", func(_ context.Context, inputs ...hypher.Value) ([]hypher.Value, error) {
+		return []hypher.Value{{"count": len(inputs)}}, nil
+	})
+
+	if op.Type() != "Count" {
+		t.Errorf("expected type: Count, got: %s", op.Type())
+	}
+	if op.Desc() != "Counts inputs" {
+		t.Errorf("expected desc: Counts inputs, got: %s", op.Desc())
+	}
+
+	outputs, err := op.Do(context.Background(), hypher.Value{}, hypher.Value{})
+	if err != nil {
+		t.Fatalf("failed to run op: %v", err)
+	}
+	if len(outputs) != 1 || outputs[0]["count"] != 2 {
+		t.Errorf("unexpected outputs: %v", outputs)
+	}
+}
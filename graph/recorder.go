@@ -0,0 +1,244 @@
+package graph
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	gonum "gonum.org/v1/gonum/graph"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+// NodeResult is the result of a node execution.
+type NodeResult struct {
+	// UID is node UID.
+	UID string
+	// Label is node label.
+	Label string
+	// OpType is node Op type.
+	OpType string
+	// Lane is the node execution lane.
+	Lane int
+	// Deps are the UIDs of the executed node predecessors.
+	Deps []string
+	// Scheduled is the time the node was scheduled.
+	Scheduled time.Time
+	// Start is the time the node execution started.
+	Start time.Time
+	// End is the time the node execution finished.
+	End time.Time
+	// Inputs are node execution inputs.
+	Inputs []hypher.Value
+	// Outputs are node execution outputs.
+	Outputs []hypher.Value
+	// Err is node execution error.
+	Err error
+	// Usage is node execution usage.
+	Usage hypher.Usage
+}
+
+// Duration returns node execution duration.
+func (r *NodeResult) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// RunResult is the result of a graph run.
+type RunResult struct {
+	// ID is the run ID.
+	ID string
+	// GraphUID is the UID of the graph.
+	GraphUID string
+	// ConcMode is the run concurrency mode.
+	ConcMode hypher.ConcMode
+	// Start is the time the run started.
+	Start time.Time
+	// End is the time the run finished.
+	End time.Time
+	// Err is the run error.
+	Err error
+	// Usage is the total run usage.
+	Usage hypher.Usage
+	// Nodes are the node results indexed by node UID.
+	Nodes map[string]*NodeResult
+	// Skipped are the UIDs of skipped nodes.
+	Skipped []string
+}
+
+// Duration returns the run duration.
+func (r *RunResult) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// CriticalPath returns the UIDs of the nodes on the run critical path
+// i.e. the chain of dependent nodes which determined the run duration.
+// The path starts with the first executed node and ends with the node
+// whose execution finished last. Nodes which never started are ignored.
+func (r *RunResult) CriticalPath() []string {
+	var last *NodeResult
+	for _, n := range r.Nodes {
+		if n.End.IsZero() {
+			continue
+		}
+		if last == nil || n.End.After(last.End) {
+			last = n
+		}
+	}
+
+	var path []string
+	for n := last; n != nil; {
+		path = append([]string{n.UID}, path...)
+
+		var next *NodeResult
+		for _, uid := range n.Deps {
+			dep, ok := r.Nodes[uid]
+			if !ok || dep.End.IsZero() {
+				continue
+			}
+			if next == nil || dep.End.After(next.End) {
+				next = dep
+			}
+		}
+		n = next
+	}
+
+	return path
+}
+
+// Recorder is a hypher.Observer which records run results.
+type Recorder struct {
+	result *RunResult
+	mu     sync.RWMutex
+}
+
+// NewRecorder creates a new run Recorder and returns it.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Observe records run event e.
+func (r *Recorder) Observe(_ context.Context, e hypher.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e.Type == hypher.RunStarted {
+		r.result = &RunResult{
+			ID:       e.RunID,
+			GraphUID: e.Graph.UID(),
+			ConcMode: e.ConcMode,
+			Start:    e.Time,
+			Nodes:    make(map[string]*NodeResult),
+			Skipped:  []string{},
+		}
+		return
+	}
+
+	if r.result == nil || r.result.ID != e.RunID {
+		return
+	}
+
+	switch e.Type {
+	case hypher.RunFinished:
+		r.result.End = e.Time
+		r.result.Err = e.Err
+		r.result.Usage = e.Usage
+	case hypher.NodeSkipped:
+		r.result.Skipped = append(r.result.Skipped, e.Node.UID())
+	case hypher.NodeScheduled:
+		r.result.Nodes[e.Node.UID()] = &NodeResult{
+			UID:       e.Node.UID(),
+			Label:     e.Node.Label(),
+			OpType:    opType(e.Node),
+			Lane:      e.Lane,
+			Deps:      deps(e.Graph, e.Node),
+			Scheduled: e.Time,
+		}
+	case hypher.NodeStarted:
+		if n, ok := r.result.Nodes[e.Node.UID()]; ok {
+			n.Start = e.Time
+			n.Inputs = e.Inputs
+		}
+	case hypher.NodeFinished:
+		if n, ok := r.result.Nodes[e.Node.UID()]; ok {
+			n.End = e.Time
+			n.Outputs = e.Outputs
+			n.Err = e.Err
+			n.Usage = e.Usage
+		}
+	}
+}
+
+// Result returns a copy of the result of the last recorded run.
+// The result of a run in progress is a snapshot of the run.
+// It returns nil if no run has been recorded.
+func (r *Recorder) Result() *RunResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.result == nil {
+		return nil
+	}
+	return r.result.deepCopy()
+}
+
+// deepCopy returns a deep copy of r.
+func (r *RunResult) deepCopy() *RunResult {
+	cr := *r
+	cr.Usage = copyUsage(r.Usage)
+	cr.Skipped = slices.Clone(r.Skipped)
+	cr.Nodes = make(map[string]*NodeResult, len(r.Nodes))
+	for uid, n := range r.Nodes {
+		cn := *n
+		cn.Deps = slices.Clone(n.Deps)
+		cn.Inputs = copyValues(n.Inputs)
+		cn.Outputs = copyValues(n.Outputs)
+		cn.Usage = copyUsage(n.Usage)
+		cr.Nodes[uid] = &cn
+	}
+	return &cr
+}
+
+// copyUsage returns a copy of u.
+func copyUsage(u hypher.Usage) hypher.Usage {
+	u.Counters = maps.Clone(u.Counters)
+	return u
+}
+
+// copyValues returns a copy of values.
+func copyValues(values []hypher.Value) []hypher.Value {
+	if values == nil {
+		return nil
+	}
+	cv := make([]hypher.Value, len(values))
+	for i, v := range values {
+		cv[i] = maps.Clone(v)
+	}
+	return cv
+}
+
+// opType returns the type of the node Op.
+func opType(n hypher.Node) string {
+	if node, ok := n.(*Node); ok && node.Op() != nil {
+		return node.Op().Type()
+	}
+	return ""
+}
+
+// deps returns the UIDs of the n predecessors in g.
+func deps(g hypher.Graph, n hypher.Node) []string {
+	dg, ok := g.(gonum.Directed)
+	if !ok {
+		return nil
+	}
+
+	var uids []string
+	to := dg.To(n.ID())
+	for to.Next() {
+		if pred, ok := to.Node().(hypher.Node); ok {
+			uids = append(uids, pred.UID())
+		}
+	}
+	return uids
+}
@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+func TestRecorder(t *testing.T) {
+	for _, mode := range []hypher.ConcMode{hypher.ConcLevelMode, hypher.ConcAllMode} {
+		t.Run(mode.String(), func(t *testing.T) {
+			g := MustGraph(t)
+			nodes := make([]*Node, 4)
+			for i := range nodes {
+				nodes[i] = MustNode(t, hypher.WithGraph(g), hypher.WithOp(testOp{}))
+			}
+			MustEdge(t, nodes[0], nodes[1], hypher.WithGraph(g))
+			MustEdge(t, nodes[1], nodes[2], hypher.WithGraph(g))
+			// nodes[3] is not on the path to output
+			MustEdge(t, nodes[0], nodes[3], hypher.WithGraph(g))
+			g.SetInputs([]*Node{nodes[0]})
+			g.SetOutputs([]*Node{nodes[2]})
+
+			rec := NewRecorder()
+			if err := g.Run(context.Background(), nil, hypher.WithConcMode(mode), hypher.WithObserver(rec)); err != nil {
+				t.Fatalf("failed to run graph: %v", err)
+			}
+
+			res := rec.Result()
+			if res == nil {
+				t.Fatal("expected run result")
+			}
+			if res.GraphUID != g.UID() || res.ConcMode != mode || res.Err != nil {
+				t.Errorf("unexpected run result: %#v", res)
+			}
+			if res.End.Before(res.Start) {
+				t.Errorf("run ended before it started")
+			}
+			if !reflect.DeepEqual(res.Skipped, []string{nodes[3].UID()}) {
+				t.Errorf("expected skipped: %v, got: %v", []string{nodes[3].UID()}, res.Skipped)
+			}
+			if len(res.Nodes) != 3 {
+				t.Fatalf("expected 3 node results, got: %d", len(res.Nodes))
+			}
+			for _, n := range nodes[:3] {
+				nr, ok := res.Nodes[n.UID()]
+				if !ok {
+					t.Fatalf("missing node %s result", n.UID())
+				}
+				if nr.Start.IsZero() || nr.End.Before(nr.Start) || nr.Start.Before(nr.Scheduled) {
+					t.Errorf("node %s: invalid timings: %#v", n.UID(), nr)
+				}
+				if len(nr.Outputs) != 1 {
+					t.Errorf("node %s: expected 1 output, got: %d", n.UID(), len(nr.Outputs))
+				}
+			}
+
+			expPath := []string{nodes[0].UID(), nodes[1].UID(), nodes[2].UID()}
+			if path := res.CriticalPath(); !reflect.DeepEqual(path, expPath) {
+				t.Errorf("expected critical path: %v, got: %v", expPath, path)
+			}
+		})
+	}
+}
+
+func TestRecorderResultCopy(t *testing.T) {
+	g := MustGraph(t)
+	n := MustNode(t, hypher.WithGraph(g), hypher.WithOp(testOp{}))
+
+	rec := NewRecorder()
+	rec.Observe(context.Background(), hypher.Event{Type: hypher.RunStarted, RunID: "r", Graph: g})
+
+	res := rec.Result()
+	rec.Observe(context.Background(), hypher.Event{Type: hypher.NodeScheduled, RunID: "r", Graph: g, Node: n})
+	if len(res.Nodes) != 0 {
+		t.Errorf("expected result snapshot without nodes, got: %d", len(res.Nodes))
+	}
+
+	res = rec.Result()
+	res.Nodes[n.UID()].Lane = 10
+	res.Skipped = append(res.Skipped, "x")
+	if res2 := rec.Result(); res2.Nodes[n.UID()].Lane != 0 || len(res2.Skipped) != 0 {
+		t.Errorf("expected recorded result to be unchanged, got: %#v", res2)
+	}
+}
+
+func TestRunResultCriticalPath(t *testing.T) {
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	res := &RunResult{
+		Start: start,
+		Nodes: map[string]*NodeResult{
+			"a": {UID: "a", Start: at(0), End: at(10)},
+			"b": {UID: "b", Deps: []string{"a"}, Start: at(10), End: at(50)},
+			"c": {UID: "c", Deps: []string{"a"}, Start: at(10), End: at(20)},
+			"d": {UID: "d", Deps: []string{"b", "c"}, Start: at(50), End: at(60)},
+			"e": {UID: "e", Deps: []string{"d"}},
+		},
+	}
+
+	exp := []string{"a", "b", "d"}
+	if path := res.CriticalPath(); !reflect.DeepEqual(path, exp) {
+		t.Errorf("expected critical path: %v, got: %v", exp, path)
+	}
+
+	if path := (&RunResult{}).CriticalPath(); len(path) != 0 {
+		t.Errorf("expected empty critical path, got: %v", path)
+	}
+}
@@ -0,0 +1,101 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+// OpRegistry maps Op types to factories which create them.
+// It is safe for concurrent use.
+type OpRegistry struct {
+	factories map[string]hypher.OpFactory
+	mu        sync.RWMutex
+}
+
+// NewOpRegistry creates a new Op registry and returns it.
+// NoOp is always registered.
+func NewOpRegistry() *OpRegistry {
+	return &OpRegistry{
+		factories: map[string]hypher.OpFactory{
+			NoOp{}.Type(): func([]byte) (hypher.Op, error) { return NoOp{}, nil },
+		},
+	}
+}
+
+// Register registers factory f for Ops of type opType.
+// It replaces the factory if opType has already been registered.
+func (r *OpRegistry) Register(opType string, f hypher.OpFactory) error {
+	if opType == "" {
+		return fmt.Errorf("missing op type")
+	}
+	if f == nil {
+		return fmt.Errorf("invalid factory for op type %s", opType)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.factories[opType] = f
+
+	return nil
+}
+
+// New creates a new Op of type opType from spec and returns it.
+// If opType is empty, NoOp is returned.
+func (r *OpRegistry) New(opType string, spec []byte) (hypher.Op, error) {
+	if opType == "" {
+		return NoOp{}, nil
+	}
+
+	r.mu.RLock()
+	f, ok := r.factories[opType]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown op type: %s", opType)
+	}
+
+	op, err := f(spec)
+	if err != nil {
+		return nil, fmt.Errorf("create op %s: %w", opType, err)
+	}
+
+	return op, nil
+}
+
+// Types returns sorted registered Op types.
+func (r *OpRegistry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+
+	return types
+}
+
+// MarshalOp returns the type of op and its spec.
+// The spec is nil if op does not implement hypher.OpSpec.
+func MarshalOp(op hypher.Op) (string, []byte, error) {
+	if op == nil {
+		return "", nil, nil
+	}
+
+	s, ok := op.(hypher.OpSpec)
+	if !ok {
+		return op.Type(), nil, nil
+	}
+
+	spec, err := s.Spec()
+	if err != nil {
+		return "", nil, fmt.Errorf("op %s spec: %w", op.Type(), err)
+	}
+
+	return op.Type(), spec, nil
+}
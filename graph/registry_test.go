@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+// specOp is a test Op with a spec.
+type specOp struct {
+	Greeting string `json:"greeting"`
+}
+
+func (op *specOp) Type() string   { return "SpecOp" }
+func (op *specOp) Desc() string   { return "SpecOp greets" }
+func (op *specOp) String() string { return op.Type() }
+func (op *specOp) Spec() ([]byte, error) {
+	return json.Marshal(op)
+}
+func (op *specOp) Do(_ context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	return []hypher.Value{{"greeting": op.Greeting}}, nil
+}
+
+func specOpFactory(spec []byte) (hypher.Op, error) {
+	op := &specOp{}
+	if err := json.Unmarshal(spec, op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func TestOpRegistry(t *testing.T) {
+	r := NewOpRegistry()
+
+	if err := r.Register("", specOpFactory); err == nil {
+		t.Error("expected missing type error")
+	}
+	if err := r.Register("SpecOp", nil); err == nil {
+		t.Error("expected invalid factory error")
+	}
+	if err := r.Register("SpecOp", specOpFactory); err != nil {
+		t.Fatalf("failed to register op: %v", err)
+	}
+
+	if types := r.Types(); !reflect.DeepEqual(types, []string{"NoOp", "SpecOp"}) {
+		t.Errorf("unexpected types: %v", types)
+	}
+
+	opType, spec, err := MarshalOp(&specOp{Greeting: "hi"})
+	if err != nil {
+		t.Fatalf("failed to marshal op: %v", err)
+	}
+	if opType != "SpecOp" || string(spec) != `{"greeting":"hi"}` {
+		t.Errorf("unexpected op type %s and spec %s", opType, spec)
+	}
+
+	op, err := r.New(opType, spec)
+	if err != nil {
+		t.Fatalf("failed to create op: %v", err)
+	}
+	if s, ok := op.(*specOp); !ok || s.Greeting != "hi" {
+		t.Errorf("unexpected op: %#v", op)
+	}
+
+	if op, err := r.New("", nil); err != nil || op.Type() != "NoOp" {
+		t.Errorf("expected NoOp, got: %v, err: %v", op, err)
+	}
+	if _, err := r.New("Unknown", nil); err == nil {
+		t.Error("expected unknown op type error")
+	}
+	if _, err := r.New("SpecOp", []byte("{")); err == nil {
+		t.Error("expected invalid spec error")
+	}
+
+	opType, spec, err = MarshalOp(NoOp{})
+	if err != nil || opType != "NoOp" || spec != nil {
+		t.Errorf("unexpected NoOp type %s spec %s err %v", opType, spec, err)
+	}
+}
@@ -0,0 +1,149 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+// run holds the state of a single graph run.
+type run struct {
+	id        string
+	graph     *Graph
+	mode      hypher.ConcMode
+	start     time.Time
+	tracer    hypher.Tracer
+	observers []hypher.Observer
+	metrics   hypher.Metrics
+	meter     *hypher.Meter
+	// spans of the executed nodes
+	spans map[int64]hypher.Span
+	// scheduling times of the nodes
+	scheduled map[int64]time.Time
+	mu        sync.Mutex
+}
+
+// newRun creates a new run of g configured with opts.
+func newRun(g *Graph, opts hypher.Options) *run {
+	tracer := opts.Tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+
+	return &run{
+		id:        uuid.New().String(),
+		graph:     g,
+		mode:      opts.ConcMode,
+		start:     time.Now(),
+		tracer:    tracer,
+		observers: append([]hypher.Observer{newLogObserver(g.runLogger(opts))}, opts.Observers...),
+		metrics:   g.Metrics(),
+		meter:     hypher.NewMeter(opts.Budget),
+		spans:     make(map[int64]hypher.Span),
+		scheduled: make(map[int64]time.Time),
+	}
+}
+
+// emit sends e to all the run observers.
+func (r *run) emit(ctx context.Context, e hypher.Event) {
+	e.RunID = r.id
+	e.Graph = r.graph
+	e.ConcMode = r.mode
+	e.Time = time.Now()
+
+	for _, o := range r.observers {
+		o.Observe(ctx, e)
+	}
+}
+
+// schedule marks node as scheduled for execution in lane.
+func (r *run) schedule(ctx context.Context, node *Node, lane int) {
+	r.mu.Lock()
+	r.scheduled[node.ID()] = time.Now()
+	r.mu.Unlock()
+
+	r.emit(ctx, hypher.Event{
+		Type: hypher.NodeScheduled,
+		Node: node,
+		Lane: lane,
+	})
+}
+
+// skip marks node as skipped.
+func (r *run) skip(ctx context.Context, node *Node) {
+	r.emit(ctx, hypher.Event{
+		Type: hypher.NodeSkipped,
+		Node: node,
+	})
+}
+
+// exec executes node in lane with the given inputs in a new span
+// which is linked to the spans of the node predecessors.
+func (r *run) exec(ctx context.Context, node *Node, lane int, preds []*Node, inputs []hypher.Value) error {
+	r.mu.Lock()
+	links := make([]hypher.Span, 0, len(preds))
+	for _, pred := range preds {
+		if span, ok := r.spans[pred.ID()]; ok {
+			links = append(links, span)
+		}
+	}
+	r.mu.Unlock()
+
+	ctx, span := r.tracer.Start(ctx, ExecSpanName, links...)
+	defer span.End()
+
+	r.mu.Lock()
+	r.spans[node.ID()] = span
+	scheduled, ok := r.scheduled[node.ID()]
+	r.mu.Unlock()
+
+	if ok {
+		r.metrics.NodeQueueWait(node.Op().Type(), time.Since(scheduled))
+	}
+
+	nodeInputs := node.Inputs()
+	execInputs := make([]hypher.Value, 0, len(nodeInputs)+len(inputs))
+	execInputs = append(execInputs, nodeInputs...)
+	execInputs = append(execInputs, inputs...)
+
+	span.SetAttrs(map[string]any{
+		AttrNodeUID:   node.UID(),
+		AttrNodeLabel: node.Label(),
+		AttrOpType:    node.Op().Type(),
+		AttrInputs:    len(execInputs),
+	})
+
+	r.emit(ctx, hypher.Event{
+		Type:   hypher.NodeStarted,
+		Node:   node,
+		Lane:   lane,
+		Inputs: execInputs,
+	})
+
+	meter := r.meter.Sub()
+	outputs, err := node.Exec(hypher.ContextWithMeter(ctx, meter), inputs...)
+
+	r.emit(ctx, hypher.Event{
+		Type:    hypher.NodeFinished,
+		Node:    node,
+		Lane:    lane,
+		Outputs: outputs,
+		Err:     err,
+		Usage:   meter.Usage(),
+	})
+
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	span.SetAttrs(map[string]any{
+		AttrOutputs: len(outputs),
+	})
+
+	return nil
+}
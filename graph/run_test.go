@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+type testSpan struct {
+	name  string
+	attrs map[string]any
+	links []hypher.Span
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttrs(attrs map[string]any) {
+	for k, v := range attrs {
+		s.attrs[k] = v
+	}
+}
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) End()                  { s.ended = true }
+
+type testSpanKey struct{}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, links ...hypher.Span) (context.Context, hypher.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := &testSpan{name: name, attrs: map[string]any{}, links: links}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, testSpanKey{}, s), s
+}
+
+var errTestOp = errors.New("testOp error")
+
+type failOp struct{}
+
+func (failOp) Type() string   { return "failOp" }
+func (failOp) Desc() string   { return "failOp always fails" }
+func (failOp) String() string { return "failOp" }
+
+func (failOp) Do(ctx context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	if _, ok := ctx.Value(testSpanKey{}).(*testSpan); !ok {
+		return nil, errors.New("missing span")
+	}
+	return nil, errTestOp
+}
+
+func TestRunTraceError(t *testing.T) {
+	for _, mode := range []hypher.ConcMode{hypher.ConcLevelMode, hypher.ConcAllMode} {
+		t.Run(mode.String(), func(t *testing.T) {
+			g := MustGraph(t)
+			n1 := MustNode(t, hypher.WithGraph(g), hypher.WithOp(testOp{}))
+			n2 := MustNode(t, hypher.WithGraph(g), hypher.WithOp(failOp{}))
+			MustEdge(t, n1, n2, hypher.WithGraph(g))
+			g.SetInputs([]*Node{n1})
+			g.SetOutputs([]*Node{n2})
+
+			tracer := &testTracer{}
+			err := g.Run(context.Background(), nil, hypher.WithConcMode(mode), hypher.WithTracer(tracer))
+			if err == nil {
+				t.Fatal("expected run error")
+			}
+
+			if len(tracer.spans) != 3 {
+				t.Fatalf("expected 3 spans, got: %d", len(tracer.spans))
+			}
+
+			run, exec1, exec2 := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+			if run.name != RunSpanName || run.err == nil || !run.ended {
+				t.Errorf("unexpected run span: %#v", run)
+			}
+			if exec1.name != ExecSpanName || exec1.err != nil || !exec1.ended {
+				t.Errorf("unexpected exec span: %#v", exec1)
+			}
+			if exec2.attrs[AttrNodeUID] != n2.UID() || exec2.err == nil || !exec2.ended {
+				t.Errorf("unexpected exec span: %#v", exec2)
+			}
+			if len(exec2.links) != 1 || exec2.links[0] != exec1 {
+				t.Errorf("expected exec span to be linked to its predecessor")
+			}
+		})
+	}
+}
+
+var testUsage = hypher.Usage{TokensIn: 10, TokensOut: 5, Cost: 0.5}
+
+type usageOp struct{}
+
+func (usageOp) Type() string   { return "usageOp" }
+func (usageOp) Desc() string   { return "usageOp records usage" }
+func (usageOp) String() string { return "usageOp" }
+
+func (usageOp) Do(ctx context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	if err := hypher.RecordUsage(ctx, testUsage); err != nil {
+		return nil, err
+	}
+	return []hypher.Value{{}}, nil
+}
+
+type blockOp struct{}
+
+func (blockOp) Type() string   { return "blockOp" }
+func (blockOp) Desc() string   { return "blockOp blocks until canceled" }
+func (blockOp) String() string { return "blockOp" }
+
+func (blockOp) Do(ctx context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRunUsage(t *testing.T) {
+	g := MustGraph(t)
+	nodes := make([]*Node, 3)
+	for i := range nodes {
+		nodes[i] = MustNode(t, hypher.WithGraph(g), hypher.WithOp(usageOp{}))
+	}
+	MustEdge(t, nodes[0], nodes[1], hypher.WithGraph(g))
+	MustEdge(t, nodes[1], nodes[2], hypher.WithGraph(g))
+	g.SetInputs([]*Node{nodes[0]})
+	g.SetOutputs([]*Node{nodes[2]})
+
+	rec := NewRecorder()
+	if err := g.Run(context.Background(), nil, hypher.WithObserver(rec)); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+
+	res := rec.Result()
+	for _, n := range nodes {
+		if u := res.Nodes[n.UID()].Usage; u.Tokens() != testUsage.Tokens() || u.Cost != testUsage.Cost {
+			t.Errorf("node %s: unexpected usage: %#v", n.UID(), u)
+		}
+	}
+
+	if res.Usage.TokensIn != 30 || res.Usage.TokensOut != 15 || res.Usage.Cost != 1.5 {
+		t.Errorf("unexpected run usage: %#v", res.Usage)
+	}
+}
+
+func TestRunBudgetExceeded(t *testing.T) {
+	for _, mode := range []hypher.ConcMode{hypher.ConcLevelMode, hypher.ConcAllMode} {
+		t.Run(mode.String(), func(t *testing.T) {
+			g := MustGraph(t)
+			in := MustNode(t, hypher.WithGraph(g))
+			n1 := MustNode(t, hypher.WithGraph(g), hypher.WithOp(usageOp{}))
+			n2 := MustNode(t, hypher.WithGraph(g), hypher.WithOp(usageOp{}))
+			blocked := MustNode(t, hypher.WithGraph(g), hypher.WithOp(blockOp{}))
+			out := MustNode(t, hypher.WithGraph(g), hypher.WithOp(usageOp{}))
+			MustEdge(t, in, n1, hypher.WithGraph(g))
+			MustEdge(t, in, blocked, hypher.WithGraph(g))
+			MustEdge(t, n1, n2, hypher.WithGraph(g))
+			MustEdge(t, n2, out, hypher.WithGraph(g))
+			MustEdge(t, blocked, out, hypher.WithGraph(g))
+			g.SetInputs([]*Node{in})
+			g.SetOutputs([]*Node{out})
+
+			budget := hypher.Budget{MaxTokens: 10}
+			err := g.Run(context.Background(), nil, hypher.WithConcMode(mode), hypher.WithBudget(budget))
+
+			var berr *hypher.ErrBudgetExceeded
+			if !errors.As(err, &berr) {
+				t.Fatalf("expected budget exceeded error, got: %v", err)
+			}
+			if berr.Usage.Tokens() != 15 || berr.Budget.MaxTokens != budget.MaxTokens {
+				t.Errorf("unexpected budget error: %#v", berr)
+			}
+			if len(out.Outputs()) != 0 {
+				t.Error("output node must not be executed")
+			}
+		})
+	}
+}
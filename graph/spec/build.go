@@ -0,0 +1,229 @@
+package spec
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// Build validates the spec and builds a new graph from it.
+// Node Ops are created through the Op registry ops.
+// Nodes without an Op run NoOp.
+func (s *Spec) Build(ops *graph.OpRegistry, opts ...hypher.Option) (*graph.Graph, error) {
+	if ops == nil {
+		return nil, fmt.Errorf("invalid op registry: %v", ops)
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+
+	var gopts []hypher.Option
+	if s.UID != "" {
+		gopts = append(gopts, hypher.WithUID(s.UID), hypher.WithDotID(s.UID))
+	}
+	if s.Label != "" {
+		gopts = append(gopts, hypher.WithLabel(s.Label))
+	}
+	if s.Attrs != nil {
+		gopts = append(gopts, hypher.WithAttrs(s.Attrs))
+	}
+
+	g, err := graph.NewGraph(append(gopts, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*graph.Node, len(s.Nodes))
+	for _, n := range s.Nodes {
+		node, err := buildNode(n, ops)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.UID, err)
+		}
+		if err := g.AddNode(node); err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.UID, err)
+		}
+		nodes[n.UID] = node
+	}
+
+	for _, e := range s.Edges {
+		eopts := []hypher.Option{
+			hypher.WithLabel(e.Label),
+			hypher.WithAttrs(e.Attrs),
+		}
+		if e.UID != "" {
+			eopts = append(eopts, hypher.WithUID(e.UID))
+		}
+		if e.Weight != nil {
+			eopts = append(eopts, hypher.WithWeight(*e.Weight))
+		}
+
+		edge, err := graph.NewEdge(nodes[e.From], nodes[e.To], eopts...)
+		if err != nil {
+			return nil, fmt.Errorf("edge %s -> %s: %w", e.From, e.To, err)
+		}
+		if err := g.SetEdge(edge); err != nil {
+			return nil, fmt.Errorf("edge %s -> %s: %w", e.From, e.To, err)
+		}
+	}
+
+	inputs := make([]*graph.Node, 0, len(s.Inputs))
+	for _, uid := range s.Inputs {
+		inputs = append(inputs, nodes[uid])
+	}
+	g.SetInputs(inputs)
+
+	outputs := make([]*graph.Node, 0, len(s.Outputs))
+	for _, uid := range s.Outputs {
+		outputs = append(outputs, nodes[uid])
+	}
+	g.SetOutputs(outputs)
+
+	return g, nil
+}
+
+// buildNode builds a graph node from spec n.
+func buildNode(n Node, ops *graph.OpRegistry) (*graph.Node, error) {
+	nopts := []hypher.Option{
+		hypher.WithUID(n.UID),
+		hypher.WithDotID(n.UID),
+	}
+	if n.Label != "" {
+		nopts = append(nopts, hypher.WithLabel(n.Label))
+	}
+	if n.Attrs != nil {
+		nopts = append(nopts, hypher.WithAttrs(n.Attrs))
+	}
+	if n.Exec != nil {
+		nopts = append(nopts, hypher.WithTimeout(time.Duration(n.Exec.Timeout)))
+	}
+
+	if n.Op != nil {
+		var spec []byte
+		if n.Op.Config != nil {
+			var err error
+			if spec, err = json.Marshal(n.Op.Config); err != nil {
+				return nil, fmt.Errorf("invalid op config: %w", err)
+			}
+		}
+		op, err := ops.New(n.Op.Type, spec)
+		if err != nil {
+			return nil, err
+		}
+		nopts = append(nopts, hypher.WithOp(op))
+	}
+
+	return graph.NewNode(nopts...)
+}
+
+// FromGraph creates a new spec from graph g and returns it.
+// Node Ops are stored with their type and, if they
+// implement hypher.OpSpec, with their config.
+func FromGraph(g *graph.Graph) (*Spec, error) {
+	s := &Spec{
+		Version: Version,
+		UID:     g.UID(),
+		Label:   g.Label(),
+		Attrs:   nilIfEmpty(maps.Clone(g.Attrs())),
+	}
+
+	// NOTE: nodes and edges are sorted by node IDs
+	// to make the spec output deterministic.
+	var nodes []*graph.Node
+	gnodes := g.Nodes()
+	for gnodes.Next() {
+		nodes = append(nodes, gnodes.Node().(*graph.Node))
+	}
+	slices.SortFunc(nodes, func(a, b *graph.Node) int {
+		return cmp.Compare(a.ID(), b.ID())
+	})
+
+	for _, n := range nodes {
+		node, err := fromNode(n)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.UID(), err)
+		}
+		s.Nodes = append(s.Nodes, node)
+	}
+
+	for _, n := range nodes {
+		var edges []*graph.Edge
+		to := g.From(n.ID())
+		for to.Next() {
+			we := g.WeightedEdge(n.ID(), to.Node().ID())
+			e, ok := we.(*graph.Edge)
+			if !ok {
+				return nil, fmt.Errorf("node %s: unsupported edge: %T", n.UID(), we)
+			}
+			edges = append(edges, e)
+		}
+		slices.SortFunc(edges, func(a, b *graph.Edge) int {
+			return cmp.Compare(a.To().ID(), b.To().ID())
+		})
+
+		for _, e := range edges {
+			w := e.Weight()
+			s.Edges = append(s.Edges, Edge{
+				UID:    e.UID(),
+				From:   n.UID(),
+				To:     e.To().(*graph.Node).UID(),
+				Weight: &w,
+				Label:  e.Label(),
+				Attrs:  nilIfEmpty(maps.Clone(e.Attrs())),
+			})
+		}
+	}
+
+	for _, n := range g.Inputs() {
+		s.Inputs = append(s.Inputs, n.UID())
+	}
+	for _, n := range g.Outputs() {
+		s.Outputs = append(s.Outputs, n.UID())
+	}
+
+	return s, nil
+}
+
+// fromNode creates a node spec from graph node n.
+func fromNode(n *graph.Node) (Node, error) {
+	node := Node{
+		UID:   n.UID(),
+		Label: n.Label(),
+		Attrs: nilIfEmpty(maps.Clone(n.Attrs())),
+	}
+
+	if t := n.Timeout(); t > 0 {
+		node.Exec = &Exec{Timeout: Duration(t)}
+	}
+
+	opType, spec, err := graph.MarshalOp(n.Op())
+	if err != nil {
+		return Node{}, err
+	}
+
+	if opType != "" && opType != (graph.NoOp{}).Type() {
+		node.Op = &Op{Type: opType}
+		if spec != nil {
+			if err := json.Unmarshal(spec, &node.Op.Config); err != nil {
+				return Node{}, fmt.Errorf("invalid op spec: %w", err)
+			}
+		}
+	}
+
+	return node, nil
+}
+
+// nilIfEmpty returns nil if attrs are empty
+// so they are omitted from the encoded spec.
+func nilIfEmpty(attrs map[string]any) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
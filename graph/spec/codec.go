@@ -0,0 +1,112 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is spec encoding format.
+type Format string
+
+const (
+	// YAML is YAML format.
+	YAML Format = "yaml"
+	// JSON is JSON format.
+	JSON Format = "json"
+)
+
+// FormatFromPath returns spec format based on the path file extension.
+func FormatFromPath(path string) (Format, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return YAML, nil
+	case ".json":
+		return JSON, nil
+	default:
+		return "", fmt.Errorf("unsupported file extension: %q", ext)
+	}
+}
+
+// Unmarshal decodes data encoded in format f into a new spec and returns it.
+// Unknown fields are rejected.
+func Unmarshal(data []byte, f Format) (*Spec, error) {
+	s := &Spec{}
+
+	switch f {
+	case YAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(s); err != nil {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+	case JSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(s); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", f)
+	}
+
+	return s, nil
+}
+
+// Marshal encodes spec s in format f.
+func Marshal(s *Spec, f Format) ([]byte, error) {
+	switch f {
+	case YAML:
+		var b bytes.Buffer
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		if err := enc.Encode(s); err != nil {
+			return nil, fmt.Errorf("encode yaml: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	case JSON:
+		return json.MarshalIndent(s, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", f)
+	}
+}
+
+// ReadFile reads the spec from the file at path and returns it.
+// The spec format is determined by the file extension.
+func ReadFile(path string) (*Spec, error) {
+	f, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Unmarshal(data, f)
+}
+
+// WriteFile writes the spec to the file at path.
+// The spec format is determined by the file extension.
+func WriteFile(path string, s *Spec) error {
+	f, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := Marshal(s, f)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
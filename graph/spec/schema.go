@@ -0,0 +1,9 @@
+package spec
+
+import _ "embed"
+
+// JSONSchema is the JSON Schema of the spec.
+// It can be used for spec validation in editors.
+//
+//go:embed schema.json
+var JSONSchema []byte
@@ -0,0 +1,141 @@
+// Package spec provides a declarative YAML and JSON format for hypher graphs.
+package spec
+
+import (
+	"fmt"
+	"time"
+)
+
+// Version is the current spec version.
+const Version = "v1"
+
+// Spec is a declarative graph spec.
+type Spec struct {
+	// Version is the spec version.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// UID is the graph UID.
+	UID string `json:"uid,omitempty" yaml:"uid,omitempty"`
+	// Label is the graph label.
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	// Attrs are the graph attributes.
+	Attrs map[string]any `json:"attrs,omitempty" yaml:"attrs,omitempty"`
+	// Nodes are the graph nodes.
+	Nodes []Node `json:"nodes" yaml:"nodes"`
+	// Edges are the graph edges.
+	Edges []Edge `json:"edges,omitempty" yaml:"edges,omitempty"`
+	// Inputs are the UIDs of the graph input nodes.
+	Inputs []string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	// Outputs are the UIDs of the graph output nodes.
+	Outputs []string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+}
+
+// Node is a graph node spec.
+type Node struct {
+	// UID is the node UID.
+	UID string `json:"uid" yaml:"uid"`
+	// Label is the node label.
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	// Attrs are the node attributes.
+	Attrs map[string]any `json:"attrs,omitempty" yaml:"attrs,omitempty"`
+	// Op is the node Op.
+	Op *Op `json:"op,omitempty" yaml:"op,omitempty"`
+	// Exec configures the node execution.
+	Exec *Exec `json:"exec,omitempty" yaml:"exec,omitempty"`
+}
+
+// Op is a node Op spec.
+type Op struct {
+	// Type is the Op type.
+	Type string `json:"type" yaml:"type"`
+	// Config is the Op config.
+	// It is passed JSON encoded to the Op factory.
+	Config any `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// Exec configures node execution.
+type Exec struct {
+	// Timeout is the node Op execution timeout.
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Edge is a graph edge spec.
+type Edge struct {
+	// UID is the edge UID.
+	UID string `json:"uid,omitempty" yaml:"uid,omitempty"`
+	// From is the UID of the edge source node.
+	From string `json:"from" yaml:"from"`
+	// To is the UID of the edge target node.
+	To string `json:"to" yaml:"to"`
+	// Weight is the edge weight.
+	Weight *float64 `json:"weight,omitempty" yaml:"weight,omitempty"`
+	// Label is the edge label.
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	// Attrs are the edge attributes.
+	Attrs map[string]any `json:"attrs,omitempty" yaml:"attrs,omitempty"`
+}
+
+// Duration is time.Duration encoded as a string e.g. "1m30s".
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Validate validates the spec.
+// It checks the spec version, that the node UIDs are unique
+// and that edges, inputs and outputs refer to existing nodes.
+func (s *Spec) Validate() error {
+	if s.Version != "" && s.Version != Version {
+		return fmt.Errorf("unsupported version: %s", s.Version)
+	}
+
+	nodes := make(map[string]struct{}, len(s.Nodes))
+	for i, n := range s.Nodes {
+		if n.UID == "" {
+			return fmt.Errorf("node %d: missing uid", i)
+		}
+		if _, ok := nodes[n.UID]; ok {
+			return fmt.Errorf("node %s: duplicate uid", n.UID)
+		}
+		if n.Op != nil && n.Op.Type == "" {
+			return fmt.Errorf("node %s: missing op type", n.UID)
+		}
+		if n.Exec != nil && n.Exec.Timeout < 0 {
+			return fmt.Errorf("node %s: negative timeout", n.UID)
+		}
+		nodes[n.UID] = struct{}{}
+	}
+
+	for i, e := range s.Edges {
+		for _, uid := range []string{e.From, e.To} {
+			if _, ok := nodes[uid]; !ok {
+				return fmt.Errorf("edge %d: unknown node: %q", i, uid)
+			}
+		}
+	}
+
+	for _, uid := range s.Inputs {
+		if _, ok := nodes[uid]; !ok {
+			return fmt.Errorf("unknown input node: %q", uid)
+		}
+	}
+
+	for _, uid := range s.Outputs {
+		if _, ok := nodes[uid]; !ok {
+			return fmt.Errorf("unknown output node: %q", uid)
+		}
+	}
+
+	return nil
+}
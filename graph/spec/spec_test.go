@@ -0,0 +1,282 @@
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// greetOp is a test Op with a spec.
+type greetOp struct {
+	Greeting string `json:"greeting"`
+}
+
+func (op *greetOp) Type() string   { return "GreetOp" }
+func (op *greetOp) Desc() string   { return "GreetOp greets" }
+func (op *greetOp) String() string { return op.Type() }
+func (op *greetOp) Spec() ([]byte, error) {
+	return json.Marshal(op)
+}
+func (op *greetOp) Do(_ context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	return []hypher.Value{{"greeting": op.Greeting}}, nil
+}
+
+func MustOpRegistry(t *testing.T) *graph.OpRegistry {
+	r := graph.NewOpRegistry()
+	if err := r.Register("GreetOp", func(spec []byte) (hypher.Op, error) {
+		op := &greetOp{}
+		if err := json.Unmarshal(spec, op); err != nil {
+			return nil, err
+		}
+		return op, nil
+	}); err != nil {
+		t.Fatalf("failed to register op: %v", err)
+	}
+	return r
+}
+
+func MustReadFile(t *testing.T, path string) *Spec {
+	s, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read spec: %v", err)
+	}
+	return s
+}
+
+func TestBuild(t *testing.T) {
+	s := MustReadFile(t, filepath.Join("testdata", "agent.yaml"))
+
+	if _, err := s.Build(nil); err == nil {
+		t.Fatal("expected invalid registry error")
+	}
+	if _, err := s.Build(graph.NewOpRegistry()); err == nil {
+		t.Fatal("expected unknown op type error")
+	}
+
+	g, err := s.Build(MustOpRegistry(t))
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	if g.UID() != "agent" || g.Label() != "Agent" || g.Attrs()["owner"] != "team" {
+		t.Errorf("unexpected graph: %s %s %v", g.UID(), g.Label(), g.Attrs())
+	}
+
+	if n := g.Nodes().Len(); n != 2 {
+		t.Fatalf("expected 2 nodes, got: %d", n)
+	}
+
+	inputs, outputs := g.Inputs(), g.Outputs()
+	if len(inputs) != 1 || len(outputs) != 1 {
+		t.Fatalf("expected 1 input and 1 output, got: %d, %d", len(inputs), len(outputs))
+	}
+
+	prompt, answer := inputs[0], outputs[0]
+	if prompt.UID() != "prompt" || prompt.Label() != "Prompt" {
+		t.Errorf("unexpected input node: %s %s", prompt.UID(), prompt.Label())
+	}
+	if op, ok := prompt.Op().(*greetOp); !ok || op.Greeting != "hello" {
+		t.Errorf("unexpected op: %#v", prompt.Op())
+	}
+
+	if answer.UID() != "answer" || answer.Timeout() != 30*time.Second || answer.Attrs()["retries"] != 2 {
+		t.Errorf("unexpected output node: %s %v %v", answer.UID(), answer.Timeout(), answer.Attrs())
+	}
+	if answer.Op().Type() != "NoOp" {
+		t.Errorf("expected NoOp, got: %s", answer.Op().Type())
+	}
+
+	e, ok := g.WeightedEdge(prompt.ID(), answer.ID()).(*graph.Edge)
+	if !ok {
+		t.Fatal("edge not found")
+	}
+	if e.Weight() != 2 || e.Label() != "next" {
+		t.Errorf("unexpected edge: %v %s", e.Weight(), e.Label())
+	}
+
+	if err := g.Run(context.Background(), map[string]hypher.Value{}); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+	if out := prompt.Outputs(); len(out) != 1 || out[0]["greeting"] != "hello" {
+		t.Errorf("unexpected outputs: %v", out)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	s := MustReadFile(t, filepath.Join("testdata", "agent.yaml"))
+	ops := MustOpRegistry(t)
+
+	g, err := s.Build(ops)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	exp, err := FromGraph(g)
+	if err != nil {
+		t.Fatalf("failed to create spec: %v", err)
+	}
+
+	for _, f := range []Format{YAML, JSON} {
+		t.Run(string(f), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "agent."+string(f))
+			if err := WriteFile(path, exp); err != nil {
+				t.Fatalf("failed to write spec: %v", err)
+			}
+
+			s2 := MustReadFile(t, path)
+			g2, err := s2.Build(ops)
+			if err != nil {
+				t.Fatalf("failed to build graph: %v", err)
+			}
+
+			got, err := FromGraph(g2)
+			if err != nil {
+				t.Fatalf("failed to create spec: %v", err)
+			}
+
+			// NOTE: JSON and YAML decode numbers differently so compare encoded specs.
+			expData, err := Marshal(exp, JSON)
+			if err != nil {
+				t.Fatalf("failed to marshal spec: %v", err)
+			}
+			gotData, err := Marshal(got, JSON)
+			if err != nil {
+				t.Fatalf("failed to marshal spec: %v", err)
+			}
+			if string(expData) != string(gotData) {
+				t.Errorf("expected spec:\n%s\ngot:\n%s", expData, gotData)
+			}
+		})
+	}
+}
+
+func TestFromGraphUnsupportedEdge(t *testing.T) {
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	from, err := g.NewNode()
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	to, err := g.NewNode()
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	g.SetWeightedEdge(simple.WeightedEdge{F: from, T: to, W: 1})
+
+	if _, err := FromGraph(g); err == nil {
+		t.Fatal("expected unsupported edge error")
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		f    Format
+	}{
+		{"UnknownYAMLField", "nodes: []\nfoo: bar\n", YAML},
+		{"UnknownJSONField", `{"nodes": [], "foo": "bar"}`, JSON},
+		{"InvalidTimeout", `{"nodes": [{"uid": "a", "exec": {"timeout": "soon"}}]}`, JSON},
+		{"Format", `{}`, "toml"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Unmarshal([]byte(tc.data), tc.f); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	if _, err := FormatFromPath("agent.toml"); err == nil {
+		t.Error("expected unsupported extension error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec Spec
+		err  string
+	}{
+		{"Version", Spec{Version: "v0"}, "version"},
+		{"MissingUID", Spec{Nodes: []Node{{}}}, "missing uid"},
+		{"DuplicateUID", Spec{Nodes: []Node{{UID: "a"}, {UID: "a"}}}, "duplicate"},
+		{"MissingOpType", Spec{Nodes: []Node{{UID: "a", Op: &Op{}}}}, "op type"},
+		{"NegativeTimeout", Spec{Nodes: []Node{{UID: "a", Exec: &Exec{Timeout: -1}}}}, "timeout"},
+		{"Edge", Spec{Nodes: []Node{{UID: "a"}}, Edges: []Edge{{From: "a", To: "b"}}}, "unknown node"},
+		{"Input", Spec{Nodes: []Node{{UID: "a"}}, Inputs: []string{"b"}}, "input"},
+		{"Output", Spec{Nodes: []Node{{UID: "a"}}, Outputs: []string{"b"}}, "output"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.spec.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error containing %q, got: %v", tc.err, err)
+			}
+		})
+	}
+
+	s := Spec{
+		Nodes: []Node{{UID: "a"}, {UID: "b"}},
+		Edges: []Edge{{From: "a", To: "b"}, {From: "b", To: "a"}},
+	}
+	if _, err := s.Build(graph.NewOpRegistry()); err == nil {
+		t.Error("expected cycle error")
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	var schema struct {
+		Properties map[string]any `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(JSONSchema, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+
+	testCases := []struct {
+		name  string
+		typ   reflect.Type
+		props map[string]any
+	}{
+		{"spec", reflect.TypeFor[Spec](), schema.Properties},
+		{"node", reflect.TypeFor[Node](), schema.Defs["node"].Properties},
+		{"op", reflect.TypeFor[Op](), schema.Defs["op"].Properties},
+		{"exec", reflect.TypeFor[Exec](), schema.Defs["exec"].Properties},
+		{"edge", reflect.TypeFor[Edge](), schema.Defs["edge"].Properties},
+	}
+
+	for _, tc := range testCases {
+		var fields []string
+		for i := 0; i < tc.typ.NumField(); i++ {
+			name, _, _ := strings.Cut(tc.typ.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+
+		var props []string
+		for p := range tc.props {
+			props = append(props, p)
+		}
+
+		slices.Sort(fields)
+		slices.Sort(props)
+		if !slices.Equal(fields, props) {
+			t.Errorf("%s: schema properties %v do not match fields %v", tc.name, props, fields)
+		}
+	}
+}
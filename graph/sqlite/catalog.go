@@ -0,0 +1,216 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SortField is a field graphs are sorted by.
+type SortField string
+
+const (
+	// SortCreatedAt sorts graphs by their creation time.
+	SortCreatedAt SortField = "created_at"
+	// SortUpdatedAt sorts graphs by their update time.
+	SortUpdatedAt SortField = "updated_at"
+)
+
+// GraphInfo is a graph catalog entry.
+type GraphInfo struct {
+	// UID is graph UID.
+	UID string
+	// DOTID is graph DOT ID.
+	DOTID string
+	// Label is graph label.
+	Label string
+	// Attrs are graph attributes.
+	Attrs map[string]any
+	// CreatedAt is the time the graph was first synced.
+	CreatedAt time.Time
+	// UpdatedAt is the time the graph was last synced.
+	UpdatedAt time.Time
+}
+
+// GraphStats are graph statistics.
+type GraphStats struct {
+	// Nodes is the number of graph nodes.
+	Nodes int
+	// Edges is the number of graph edges.
+	Edges int
+}
+
+// ListOptions configure listed graphs.
+type ListOptions struct {
+	// SortBy is the field graphs are sorted by.
+	// Graphs are sorted by SortCreatedAt by default.
+	SortBy SortField
+	// Desc sorts graphs in descending order.
+	Desc bool
+	// Limit limits the number of listed graphs.
+	Limit int
+	// Offset skips the given number of graphs.
+	Offset int
+}
+
+// SearchFilter filters searched graphs.
+// Zero values of the fields do not filter.
+type SearchFilter struct {
+	// LabelPrefix matches graphs whose labels start with it.
+	// The match is case-sensitive.
+	LabelPrefix string
+	// Attrs matches graphs whose attributes have the given values.
+	Attrs map[string]any
+}
+
+// Catalog catalogs graphs stored in sqlite DB.
+type Catalog struct {
+	db *DB
+}
+
+// NewCatalog creates a new catalog and returns it.
+func NewCatalog(db *DB) (*Catalog, error) {
+	return &Catalog{
+		db: db,
+	}, nil
+}
+
+// List returns the stored graphs.
+func (c *Catalog) List(ctx context.Context, opts ListOptions) ([]*GraphInfo, error) {
+	return c.Search(ctx, SearchFilter{}, opts)
+}
+
+// Search returns the stored graphs matching filter f.
+// Attribute values are matched via sqlite JSON1 json_extract.
+func (c *Catalog) Search(ctx context.Context, f SearchFilter, opts ListOptions) ([]*GraphInfo, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if f.LabelPrefix != "" {
+		where = append(where, "instr(label, ?) = 1")
+		args = append(args, f.LabelPrefix)
+	}
+
+	// sort attribute keys so the queries are deterministic
+	keys := make([]string, 0, len(f.Attrs))
+	for k := range f.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		where = append(where, "json_extract(attrs, ?) = ?")
+		args = append(args, "$."+strconv.Quote(k), f.Attrs[k])
+	}
+
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = SortCreatedAt
+	}
+	if sortBy != SortCreatedAt && sortBy != SortUpdatedAt {
+		return nil, fmt.Errorf("invalid sort field: %s", sortBy)
+	}
+	order := "ASC"
+	if opts.Desc {
+		order = "DESC"
+	}
+
+	query := `SELECT uid, dotid, label, attrs, created_at, updated_at FROM graphs`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+
+	// negative limit means no limit in sqlite.
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, uid LIMIT ? OFFSET ?`, sortBy, order)
+	args = append(args, limit, max(opts.Offset, 0))
+
+	rows, err := c.db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list graphs: %w", err)
+	}
+	defer rows.Close()
+
+	var graphs []*GraphInfo
+	for rows.Next() {
+		var (
+			g            GraphInfo
+			dotid, label sql.NullString
+			attrs        sql.NullString
+		)
+		if err := rows.Scan(&g.UID, &dotid, &label, &attrs, (*NullTime)(&g.CreatedAt), (*NullTime)(&g.UpdatedAt)); err != nil {
+			return nil, fmt.Errorf("failed to scan graph: %w", err)
+		}
+		g.DOTID, g.Label = dotid.String, label.String
+		if g.Attrs, err = AttrsFromString(attrs.String); err != nil {
+			return nil, fmt.Errorf("graph %s attrs: %w", g.UID, err)
+		}
+		graphs = append(graphs, &g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return graphs, nil
+}
+
+// Delete deletes the graph with the given uid.
+// The graph nodes and edges are deleted, too.
+func (c *Catalog) Delete(ctx context.Context, uid string) error {
+	res, err := c.db.db.ExecContext(ctx, `DELETE FROM graphs WHERE uid = ?`, uid)
+	if err != nil {
+		return err
+	}
+	return checkFound(res, "delete", uid)
+}
+
+// Rename sets the label of the graph with the given uid.
+// The graph update time is bumped.
+func (c *Catalog) Rename(ctx context.Context, uid, label string) error {
+	updatedAt := time.Now()
+	res, err := c.db.db.ExecContext(ctx, `
+		UPDATE graphs
+		SET label = ?, updated_at = ?
+		WHERE uid = ?
+	`, label, (*NullTime)(&updatedAt), uid)
+	if err != nil {
+		return err
+	}
+	return checkFound(res, "rename", uid)
+}
+
+// Stats returns the statistics of the graph with the given uid.
+// The graph is not loaded.
+func (c *Catalog) Stats(ctx context.Context, uid string) (*GraphStats, error) {
+	var stats GraphStats
+	err := c.db.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM nodes WHERE graph = g.uid),
+			(SELECT COUNT(*) FROM edges WHERE graph = g.uid)
+		FROM graphs g
+		WHERE g.uid = ?
+	`, uid).Scan(&stats.Nodes, &stats.Edges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve graph stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// checkFound returns error wrapping sql.ErrNoRows if res affected no graph.
+func checkFound(res sql.Result, op, uid string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to %s graph %s: %w", op, uid, sql.ErrNoRows)
+	}
+	return nil
+}
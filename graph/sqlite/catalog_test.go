@@ -0,0 +1,230 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func MustCatalog(tb testing.TB, db *DB) *Catalog {
+	c, err := NewCatalog(db)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return c
+}
+
+// MustSyncCatalog syncs graphs g0, g1 and g2 created an hour apart
+// and updates g0 an hour after g2 was created.
+func MustSyncCatalog(t *testing.T, db *DB) {
+	ctx := context.Background()
+	s := MustSyncer(t, db)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	graphs := []struct {
+		uid   string
+		label string
+		attrs map[string]any
+	}{
+		{"g0", "agent-chat", map[string]any{"env": "prod", "version": 1}},
+		{"g1", "agent-search", map[string]any{"env": "dev", "version": 2}},
+		{"g2", "tool", map[string]any{"env": "prod", "version": 2}},
+	}
+
+	var g0 *graph.Graph
+	for i, gr := range graphs {
+		g, err := graph.NewGraph(hypher.WithUID(gr.uid), hypher.WithLabel(gr.label), hypher.WithAttrs(gr.attrs))
+		if err != nil {
+			t.Fatalf("failed to create graph: %v", err)
+		}
+		for j := 0; j <= i; j++ {
+			if _, err := g.NewNode(hypher.WithUID(gr.uid + "-" + string(rune('a'+j)))); err != nil {
+				t.Fatalf("failed to create node: %v", err)
+			}
+		}
+		at := start.Add(time.Duration(i) * time.Hour)
+		s.now = func() time.Time { return at }
+		if err := s.Sync(ctx, g); err != nil {
+			t.Fatalf("failed to sync graph: %v", err)
+		}
+		if i == 0 {
+			g0 = g
+		}
+	}
+
+	at := start.Add(3 * time.Hour)
+	s.now = func() time.Time { return at }
+	if err := s.Sync(ctx, g0); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+}
+
+func uids(graphs []*GraphInfo) []string {
+	res := make([]string, 0, len(graphs))
+	for _, g := range graphs {
+		res = append(res, g.UID)
+	}
+	return res
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCatalog_List(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	MustSyncCatalog(t, db)
+	c := MustCatalog(t, db)
+
+	testCases := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"default", ListOptions{}, []string{"g0", "g1", "g2"}},
+		{"created desc", ListOptions{Desc: true}, []string{"g2", "g1", "g0"}},
+		{"updated", ListOptions{SortBy: SortUpdatedAt}, []string{"g1", "g2", "g0"}},
+		{"page", ListOptions{Limit: 1, Offset: 1}, []string{"g1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			graphs, err := c.List(context.Background(), tc.opts)
+			if err != nil {
+				t.Fatalf("failed to list graphs: %v", err)
+			}
+			if got := uids(graphs); !equal(got, tc.want) {
+				t.Errorf("expected graphs: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := c.List(context.Background(), ListOptions{SortBy: "label; DROP TABLE graphs"}); err == nil {
+		t.Error("expected invalid sort field error")
+	}
+}
+
+func TestCatalog_Search(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	MustSyncCatalog(t, db)
+	c := MustCatalog(t, db)
+
+	testCases := []struct {
+		name   string
+		filter SearchFilter
+		want   []string
+	}{
+		{"prefix", SearchFilter{LabelPrefix: "agent-"}, []string{"g0", "g1"}},
+		{"prefix case", SearchFilter{LabelPrefix: "Agent"}, nil},
+		{"attr string", SearchFilter{Attrs: map[string]any{"env": "prod"}}, []string{"g0", "g2"}},
+		{"attr number", SearchFilter{Attrs: map[string]any{"version": 2}}, []string{"g1", "g2"}},
+		{"combined", SearchFilter{LabelPrefix: "agent", Attrs: map[string]any{"env": "prod", "version": 1}}, []string{"g0"}},
+		{"missing attr", SearchFilter{Attrs: map[string]any{"owner": "joe"}}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			graphs, err := c.Search(context.Background(), tc.filter, ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to search graphs: %v", err)
+			}
+			if got := uids(graphs); !equal(got, tc.want) {
+				t.Errorf("expected graphs: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCatalog_RenameDeleteStats(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	MustSyncCatalog(t, db)
+	c := MustCatalog(t, db)
+
+	ctx := context.Background()
+
+	stats, err := c.Stats(ctx, "g2")
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	if stats.Nodes != 3 || stats.Edges != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	if err := c.Rename(ctx, "g2", "renamed"); err != nil {
+		t.Fatalf("failed to rename graph: %v", err)
+	}
+	graphs, err := c.Search(ctx, SearchFilter{LabelPrefix: "renamed"}, ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to search graphs: %v", err)
+	}
+	if got := uids(graphs); !equal(got, []string{"g2"}) {
+		t.Errorf("expected renamed graph, got: %v", got)
+	}
+
+	if err := c.Delete(ctx, "g2"); err != nil {
+		t.Fatalf("failed to delete graph: %v", err)
+	}
+	if n := count(t, db, "nodes", "g2"); n != 0 {
+		t.Errorf("expected graph nodes to be deleted, got: %d", n)
+	}
+
+	for name, err := range map[string]error{
+		"delete": c.Delete(ctx, "g2"),
+		"rename": c.Rename(ctx, "g2", "x"),
+	} {
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("%s: expected %v, got: %v", name, sql.ErrNoRows, err)
+		}
+	}
+	if _, err := c.Stats(ctx, "g2"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("stats: expected %v, got: %v", sql.ErrNoRows, err)
+	}
+}
+
+func TestCatalog_DeleteConns(t *testing.T) {
+	db, err := NewDB(Scheme + "://" + filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatalf("failed to open DB: %v", err)
+	}
+	defer MustCloseDB(t, db)
+	MustSyncCatalog(t, db)
+	c := MustCatalog(t, db)
+
+	ctx := context.Background()
+
+	// hold the pooled connections so the delete runs on a new one
+	for i := 0; i < 3; i++ {
+		conn, err := db.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("failed to open connection: %v", err)
+		}
+		defer conn.Close()
+	}
+
+	if err := c.Delete(ctx, "g2"); err != nil {
+		t.Fatalf("failed to delete graph: %v", err)
+	}
+	if _, err := c.Stats(ctx, "g2"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("stats: expected %v, got: %v", sql.ErrNoRows, err)
+	}
+	if n := count(t, db, "nodes", "g2"); n != 0 {
+		t.Errorf("expected graph nodes to be deleted, got: %d", n)
+	}
+}
@@ -72,3 +72,31 @@ func AttrsFromString(attrString string) (map[string]any, error) {
 	}
 	return attrs, nil
 }
+
+// NanoTime is like NullTime, but it keeps the time nanoseconds.
+// Times are formatted with a fixed number of fractional digits
+// so their text representations sort chronologically.
+type NanoTime time.Time
+
+// nanoTimeFormat is RFC 3339 with fixed nanosecond precision.
+const nanoTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// Scan reads a time value from the database.
+func (n *NanoTime) Scan(value any) error {
+	if value == nil {
+		*(*time.Time)(n) = time.Time{}
+		return nil
+	} else if value, ok := value.(string); ok {
+		*(*time.Time)(n), _ = time.Parse(time.RFC3339Nano, value)
+		return nil
+	}
+	return fmt.Errorf("NanoTime: cannot scan to time.Time: %T", value)
+}
+
+// Value formats a time value for the database.
+func (n *NanoTime) Value() (driver.Value, error) {
+	if n == nil || (*time.Time)(n).IsZero() {
+		return nil, nil
+	}
+	return (*time.Time)(n).UTC().Format(nanoTimeFormat), nil
+}
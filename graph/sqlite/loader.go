@@ -2,8 +2,12 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/milosgajdos/go-hypher"
@@ -23,7 +27,166 @@ func NewLoader(db *DB) (*Loader, error) {
 }
 
 // Load loads the graph from sqlite DB and returns it.
+// The loaded graph keeps the synced node IDs, DOT IDs
+// and the graph input and output nodes.
+// If the DB was opened with an OpRegistry, node Ops are restored
+// through it, otherwise the loaded nodes run NoOp.
 func (l *Loader) Load(ctx context.Context, uid string) (*graph.Graph, error) {
+	return l.loadLogged(ctx, uid, reach{})
+}
+
+// Ancestors loads the node with the given nodeUID from the graph with
+// the given graphUID along with its ancestors up to the given depth.
+// If depth is not positive, all the node ancestors are loaded.
+// The ancestors are queried in sqlite and the returned graph only
+// contains the loaded nodes and the edges between them.
+func (l *Loader) Ancestors(ctx context.Context, graphUID, nodeUID string, depth int) (*graph.Graph, error) {
+	return l.loadLogged(ctx, graphUID, newReach(graphUID, []string{nodeUID}, depth, true))
+}
+
+// Descendants loads the node with the given nodeUID from the graph with
+// the given graphUID along with its descendants up to the given depth.
+// If depth is not positive, all the node descendants are loaded.
+// The descendants are queried in sqlite and the returned graph only
+// contains the loaded nodes and the edges between them.
+func (l *Loader) Descendants(ctx context.Context, graphUID, nodeUID string, depth int) (*graph.Graph, error) {
+	return l.loadLogged(ctx, graphUID, newReach(graphUID, []string{nodeUID}, depth, false))
+}
+
+// LoadSubGraph loads the sub-graph of the graph with the given graphUID
+// which contains the nodes with the given rootUIDs and all the nodes
+// reachable from them in at most depth edges.
+// If depth is not positive, all the reachable nodes are loaded.
+func (l *Loader) LoadSubGraph(ctx context.Context, graphUID string, rootUIDs []string, depth int) (*graph.Graph, error) {
+	if len(rootUIDs) == 0 {
+		return nil, fmt.Errorf("missing root nodes")
+	}
+	return l.loadLogged(ctx, graphUID, newReach(graphUID, rootUIDs, depth, false))
+}
+
+// Path loads the sub-graph of the graph with the given graphUID which
+// contains the nodes on all the paths from the node with the given fromUID
+// to the node with the given toUID and the edges between them.
+// The paths are queried in sqlite as the intersection of the from node
+// descendants and the to node ancestors. If there is no such path
+// the returned error wraps sql.ErrNoRows.
+func (l *Loader) Path(ctx context.Context, graphUID, fromUID, toUID string) (*graph.Graph, error) {
+	g, err := l.loadLogged(ctx, graphUID, newPath(graphUID, fromUID, toUID))
+	if err != nil {
+		return nil, err
+	}
+	if g.Nodes().Len() == 0 {
+		return nil, fmt.Errorf("failed to find path from %s to %s: %w", fromUID, toUID, sql.ErrNoRows)
+	}
+	return g, nil
+}
+
+// reach restricts the loaded nodes to the nodes reached by a recursive query.
+// Its zero value does not restrict the loaded nodes.
+type reach struct {
+	// with is the recursive CTE which defines the reached table of node UIDs.
+	with string
+	// args are the CTE arguments.
+	args []any
+	// roots are the UIDs of the nodes the query starts from.
+	roots []string
+}
+
+// newReach returns the query of the nodes reachable from roots in at most depth edges.
+// If up is true, the edges are followed from their targets to their sources.
+// Unlimited depth queries keep only the node UIDs so every node is visited once.
+func newReach(graphUID string, roots []string, depth int, up bool) reach {
+	from, to := "source", "target"
+	if up {
+		from, to = "target", "source"
+	}
+
+	args := []any{graphUID}
+	for _, uid := range roots {
+		args = append(args, uid)
+	}
+	args = append(args, graphUID)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(roots)), ", ")
+
+	if depth <= 0 {
+		return reach{
+			with: fmt.Sprintf(`
+				WITH RECURSIVE reached(uid) AS (
+					SELECT uid FROM nodes WHERE graph = ? AND uid IN (%s)
+					UNION
+					SELECT e.%s FROM edges e JOIN reached r ON e.%s = r.uid WHERE e.graph = ?
+				)`, placeholders, to, from),
+			args:  args,
+			roots: roots,
+		}
+	}
+
+	return reach{
+		with: fmt.Sprintf(`
+			WITH RECURSIVE walk(uid, depth) AS (
+				SELECT uid, 0 FROM nodes WHERE graph = ? AND uid IN (%s)
+				UNION
+				SELECT e.%s, w.depth + 1 FROM edges e JOIN walk w ON e.%s = w.uid WHERE e.graph = ? AND w.depth < ?
+			),
+			reached(uid) AS (
+				SELECT DISTINCT uid FROM walk
+			)`, placeholders, to, from),
+		args:  append(args, depth),
+		roots: roots,
+	}
+}
+
+// newPath returns the query of the nodes on the paths from node fromUID to node toUID.
+func newPath(graphUID, fromUID, toUID string) reach {
+	return reach{
+		with: `
+			WITH RECURSIVE descendants(uid) AS (
+				SELECT uid FROM nodes WHERE graph = ? AND uid = ?
+				UNION
+				SELECT e.target FROM edges e JOIN descendants d ON e.source = d.uid WHERE e.graph = ?
+			),
+			ancestors(uid) AS (
+				SELECT uid FROM nodes WHERE graph = ? AND uid = ?
+				UNION
+				SELECT e.source FROM edges e JOIN ancestors a ON e.target = a.uid WHERE e.graph = ?
+			),
+			reached(uid) AS (
+				SELECT uid FROM descendants
+				INTERSECT
+				SELECT uid FROM ancestors
+			)`,
+		args: []any{graphUID, fromUID, graphUID, graphUID, toUID, graphUID},
+	}
+}
+
+// loadLogged loads the graph with the given uid restricted
+// to the nodes reached by q and logs the result.
+func (l *Loader) loadLogged(ctx context.Context, uid string, q reach) (*graph.Graph, error) {
+	start := time.Now()
+
+	g, err := l.load(ctx, uid, q)
+	if err != nil {
+		l.db.logger.ErrorContext(ctx, "graph load failed",
+			slog.String("graph_uid", uid),
+			slog.Any("error", err),
+		)
+		return nil, err
+	}
+
+	l.db.logger.InfoContext(ctx, "graph loaded",
+		slog.String("graph_uid", uid),
+		slog.Int("nodes", g.Nodes().Len()),
+		slog.Int("edges", g.Edges().Len()),
+		slog.Duration("duration", time.Since(start)),
+	)
+
+	return g, nil
+}
+
+// load loads the graph with the given uid from sqlite DB.
+// The loaded nodes are restricted to the nodes reached by q.
+func (l *Loader) load(ctx context.Context, uid string, q reach) (*graph.Graph, error) {
 	tx, err := l.db.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -32,6 +195,7 @@ func (l *Loader) Load(ctx context.Context, uid string) (*graph.Graph, error) {
 	defer tx.Rollback()
 
 	var (
+		dotid     sql.NullString
 		label     string
 		attrsJSON string
 		createdAt time.Time
@@ -40,13 +204,14 @@ func (l *Loader) Load(ctx context.Context, uid string) (*graph.Graph, error) {
 
 	err = tx.QueryRowContext(ctx, `
 		SELECT
+			dotid,
 			label,
 			attrs,
 			created_at,
 			updated_at
 		FROM graphs
 		WHERE uid = ?
-	`, uid).Scan(&label, &attrsJSON, (*NullTime)(&createdAt), (*NullTime)(&updatedAt))
+	`, uid).Scan(&dotid, &label, &attrsJSON, (*NullTime)(&createdAt), (*NullTime)(&updatedAt))
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve graph: %w", err)
 	}
@@ -56,28 +221,46 @@ func (l *Loader) Load(ctx context.Context, uid string) (*graph.Graph, error) {
 		return nil, err
 	}
 
-	// Create the in-memory graph
-	g, err := graph.NewGraph(
+	graphOpts := []hypher.Option{
 		hypher.WithUID(uid),
 		hypher.WithLabel(label),
 		hypher.WithAttrs(attrs),
-	)
+	}
+	if dotid.Valid {
+		graphOpts = append(graphOpts, hypher.WithDotID(dotid.String))
+	}
+
+	// Create the in-memory graph
+	g, err := graph.NewGraph(graphOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create in-memory graph: %w", err)
 	}
 
+	args := append(slices.Clip(q.args), uid)
+	nodeFilter, edgeFilter := "", ""
+	if q.with != "" {
+		nodeFilter = `AND uid IN (SELECT uid FROM reached)`
+		edgeFilter = `AND source IN (SELECT uid FROM reached) AND target IN (SELECT uid FROM reached)`
+	}
+
 	// Retrieve nodes
-	rows, err := tx.QueryContext(ctx, `
+	rows, err := tx.QueryContext(ctx, q.with+`
 		SELECT
-			id,
+			local_id,
 			uid,
+			dotid,
 			label,
 			attrs,
+			op_type,
+			op_spec,
+			is_input,
+			is_output,
 			created_at,
 			updated_at
 		FROM nodes
-		WHERE graph = ?
-	`, uid)
+		WHERE graph = ? `+nodeFilter+`
+		ORDER BY local_id
+	`, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve nodes: %w", err)
 	}
@@ -85,17 +268,25 @@ func (l *Loader) Load(ctx context.Context, uid string) (*graph.Graph, error) {
 
 	nodeMap := make(map[string]*graph.Node) // Map to store nodes by their UID
 
+	var inputs, outputs []*graph.Node
+
 	for rows.Next() {
 		var (
 			id            int64
 			nodeUID       string
+			nodeDOTID     sql.NullString
 			nodeLabel     string
 			nodeAttrsJSON string
+			opType        sql.NullString
+			opSpec        sql.NullString
+			isInput       bool
+			isOutput      bool
 			createdAt     time.Time
 			updatedAt     time.Time
 		)
 
-		if err := rows.Scan(&id, &nodeUID, &nodeLabel, &nodeAttrsJSON, (*NullTime)(&createdAt), (*NullTime)(&updatedAt)); err != nil {
+		if err := rows.Scan(&id, &nodeUID, &nodeDOTID, &nodeLabel, &nodeAttrsJSON, &opType, &opSpec,
+			&isInput, &isOutput, (*NullTime)(&createdAt), (*NullTime)(&updatedAt)); err != nil {
 			return nil, fmt.Errorf("failed to scan node: %w", err)
 		}
 
@@ -104,25 +295,59 @@ func (l *Loader) Load(ctx context.Context, uid string) (*graph.Graph, error) {
 			return nil, err
 		}
 
-		// Create node and add it to the graph
-		node, err := graph.NewNode(
+		nodeOpts := []hypher.Option{
 			hypher.WithID(id),
 			hypher.WithUID(nodeUID),
 			hypher.WithLabel(nodeLabel),
 			hypher.WithAttrs(nodeAttrs),
-		)
+		}
+		if nodeDOTID.Valid {
+			nodeOpts = append(nodeOpts, hypher.WithDotID(nodeDOTID.String))
+		}
+
+		// Restore node Op if the registry is configured
+		if l.db.ops != nil {
+			var spec []byte
+			if opSpec.Valid {
+				spec = []byte(opSpec.String)
+			}
+			op, err := l.db.ops.New(opType.String, spec)
+			if err != nil {
+				return nil, fmt.Errorf("node %s: %w", nodeUID, err)
+			}
+			nodeOpts = append(nodeOpts, hypher.WithOp(op))
+		}
+
+		// Create node and add it to the graph
+		node, err := graph.NewNode(nodeOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create node: %w", err)
 		}
+		if err := g.AddNode(node); err != nil {
+			return nil, fmt.Errorf("failed to add node: %w", err)
+		}
 		nodeMap[nodeUID] = node
+
+		if isInput {
+			inputs = append(inputs, node)
+		}
+		if isOutput {
+			outputs = append(outputs, node)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("row error: %w", err)
 	}
 
+	for _, root := range q.roots {
+		if _, ok := nodeMap[root]; !ok {
+			return nil, fmt.Errorf("failed to retrieve node %s: %w", root, sql.ErrNoRows)
+		}
+	}
+
 	// Retrieve edges
-	edgeRows, err := tx.QueryContext(ctx, `
+	edgeRows, err := tx.QueryContext(ctx, q.with+`
 		SELECT
 			uid,
 			source,
@@ -133,8 +358,8 @@ func (l *Loader) Load(ctx context.Context, uid string) (*graph.Graph, error) {
 			created_at,
 			updated_at
 		FROM edges
-		WHERE graph = ?
-	`, uid)
+		WHERE graph = ? `+edgeFilter+`
+	`, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve edges: %w", err)
 	}
@@ -187,5 +412,12 @@ func (l *Loader) Load(ctx context.Context, uid string) (*graph.Graph, error) {
 		return nil, fmt.Errorf("row error: %w", err)
 	}
 
+	if len(inputs) > 0 {
+		g.SetInputs(inputs)
+	}
+	if len(outputs) > 0 {
+		g.SetOutputs(outputs)
+	}
+
 	return g, nil
 }
@@ -2,6 +2,10 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
 	"testing"
 
 	"github.com/milosgajdos/go-hypher"
@@ -169,3 +173,321 @@ func TestLoader_Load(t *testing.T) {
 		}
 	}
 }
+
+// greetOp is a test Op with a spec.
+type greetOp struct {
+	Greeting string `json:"greeting"`
+}
+
+func (op *greetOp) Type() string   { return "GreetOp" }
+func (op *greetOp) Desc() string   { return "GreetOp greets" }
+func (op *greetOp) String() string { return op.Type() }
+func (op *greetOp) Spec() ([]byte, error) {
+	return json.Marshal(op)
+}
+func (op *greetOp) Do(_ context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	return []hypher.Value{{"greeting": op.Greeting}}, nil
+}
+
+func TestLoader_LoadOps(t *testing.T) {
+	ops := graph.NewOpRegistry()
+	if err := ops.Register("GreetOp", func(spec []byte) (hypher.Op, error) {
+		op := &greetOp{}
+		if err := json.Unmarshal(spec, op); err != nil {
+			return nil, err
+		}
+		return op, nil
+	}); err != nil {
+		t.Fatalf("failed to register op: %v", err)
+	}
+
+	db := MustOpenDB(t, WithOpRegistry(ops))
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create new graph: %v", err)
+	}
+
+	greet, err := graph.NewNode(hypher.WithUID("greet"), hypher.WithOp(&greetOp{Greeting: "hello"}))
+	if err != nil {
+		t.Fatalf("failed to create new node: %v", err)
+	}
+	noop, err := graph.NewNode(hypher.WithUID("noop"))
+	if err != nil {
+		t.Fatalf("failed to create new node: %v", err)
+	}
+	for _, n := range []*graph.Node{greet, noop} {
+		if err := g.AddNode(n); err != nil {
+			t.Fatalf("failed to add node: %v", err)
+		}
+	}
+	e, err := graph.NewEdge(greet, noop)
+	if err != nil {
+		t.Fatalf("failed to create new edge: %v", err)
+	}
+	g.SetWeightedEdge(e)
+
+	if err := MustSyncer(t, db).Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	lg, err := MustLoader(t, db).Load(ctx, g.UID())
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+
+	nodes := lg.Nodes()
+	for nodes.Next() {
+		n := nodes.Node().(*graph.Node)
+		switch n.UID() {
+		case "greet":
+			op, ok := n.Op().(*greetOp)
+			if !ok || op.Greeting != "hello" {
+				t.Errorf("unexpected op: %#v", n.Op())
+			}
+		case "noop":
+			if n.Op().Type() != "NoOp" {
+				t.Errorf("expected NoOp, got: %s", n.Op().Type())
+			}
+		}
+	}
+
+	// unknown op types fail the load
+	db2 := MustOpenDB(t, WithOpRegistry(graph.NewOpRegistry()))
+	defer MustCloseDB(t, db2)
+
+	if err := MustSyncer(t, db2).Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+	if _, err := MustLoader(t, db2).Load(ctx, g.UID()); err == nil {
+		t.Error("expected unknown op type error")
+	}
+}
+
+func TestLoader_LoadRoundTrip(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+
+	g, err := graph.NewGraph(hypher.WithLabel("agent"), hypher.WithDotID("agent_graph"))
+	if err != nil {
+		t.Fatalf("failed to create new graph: %v", err)
+	}
+
+	nodes := make(map[string]*graph.Node)
+	for i, uid := range []string{"in", "plan", "out", "orphan"} {
+		n, err := graph.NewNode(
+			hypher.WithID(int64(10+i)),
+			hypher.WithUID(uid),
+			hypher.WithDotID(uid+"_dot"),
+			hypher.WithLabel(uid),
+		)
+		if err != nil {
+			t.Fatalf("failed to create new node: %v", err)
+		}
+		if err := g.AddNode(n); err != nil {
+			t.Fatalf("failed to add node: %v", err)
+		}
+		nodes[uid] = n
+	}
+	for _, e := range [][2]string{{"in", "plan"}, {"plan", "out"}} {
+		if _, err := g.NewEdge(nodes[e[0]], nodes[e[1]], hypher.WithUID(e[0]+"-"+e[1])); err != nil {
+			t.Fatalf("failed to create new edge: %v", err)
+		}
+	}
+	g.SetInputs([]*graph.Node{nodes["in"]})
+	g.SetOutputs([]*graph.Node{nodes["out"]})
+
+	if err := MustSyncer(t, db).Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	lg, err := MustLoader(t, db).Load(ctx, g.UID())
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+
+	if lg.DOTID() != g.DOTID() {
+		t.Errorf("expected graph DOT ID: %s, got: %s", g.DOTID(), lg.DOTID())
+	}
+	if n := lg.Nodes().Len(); n != g.Nodes().Len() {
+		t.Errorf("expected %d nodes, got: %d", g.Nodes().Len(), n)
+	}
+	if n := lg.Edges().Len(); n != g.Edges().Len() {
+		t.Errorf("expected %d edges, got: %d", g.Edges().Len(), n)
+	}
+
+	for uid, n := range nodes {
+		ln, ok := lg.Node(n.ID()).(*graph.Node)
+		if !ok {
+			t.Errorf("node %s: expected ID: %d", uid, n.ID())
+			continue
+		}
+		if ln.UID() != uid {
+			t.Errorf("node %d: expected UID: %s, got: %s", n.ID(), uid, ln.UID())
+		}
+		if ln.DOTID() != n.DOTID() {
+			t.Errorf("node %s: expected DOT ID: %s, got: %s", uid, n.DOTID(), ln.DOTID())
+		}
+	}
+
+	for _, e := range [][2]string{{"in", "plan"}, {"plan", "out"}} {
+		if !lg.HasEdgeFromTo(nodes[e[0]].ID(), nodes[e[1]].ID()) {
+			t.Errorf("expected edge %s -> %s", e[0], e[1])
+		}
+	}
+
+	if in := lg.Inputs(); len(in) != 1 || in[0].UID() != "in" {
+		t.Errorf("expected inputs: [in], got: %v", in)
+	}
+	if out := lg.Outputs(); len(out) != 1 || out[0].UID() != "out" {
+		t.Errorf("expected outputs: [out], got: %v", out)
+	}
+
+	// the loaded graph can be run
+	if err := lg.Run(ctx, map[string]hypher.Value{"in": {"msg": "hello"}}); err != nil {
+		t.Errorf("failed to run loaded graph: %v", err)
+	}
+}
+
+// MustSyncDAG syncs graph: a -> b -> c -> d, x -> c, y.
+func MustSyncDAG(t *testing.T, db *DB) *graph.Graph {
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create new graph: %v", err)
+	}
+
+	nodes := make(map[string]*graph.Node)
+	for _, uid := range []string{"a", "b", "c", "d", "x", "y"} {
+		n, err := g.NewNode(hypher.WithUID(uid))
+		if err != nil {
+			t.Fatalf("failed to create new node: %v", err)
+		}
+		nodes[uid] = n
+	}
+	for _, e := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "d"}, {"x", "c"}} {
+		if _, err := g.NewEdge(nodes[e[0]], nodes[e[1]], hypher.WithUID(e[0]+e[1])); err != nil {
+			t.Fatalf("failed to create new edge: %v", err)
+		}
+	}
+
+	if err := MustSyncer(t, db).Sync(context.Background(), g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	return g
+}
+
+// elements returns the sorted node and edge UIDs of g.
+func elements(g *graph.Graph) ([]string, []string) {
+	var nodes, edges []string
+	nit := g.Nodes()
+	for nit.Next() {
+		nodes = append(nodes, nit.Node().(*graph.Node).UID())
+	}
+	eit := g.Edges()
+	for eit.Next() {
+		edges = append(edges, eit.Edge().(*graph.Edge).UID())
+	}
+	slices.Sort(nodes)
+	slices.Sort(edges)
+	return nodes, edges
+}
+
+func TestLoader_Reach(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	g := MustSyncDAG(t, db)
+	l := MustLoader(t, db)
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		name  string
+		load  func() (*graph.Graph, error)
+		nodes []string
+		edges []string
+	}{
+		{
+			name:  "descendants",
+			load:  func() (*graph.Graph, error) { return l.Descendants(ctx, g.UID(), "b", 0) },
+			nodes: []string{"b", "c", "d"},
+			edges: []string{"bc", "cd"},
+		},
+		{
+			name:  "descendants depth",
+			load:  func() (*graph.Graph, error) { return l.Descendants(ctx, g.UID(), "b", 1) },
+			nodes: []string{"b", "c"},
+			edges: []string{"bc"},
+		},
+		{
+			name:  "ancestors",
+			load:  func() (*graph.Graph, error) { return l.Ancestors(ctx, g.UID(), "c", 0) },
+			nodes: []string{"a", "b", "c", "x"},
+			edges: []string{"ab", "bc", "xc"},
+		},
+		{
+			name:  "ancestors depth",
+			load:  func() (*graph.Graph, error) { return l.Ancestors(ctx, g.UID(), "d", 1) },
+			nodes: []string{"c", "d"},
+			edges: []string{"cd"},
+		},
+		{
+			name:  "isolated",
+			load:  func() (*graph.Graph, error) { return l.Descendants(ctx, g.UID(), "y", 0) },
+			nodes: []string{"y"},
+		},
+		{
+			name:  "path",
+			load:  func() (*graph.Graph, error) { return l.Path(ctx, g.UID(), "a", "d") },
+			nodes: []string{"a", "b", "c", "d"},
+			edges: []string{"ab", "bc", "cd"},
+		},
+		{
+			name:  "path same node",
+			load:  func() (*graph.Graph, error) { return l.Path(ctx, g.UID(), "c", "c") },
+			nodes: []string{"c"},
+		},
+		{
+			name:  "subgraph",
+			load:  func() (*graph.Graph, error) { return l.LoadSubGraph(ctx, g.UID(), []string{"a", "x"}, 1) },
+			nodes: []string{"a", "b", "c", "x"},
+			edges: []string{"ab", "bc", "xc"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sg, err := tc.load()
+			if err != nil {
+				t.Fatalf("failed to load graph: %v", err)
+			}
+			if sg.UID() != g.UID() {
+				t.Errorf("expected graph UID: %s, got: %s", g.UID(), sg.UID())
+			}
+			nodes, edges := elements(sg)
+			if !slices.Equal(nodes, tc.nodes) {
+				t.Errorf("expected nodes: %v, got: %v", tc.nodes, nodes)
+			}
+			if !slices.Equal(edges, tc.edges) {
+				t.Errorf("expected edges: %v, got: %v", tc.edges, edges)
+			}
+		})
+	}
+
+	if _, err := l.Descendants(ctx, g.UID(), "missing", 0); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected %v, got: %v", sql.ErrNoRows, err)
+	}
+	for _, path := range [][2]string{{"a", "x"}, {"d", "a"}, {"a", "missing"}} {
+		if _, err := l.Path(ctx, g.UID(), path[0], path[1]); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("path %s -> %s: expected %v, got: %v", path[0], path[1], sql.ErrNoRows, err)
+		}
+	}
+	if _, err := l.LoadSubGraph(ctx, g.UID(), nil, 0); err == nil {
+		t.Error("expected missing roots error")
+	}
+}
@@ -0,0 +1,260 @@
+package sqlite
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationRe matches migration file names: <version>_<name>.<up|down>.sql
+var migrationRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migration is a versioned schema migration.
+type migration struct {
+	version  int
+	name     string
+	up       string
+	down     string
+	checksum string
+}
+
+// loadMigrations loads the embedded migrations sorted by their versions.
+// Migration versions must start at 1 and must have no gaps.
+func loadMigrations() ([]migration, error) {
+	names, err := fs.Glob(migrationFS, Migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, name := range names {
+		m := migrationRe.FindStringSubmatch(path.Base(name))
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		version, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", name)
+		}
+
+		buf, err := fs.ReadFile(migrationFS, name)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{version: version, name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.name != m[2] {
+			return nil, fmt.Errorf("migration %d: name mismatch: %s != %s", version, mig.name, m[2])
+		}
+
+		switch m[3] {
+		case "up":
+			sum := sha256.Sum256(buf)
+			mig.up = string(buf)
+			mig.checksum = hex.EncodeToString(sum[:])
+		case "down":
+			mig.down = string(buf)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	for i, mig := range migrations {
+		if mig.version != i+1 {
+			return nil, fmt.Errorf("missing migration version: %d", i+1)
+		}
+		if mig.up == "" {
+			return nil, fmt.Errorf("migration %d: missing up migration", mig.version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// migrate migrates the database to the latest schema version.
+func (s *DB) migrate() error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return s.migrateTo(migrations, len(migrations))
+}
+
+// Version returns the current database schema version.
+// Version 0 means no migrations have been applied.
+func (s *DB) Version() (int, error) {
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(applied), nil
+}
+
+// MigrateTo migrates the database schema up or down to the given version.
+// Every migration is applied in its own transaction. The checksums of
+// the applied migrations are verified against the embedded migrations
+// before any migration is applied. Migrating to version 0 reverts
+// all the migrations.
+func (s *DB) MigrateTo(version int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return s.migrateTo(migrations, version)
+}
+
+// migrateTo migrates the database schema to the given version.
+func (s *DB) migrateTo(migrations []migration, version int) error {
+	if version < 0 || version > len(migrations) {
+		return fmt.Errorf("invalid schema version: %d", version)
+	}
+
+	if _, err := s.db.ExecContext(s.ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)
+	`); err != nil {
+		return err
+	}
+
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	if err := verifyMigrations(migrations, applied); err != nil {
+		return err
+	}
+
+	current := len(applied)
+
+	for v := current + 1; v <= version; v++ {
+		if err := s.applyMigration(migrations[v-1], true); err != nil {
+			return err
+		}
+	}
+	for v := current; v > version; v-- {
+		if err := s.applyMigration(migrations[v-1], false); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("database migrated",
+		slog.String("dsn", s.DSN),
+		slog.Int("from", current),
+		slog.Int("to", version),
+	)
+
+	return nil
+}
+
+// appliedMigrations returns the checksums of the applied migrations sorted by their versions.
+func (s *DB) appliedMigrations() ([]string, error) {
+	rows, err := s.db.QueryContext(s.ctx, `SELECT version, checksum FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checksums []string
+	for rows.Next() {
+		var (
+			version  int
+			checksum string
+		)
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, err
+		}
+		if version != len(checksums)+1 {
+			return nil, fmt.Errorf("missing applied migration version: %d", len(checksums)+1)
+		}
+		checksums = append(checksums, checksum)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return checksums, nil
+}
+
+// verifyMigrations verifies the checksums of the applied migrations.
+func verifyMigrations(migrations []migration, applied []string) error {
+	if len(applied) > len(migrations) {
+		return fmt.Errorf("unknown schema version: %d", len(applied))
+	}
+	for i, checksum := range applied {
+		if m := migrations[i]; m.checksum != checksum {
+			return fmt.Errorf("migration %d_%s: checksum mismatch", m.version, m.name)
+		}
+	}
+	return nil
+}
+
+// applyMigration applies the up or down migration m in a transaction.
+func (s *DB) applyMigration(m migration, up bool) error {
+	direction, query := "up", m.up
+	if !up {
+		direction, query = "down", m.down
+		if query == "" {
+			return fmt.Errorf("migration %d_%s: missing down migration", m.version, m.name)
+		}
+	}
+
+	s.logger.Debug("applying migration",
+		slog.Int("version", m.version),
+		slog.String("name", m.name),
+		slog.String("direction", direction),
+	)
+
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(s.ctx, query); err != nil {
+		s.logger.Error("migration failed",
+			slog.Int("version", m.version),
+			slog.String("name", m.name),
+			slog.Any("error", err),
+		)
+		return fmt.Errorf("migration %d_%s %s: %w", m.version, m.name, direction, err)
+	}
+
+	if up {
+		appliedAt := time.Now()
+		_, err = tx.ExecContext(s.ctx, `
+			INSERT INTO schema_migrations (version, name, checksum, applied_at)
+			VALUES (?, ?, ?, ?)
+		`, m.version, m.name, m.checksum, (*NullTime)(&appliedAt))
+	} else {
+		_, err = tx.ExecContext(s.ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,259 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func MustVersion(t *testing.T, db *DB) int {
+	t.Helper()
+	v, err := db.Version()
+	if err != nil {
+		t.Fatalf("failed to get schema version: %v", err)
+	}
+	return v
+}
+
+func hasTable(t *testing.T, db *DB, name string) bool {
+	t.Helper()
+	var n int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&n); err != nil {
+		t.Fatalf("failed to query tables: %v", err)
+	}
+	return n > 0
+}
+
+func TestMigrateTo(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	latest := len(migrations)
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	if v := MustVersion(t, db); v != latest {
+		t.Fatalf("expected version: %d, got: %d", latest, v)
+	}
+
+	if err := db.MigrateTo(0); err != nil {
+		t.Fatalf("failed to migrate down: %v", err)
+	}
+	if v := MustVersion(t, db); v != 0 {
+		t.Errorf("expected version: 0, got: %d", v)
+	}
+	if hasTable(t, db, "graphs") {
+		t.Error("expected graphs table to be dropped")
+	}
+
+	if err := db.MigrateTo(latest); err != nil {
+		t.Fatalf("failed to migrate up: %v", err)
+	}
+	if v := MustVersion(t, db); v != latest {
+		t.Errorf("expected version: %d, got: %d", latest, v)
+	}
+	if !hasTable(t, db, "graphs") {
+		t.Error("expected graphs table")
+	}
+
+	for _, v := range []int{-1, latest + 1} {
+		if err := db.MigrateTo(v); err == nil {
+			t.Errorf("expected error migrating to version %d", v)
+		}
+	}
+}
+
+func TestMigrateChecksum(t *testing.T) {
+	dsn := Scheme + "://" + filepath.Join(t.TempDir(), "db")
+
+	db, err := NewDB(dsn)
+	if err != nil {
+		t.Fatalf("failed to open DB: %v", err)
+	}
+	if _, err := db.db.Exec(`UPDATE schema_migrations SET checksum = 'tampered' WHERE version = 1`); err != nil {
+		t.Fatalf("failed to update checksum: %v", err)
+	}
+	MustCloseDB(t, db)
+
+	if _, err := NewDB(dsn); err == nil {
+		t.Fatal("expected checksum error")
+	}
+}
+
+func TestMigrateReopen(t *testing.T) {
+	dsn := Scheme + "://" + filepath.Join(t.TempDir(), "db")
+
+	for i := 0; i < 2; i++ {
+		db, err := NewDB(dsn)
+		if err != nil {
+			t.Fatalf("failed to open DB: %v", err)
+		}
+		if v := MustVersion(t, db); v == 0 {
+			t.Errorf("expected migrated DB")
+		}
+		MustCloseDB(t, db)
+	}
+}
+
+// MustBaselineDB seeds a DB file with the schema and rows created
+// before versioned migrations and returns its DSN.
+func MustBaselineDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "db")
+
+	baseline, err := fs.ReadFile(migrationFS, "schema/0001_init.up.sql")
+	if err != nil {
+		t.Fatalf("failed to read baseline schema: %v", err)
+	}
+	sdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open DB: %v", err)
+	}
+	for _, query := range []string{
+		string(baseline),
+		`INSERT INTO graphs (uid, label, attrs, created_at, updated_at)
+		VALUES ('g1', 'baseline', '{}', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z')`,
+		`INSERT INTO nodes (uid, graph, label, attrs, created_at, updated_at)
+		VALUES
+			('n1', 'g1', 'n1', '{}', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+			('n2', 'g1', 'n2', '{}', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z')`,
+		`INSERT INTO edges (uid, graph, source, target, label, weight, attrs, created_at, updated_at)
+		VALUES ('e1', 'g1', 'n1', 'n2', 'e1', 1.0, '{}', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z')`,
+	} {
+		if _, err := sdb.Exec(query); err != nil {
+			t.Fatalf("failed to seed DB: %v", err)
+		}
+	}
+	if err := sdb.Close(); err != nil {
+		t.Fatalf("failed to close DB: %v", err)
+	}
+
+	return Scheme + "://" + path
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	db, err := NewDB(MustBaselineDB(t))
+	if err != nil {
+		t.Fatalf("failed to open baseline DB: %v", err)
+	}
+	defer MustCloseDB(t, db)
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if v := MustVersion(t, db); v != len(migrations) {
+		t.Errorf("expected version: %d, got: %d", len(migrations), v)
+	}
+
+	var localIDs []int64
+	rows, err := db.db.Query(`SELECT local_id FROM nodes WHERE graph = 'g1' ORDER BY id`)
+	if err != nil {
+		t.Fatalf("failed to query nodes: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("failed to scan node: %v", err)
+		}
+		localIDs = append(localIDs, id)
+	}
+	if len(localIDs) != 2 || localIDs[0] == localIDs[1] {
+		t.Errorf("expected unique backfilled local IDs, got: %v", localIDs)
+	}
+
+	g, err := MustLoader(t, db).Load(context.Background(), "g1")
+	if err != nil {
+		t.Fatalf("failed to load baseline graph: %v", err)
+	}
+	if n := g.Nodes().Len(); n != 2 {
+		t.Errorf("expected 2 nodes, got: %d", n)
+	}
+	if e := g.Edges().Len(); e != 1 {
+		t.Errorf("expected 1 edge, got: %d", e)
+	}
+
+	// the synced baseline graph must be upserted
+	if err := MustSyncer(t, db).Sync(context.Background(), g); err != nil {
+		t.Fatalf("failed to sync baseline graph: %v", err)
+	}
+}
+
+func TestMigrateBaselineOps(t *testing.T) {
+	ops := graph.NewOpRegistry()
+	if err := ops.Register("GreetOp", func(spec []byte) (hypher.Op, error) {
+		op := &greetOp{}
+		if err := json.Unmarshal(spec, op); err != nil {
+			return nil, err
+		}
+		return op, nil
+	}); err != nil {
+		t.Fatalf("failed to register op: %v", err)
+	}
+
+	db, err := NewDB(MustBaselineDB(t), WithOpRegistry(ops))
+	if err != nil {
+		t.Fatalf("failed to open baseline DB: %v", err)
+	}
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+
+	g := MustRunGraph(t, &greetOp{Greeting: "hello"})
+	if err := MustSyncer(t, db).Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	g2, err := MustLoader(t, db).Load(ctx, g.UID())
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+	op, ok := MustNodeByUID(t, g2, "out").Op().(*greetOp)
+	if !ok || op.Greeting != "hello" {
+		t.Errorf("unexpected op: %#v", MustNodeByUID(t, g2, "out").Op())
+	}
+}
+
+func TestMigrateBaselineRoles(t *testing.T) {
+	db, err := NewDB(MustBaselineDB(t))
+	if err != nil {
+		t.Fatalf("failed to open baseline DB: %v", err)
+	}
+	defer MustCloseDB(t, db)
+
+	ctx := context.Background()
+
+	g := MustRunGraph(t, &greetOp{Greeting: "hello"})
+	g.SetDOTID("run")
+	if err := MustSyncer(t, db).Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	g2, err := MustLoader(t, db).Load(ctx, g.UID())
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+	if g2.DOTID() != "run" {
+		t.Errorf("expected DOT ID: run, got: %s", g2.DOTID())
+	}
+	if in := g2.Inputs(); len(in) != 1 || in[0].UID() != "in" {
+		t.Errorf("unexpected inputs: %v", in)
+	}
+	if out := g2.Outputs(); len(out) != 1 || out[0].UID() != "out" {
+		t.Errorf("unexpected outputs: %v", out)
+	}
+	for _, uid := range []string{"in", "out"} {
+		if id, id2 := MustNodeByUID(t, g, uid).ID(), MustNodeByUID(t, g2, uid).ID(); id != id2 {
+			t.Errorf("node %s: expected ID: %d, got: %d", uid, id, id2)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"log/slog"
+
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// Options configure DB.
+type Options struct {
+	// Logger configures DB logger.
+	Logger *slog.Logger
+	// LogLevel configures the minimum level of logged records.
+	LogLevel slog.Leveler
+	// OpRegistry configures the registry used to restore node Ops.
+	OpRegistry *graph.OpRegistry
+}
+
+// Option is functional DB option.
+type Option func(*Options)
+
+// WithLogger sets Logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithLogLevel sets LogLevel.
+func WithLogLevel(level slog.Leveler) Option {
+	return func(o *Options) {
+		o.LogLevel = level
+	}
+}
+
+// WithOpRegistry sets OpRegistry.
+func WithOpRegistry(r *graph.OpRegistry) Option {
+	return func(o *Options) {
+		o.OpRegistry = r
+	}
+}
+
+// SyncerOptions configure Syncer.
+type SyncerOptions struct {
+	// Incremental enables incremental sync.
+	Incremental bool
+}
+
+// SyncerOption is functional Syncer option.
+type SyncerOption func(*SyncerOptions)
+
+// WithIncremental enables or disables incremental sync.
+// Incremental sync only syncs the graph changes made since
+// the graph was last synced by the same Syncer.
+func WithIncremental(enabled bool) SyncerOption {
+	return func(o *SyncerOptions) {
+		o.Incremental = enabled
+	}
+}
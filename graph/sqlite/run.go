@@ -0,0 +1,660 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// RunStatus is the status of a run or a node execution.
+type RunStatus string
+
+const (
+	// StatusScheduled is the status of scheduled node executions.
+	StatusScheduled RunStatus = "scheduled"
+	// StatusRunning is the status of runs and node executions in progress.
+	StatusRunning RunStatus = "running"
+	// StatusSucceeded is the status of successfully finished runs and node executions.
+	StatusSucceeded RunStatus = "succeeded"
+	// StatusFailed is the status of failed runs and node executions.
+	StatusFailed RunStatus = "failed"
+	// StatusSkipped is the status of skipped nodes.
+	StatusSkipped RunStatus = "skipped"
+)
+
+// NodeExecution is a recorded node execution.
+type NodeExecution struct {
+	// NodeUID is node UID.
+	NodeUID string
+	// Label is node label.
+	Label string
+	// OpType is node Op type.
+	OpType string
+	// Lane is the node execution lane.
+	Lane int
+	// Status is the node execution status.
+	Status RunStatus
+	// Error is the node execution error.
+	Error string
+	// Attempts is the number of node execution attempts.
+	Attempts int
+	// Inputs are node execution inputs.
+	Inputs []hypher.Value
+	// Outputs are node execution outputs.
+	Outputs []hypher.Value
+	// Usage is node execution usage.
+	Usage hypher.Usage
+	// Scheduled is the time the node was scheduled.
+	Scheduled time.Time
+	// Start is the time the node execution started.
+	Start time.Time
+	// End is the time the node execution finished.
+	End time.Time
+}
+
+// Duration returns node execution duration.
+func (n *NodeExecution) Duration() time.Duration {
+	return n.End.Sub(n.Start)
+}
+
+// Run is a recorded graph run.
+type Run struct {
+	// ID is the run ID.
+	ID string
+	// GraphUID is the UID of the graph.
+	GraphUID string
+	// GraphVersion is the graph revision at the start of the run.
+	GraphVersion uint64
+	// ConcMode is the run concurrency mode.
+	ConcMode hypher.ConcMode
+	// Inputs are the run inputs indexed by input node UID.
+	Inputs map[string][]hypher.Value
+	// Status is the run status.
+	Status RunStatus
+	// Error is the run error.
+	Error string
+	// Usage is the total run usage.
+	Usage hypher.Usage
+	// Start is the time the run started.
+	Start time.Time
+	// End is the time the run finished.
+	End time.Time
+	// Nodes are the run node executions.
+	Nodes []*NodeExecution
+}
+
+// Duration returns the run duration.
+func (r *Run) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// RunFilter filters listed runs.
+// Zero values of the fields do not filter.
+type RunFilter struct {
+	// GraphUID filters runs by graph UID.
+	GraphUID string
+	// Status filters runs by status.
+	Status RunStatus
+	// Since filters runs started at or after the given time.
+	Since time.Time
+	// Until filters runs started before the given time.
+	Until time.Time
+	// Limit limits the number of listed runs.
+	Limit int
+	// Offset skips the given number of runs.
+	Offset int
+}
+
+// RunStore stores graph runs in sqlite DB.
+// RunStore implements hypher.Observer so it can record
+// graph runs when it's passed to Graph.Run via hypher.WithObserver.
+type RunStore struct {
+	db *DB
+	// runs in progress indexed by run ID
+	runs map[string]*runState
+	mu   sync.Mutex
+}
+
+// runState is the state of a run in progress.
+type runState struct {
+	run *Run
+	// UIDs of the nodes whose executions have been stored
+	stored map[string]bool
+}
+
+// NewRunStore creates a new run store and returns it.
+func NewRunStore(db *DB) (*RunStore, error) {
+	return &RunStore{
+		db:   db,
+		runs: make(map[string]*runState),
+	}, nil
+}
+
+// Observe records run event e.
+// Node executions are buffered in memory and stored when the nodes
+// finish, the run is stored when it starts and when it finishes along
+// with the node executions which never finished, so the runs which
+// never finish are stored, too. Storage errors are logged by the DB logger.
+func (s *RunStore) Observe(ctx context.Context, e hypher.Event) {
+	// node events may arrive with canceled contexts when a run fails.
+	ctx = context.WithoutCancel(ctx)
+
+	s.mu.Lock()
+	write := s.observe(e)
+	s.mu.Unlock()
+
+	// NOTE: writes are done without the lock held
+	// so concurrent nodes do not wait for each other.
+	if write != nil {
+		s.logErr(ctx, e.RunID, s.exec(ctx, func(tx *sql.Tx) error { return write(ctx, tx) }))
+	}
+}
+
+// observe records run event e and returns the write of the recorded
+// state or nil if nothing needs to be stored.
+// It must be called with the store lock held.
+func (s *RunStore) observe(e hypher.Event) func(context.Context, *sql.Tx) error {
+	if e.Type == hypher.RunStarted {
+		r := &Run{
+			ID:       e.RunID,
+			GraphUID: e.Graph.UID(),
+			ConcMode: e.ConcMode,
+			Inputs:   make(map[string][]hypher.Value),
+			Status:   StatusRunning,
+			Start:    e.Time,
+		}
+		if g, ok := e.Graph.(*graph.Graph); ok {
+			r.GraphVersion = g.Revision()
+			for _, n := range g.Inputs() {
+				if inputs := n.Inputs(); len(inputs) > 0 {
+					r.Inputs[n.UID()] = inputs
+				}
+			}
+		}
+		s.runs[r.ID] = &runState{run: r, stored: make(map[string]bool)}
+		run := *r
+		return func(ctx context.Context, tx *sql.Tx) error { return saveRun(ctx, tx, &run) }
+	}
+
+	rs, ok := s.runs[e.RunID]
+	if !ok {
+		return nil
+	}
+	r := rs.run
+
+	if e.Type == hypher.RunFinished {
+		r.End = e.Time
+		r.Usage = e.Usage
+		r.Status = StatusSucceeded
+		if e.Err != nil {
+			r.Status = StatusFailed
+			r.Error = e.Err.Error()
+		}
+		delete(s.runs, r.ID)
+
+		run := *r
+		var nodes []NodeExecution
+		for _, n := range r.Nodes {
+			if !rs.stored[n.NodeUID] {
+				nodes = append(nodes, *n)
+			}
+		}
+		return func(ctx context.Context, tx *sql.Tx) error {
+			if err := saveRun(ctx, tx, &run); err != nil {
+				return err
+			}
+			for i := range nodes {
+				if err := saveNodeExecution(ctx, tx, run.ID, &nodes[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	if e.Node == nil {
+		return nil
+	}
+
+	var n *NodeExecution
+	for _, ne := range r.Nodes {
+		if ne.NodeUID == e.Node.UID() {
+			n = ne
+			break
+		}
+	}
+	if n == nil {
+		n = &NodeExecution{
+			NodeUID: e.Node.UID(),
+			Label:   e.Node.Label(),
+		}
+		if o, ok := e.Node.(interface{ Op() hypher.Op }); ok && o.Op() != nil {
+			n.OpType = o.Op().Type()
+		}
+		r.Nodes = append(r.Nodes, n)
+	}
+
+	switch e.Type {
+	case hypher.NodeSkipped:
+		n.Status = StatusSkipped
+	case hypher.NodeScheduled:
+		n.Status = StatusScheduled
+		n.Lane = e.Lane
+		n.Scheduled = e.Time
+	case hypher.NodeStarted:
+		n.Status = StatusRunning
+		n.Attempts++
+		n.Start = e.Time
+		n.Inputs = e.Inputs
+	case hypher.NodeFinished:
+		n.Status = StatusSucceeded
+		if e.Err != nil {
+			n.Status = StatusFailed
+			n.Error = e.Err.Error()
+		}
+		n.End = e.Time
+		n.Outputs = e.Outputs
+		n.Usage = e.Usage
+
+		rs.stored[n.NodeUID] = true
+		runID, node := r.ID, *n
+		return func(ctx context.Context, tx *sql.Tx) error { return saveNodeExecution(ctx, tx, runID, &node) }
+	}
+
+	return nil
+}
+
+// logErr logs the error of storing run runID.
+func (s *RunStore) logErr(ctx context.Context, runID string, err error) {
+	if err == nil {
+		return
+	}
+	s.db.logger.ErrorContext(ctx, "run store failed",
+		slog.String("run_id", runID),
+		slog.Any("error", err),
+	)
+}
+
+// exec runs fn in a transaction.
+func (s *RunStore) exec(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Save stores run r in sqlite DB.
+// If the run already exists it's replaced including its node executions.
+func (s *RunStore) Save(ctx context.Context, r *Run) error {
+	return s.exec(ctx, func(tx *sql.Tx) error {
+		if err := saveRun(ctx, tx, r); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM node_executions WHERE run = ?`, r.ID); err != nil {
+			return err
+		}
+		for _, n := range r.Nodes {
+			if err := saveNodeExecution(ctx, tx, r.ID, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Get returns the run with the given id including its node executions.
+func (s *RunStore) Get(ctx context.Context, id string) (*Run, error) {
+	r, err := scanRun(s.db.db.QueryRowContext(ctx, `SELECT `+runColumns+` FROM runs WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve run: %w", err)
+	}
+
+	rows, err := s.db.db.QueryContext(ctx, `
+		SELECT
+			node,
+			label,
+			op_type,
+			lane,
+			status,
+			error,
+			attempts,
+			inputs,
+			outputs,
+			tokens_in,
+			tokens_out,
+			cost,
+			counters,
+			scheduled_at,
+			started_at,
+			finished_at
+		FROM node_executions
+		WHERE run = ?
+		ORDER BY scheduled_at, node
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve node executions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			n                         NodeExecution
+			label, opType, errMsg     sql.NullString
+			inputs, outputs, counters sql.NullString
+			status                    string
+		)
+		if err := rows.Scan(&n.NodeUID, &label, &opType, &n.Lane, &status, &errMsg, &n.Attempts,
+			&inputs, &outputs, &n.Usage.TokensIn, &n.Usage.TokensOut, &n.Usage.Cost, &counters,
+			(*NanoTime)(&n.Scheduled), (*NanoTime)(&n.Start), (*NanoTime)(&n.End)); err != nil {
+			return nil, fmt.Errorf("failed to scan node execution: %w", err)
+		}
+		n.Label, n.OpType, n.Error = label.String, opType.String, errMsg.String
+		n.Status = RunStatus(status)
+		if err := unmarshalNull(inputs, &n.Inputs); err != nil {
+			return nil, fmt.Errorf("node %s inputs: %w", n.NodeUID, err)
+		}
+		if err := unmarshalNull(outputs, &n.Outputs); err != nil {
+			return nil, fmt.Errorf("node %s outputs: %w", n.NodeUID, err)
+		}
+		if err := unmarshalNull(counters, &n.Usage.Counters); err != nil {
+			return nil, fmt.Errorf("node %s usage: %w", n.NodeUID, err)
+		}
+		r.Nodes = append(r.Nodes, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return r, nil
+}
+
+// List returns the runs matching filter f ordered
+// from the most recently started ones.
+// The listed runs do not include their node executions.
+func (s *RunStore) List(ctx context.Context, f RunFilter) ([]*Run, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if f.GraphUID != "" {
+		where = append(where, "graph = ?")
+		args = append(args, f.GraphUID)
+	}
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, string(f.Status))
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, (*NanoTime)(&f.Since))
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "started_at < ?")
+		args = append(args, (*NanoTime)(&f.Until))
+	}
+
+	query := `SELECT ` + runColumns + ` FROM runs`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+
+	// negative limit means no limit in sqlite.
+	limit := f.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	query += ` ORDER BY started_at DESC, id LIMIT ? OFFSET ?`
+	args = append(args, limit, max(f.Offset, 0))
+
+	rows, err := s.db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*Run
+	for rows.Next() {
+		r, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		runs = append(runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return runs, nil
+}
+
+// Delete deletes the run with the given id including its node executions.
+func (s *RunStore) Delete(ctx context.Context, id string) error {
+	res, err := s.db.db.ExecContext(ctx, `DELETE FROM runs WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to delete run %s: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// runColumns are the selected runs table columns.
+const runColumns = `
+	id,
+	graph,
+	graph_version,
+	conc_mode,
+	inputs,
+	status,
+	error,
+	tokens_in,
+	tokens_out,
+	cost,
+	counters,
+	started_at,
+	finished_at
+`
+
+// scanRun scans the run columns from row.
+func scanRun(row interface{ Scan(...any) error }) (*Run, error) {
+	var (
+		r                Run
+		concMode, errMsg sql.NullString
+		inputs, counters sql.NullString
+		status           string
+	)
+	if err := row.Scan(&r.ID, &r.GraphUID, &r.GraphVersion, &concMode, &inputs, &status, &errMsg,
+		&r.Usage.TokensIn, &r.Usage.TokensOut, &r.Usage.Cost, &counters,
+		(*NanoTime)(&r.Start), (*NanoTime)(&r.End)); err != nil {
+		return nil, err
+	}
+	r.Status = RunStatus(status)
+	r.Error = errMsg.String
+	r.ConcMode = parseConcMode(concMode.String)
+	if err := unmarshalNull(inputs, &r.Inputs); err != nil {
+		return nil, fmt.Errorf("run %s inputs: %w", r.ID, err)
+	}
+	if err := unmarshalNull(counters, &r.Usage.Counters); err != nil {
+		return nil, fmt.Errorf("run %s usage: %w", r.ID, err)
+	}
+	return &r, nil
+}
+
+// saveRun upserts run r without its node executions.
+func saveRun(ctx context.Context, tx *sql.Tx, r *Run) error {
+	inputs, err := marshalNull(r.Inputs)
+	if err != nil {
+		return fmt.Errorf("run %s inputs: %w", r.ID, err)
+	}
+	counters, err := marshalNull(r.Usage.Counters)
+	if err != nil {
+		return fmt.Errorf("run %s usage: %w", r.ID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO runs (
+			id,
+			graph,
+			graph_version,
+			conc_mode,
+			inputs,
+			status,
+			error,
+			tokens_in,
+			tokens_out,
+			cost,
+			counters,
+			started_at,
+			finished_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			graph = excluded.graph,
+			graph_version = excluded.graph_version,
+			conc_mode = excluded.conc_mode,
+			inputs = excluded.inputs,
+			status = excluded.status,
+			error = excluded.error,
+			tokens_in = excluded.tokens_in,
+			tokens_out = excluded.tokens_out,
+			cost = excluded.cost,
+			counters = excluded.counters,
+			started_at = excluded.started_at,
+			finished_at = excluded.finished_at
+	`,
+		r.ID,
+		r.GraphUID,
+		r.GraphVersion,
+		r.ConcMode.String(),
+		inputs,
+		string(r.Status),
+		sql.NullString{String: r.Error, Valid: r.Error != ""},
+		r.Usage.TokensIn,
+		r.Usage.TokensOut,
+		r.Usage.Cost,
+		counters,
+		(*NanoTime)(&r.Start),
+		(*NanoTime)(&r.End),
+	)
+	return err
+}
+
+// saveNodeExecution upserts node execution n of run runID.
+func saveNodeExecution(ctx context.Context, tx *sql.Tx, runID string, n *NodeExecution) error {
+	inputs, err := marshalNull(n.Inputs)
+	if err != nil {
+		return fmt.Errorf("node %s inputs: %w", n.NodeUID, err)
+	}
+	outputs, err := marshalNull(n.Outputs)
+	if err != nil {
+		return fmt.Errorf("node %s outputs: %w", n.NodeUID, err)
+	}
+	counters, err := marshalNull(n.Usage.Counters)
+	if err != nil {
+		return fmt.Errorf("node %s usage: %w", n.NodeUID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO node_executions (
+			run,
+			node,
+			label,
+			op_type,
+			lane,
+			status,
+			error,
+			attempts,
+			inputs,
+			outputs,
+			tokens_in,
+			tokens_out,
+			cost,
+			counters,
+			scheduled_at,
+			started_at,
+			finished_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (run, node) DO UPDATE SET
+			label = excluded.label,
+			op_type = excluded.op_type,
+			lane = excluded.lane,
+			status = excluded.status,
+			error = excluded.error,
+			attempts = excluded.attempts,
+			inputs = excluded.inputs,
+			outputs = excluded.outputs,
+			tokens_in = excluded.tokens_in,
+			tokens_out = excluded.tokens_out,
+			cost = excluded.cost,
+			counters = excluded.counters,
+			scheduled_at = excluded.scheduled_at,
+			started_at = excluded.started_at,
+			finished_at = excluded.finished_at
+	`,
+		runID,
+		n.NodeUID,
+		n.Label,
+		sql.NullString{String: n.OpType, Valid: n.OpType != ""},
+		n.Lane,
+		string(n.Status),
+		sql.NullString{String: n.Error, Valid: n.Error != ""},
+		n.Attempts,
+		inputs,
+		outputs,
+		n.Usage.TokensIn,
+		n.Usage.TokensOut,
+		n.Usage.Cost,
+		counters,
+		(*NanoTime)(&n.Scheduled),
+		(*NanoTime)(&n.Start),
+		(*NanoTime)(&n.End),
+	)
+	return err
+}
+
+// marshalNull encodes v to JSON. Nil v is encoded as NULL.
+func marshalNull(v any) (sql.NullString, error) {
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// unmarshalNull decodes JSON s into v unless s is NULL.
+func unmarshalNull(s sql.NullString, v any) error {
+	if !s.Valid {
+		return nil
+	}
+	return json.Unmarshal([]byte(s.String), v)
+}
+
+// parseConcMode parses the string representation of hypher.ConcMode.
+func parseConcMode(s string) hypher.ConcMode {
+	if s == hypher.ConcAllMode.String() {
+		return hypher.ConcAllMode
+	}
+	return hypher.ConcLevelMode
+}
@@ -0,0 +1,301 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func MustRunStore(tb testing.TB, db *DB) *RunStore {
+	s, err := NewRunStore(db)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return s
+}
+
+// failOp is a test Op which always fails.
+type failOp struct{}
+
+func (failOp) Type() string   { return "FailOp" }
+func (failOp) Desc() string   { return "FailOp fails" }
+func (failOp) String() string { return "FailOp" }
+func (failOp) Do(_ context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	return nil, errors.New("boom")
+}
+
+// MustRunGraph returns graph: in -> out where out runs op.
+func MustRunGraph(t *testing.T, op hypher.Op) *graph.Graph {
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create new graph: %v", err)
+	}
+	in, err := g.NewNode(hypher.WithUID("in"))
+	if err != nil {
+		t.Fatalf("failed to create new node: %v", err)
+	}
+	out, err := g.NewNode(hypher.WithUID("out"), hypher.WithOp(op))
+	if err != nil {
+		t.Fatalf("failed to create new node: %v", err)
+	}
+	if _, err := g.NewEdge(in, out); err != nil {
+		t.Fatalf("failed to create new edge: %v", err)
+	}
+	g.SetInputs([]*graph.Node{in})
+	g.SetOutputs([]*graph.Node{out})
+	return g
+}
+
+func TestRunStore_Observe(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	s := MustRunStore(t, db)
+
+	ctx := context.Background()
+	g := MustRunGraph(t, &greetOp{Greeting: "hello"})
+
+	inputs := map[string]hypher.Value{"in": {"name": "joe"}}
+	if err := g.Run(ctx, inputs, hypher.WithObserver(s)); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+
+	runs, err := s.List(ctx, RunFilter{GraphUID: g.UID()})
+	if err != nil {
+		t.Fatalf("failed to list runs: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 run, got: %d", len(runs))
+	}
+
+	r, err := s.Get(ctx, runs[0].ID)
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	if r.Status != StatusSucceeded {
+		t.Errorf("expected status: %s, got: %s", StatusSucceeded, r.Status)
+	}
+	if r.GraphVersion != g.Revision() {
+		t.Errorf("expected graph version: %d, got: %d", g.Revision(), r.GraphVersion)
+	}
+	if r.Start.IsZero() || r.End.Before(r.Start) {
+		t.Errorf("invalid run timings: %v - %v", r.Start, r.End)
+	}
+	if in := r.Inputs["in"]; len(in) != 1 || in[0]["name"] != "joe" {
+		t.Errorf("unexpected run inputs: %v", r.Inputs)
+	}
+
+	if len(r.Nodes) != 2 {
+		t.Fatalf("expected 2 node executions, got: %d", len(r.Nodes))
+	}
+	for _, n := range r.Nodes {
+		if n.Status != StatusSucceeded {
+			t.Errorf("node %s: expected status: %s, got: %s", n.NodeUID, StatusSucceeded, n.Status)
+		}
+		if n.Attempts != 1 {
+			t.Errorf("node %s: expected 1 attempt, got: %d", n.NodeUID, n.Attempts)
+		}
+		if n.NodeUID == "out" {
+			if n.OpType != "GreetOp" {
+				t.Errorf("expected op type: GreetOp, got: %s", n.OpType)
+			}
+			if len(n.Outputs) != 1 || n.Outputs[0]["greeting"] != "hello" {
+				t.Errorf("unexpected node outputs: %v", n.Outputs)
+			}
+		}
+	}
+}
+
+func TestRunStore_ObserveFailed(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	s := MustRunStore(t, db)
+
+	ctx := context.Background()
+	g := MustRunGraph(t, failOp{})
+
+	if err := g.Run(ctx, nil, hypher.WithObserver(s)); err == nil {
+		t.Fatal("expected run error")
+	}
+
+	runs, err := s.List(ctx, RunFilter{Status: StatusFailed})
+	if err != nil {
+		t.Fatalf("failed to list runs: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 failed run, got: %d", len(runs))
+	}
+
+	r, err := s.Get(ctx, runs[0].ID)
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	if r.Error == "" {
+		t.Error("expected run error")
+	}
+	for _, n := range r.Nodes {
+		if n.NodeUID == "out" && (n.Status != StatusFailed || n.Error == "") {
+			t.Errorf("expected failed node execution, got: %+v", n)
+		}
+	}
+}
+
+func TestRunStore_ObserveBuffered(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	s := MustRunStore(t, db)
+
+	ctx := context.Background()
+	g := MustRunGraph(t, &greetOp{Greeting: "hello"})
+	out := MustNodeByUID(t, g, "out")
+
+	executions := func() int {
+		var n int
+		if err := db.db.QueryRow(`SELECT COUNT(*) FROM node_executions WHERE run = 'r'`).Scan(&n); err != nil {
+			t.Fatalf("failed to count node executions: %v", err)
+		}
+		return n
+	}
+
+	events := []struct {
+		e    hypher.Event
+		want int
+	}{
+		{hypher.Event{Type: hypher.RunStarted, Graph: g}, 0},
+		{hypher.Event{Type: hypher.NodeScheduled, Graph: g, Node: out}, 0},
+		{hypher.Event{Type: hypher.NodeStarted, Graph: g, Node: out}, 0},
+		{hypher.Event{Type: hypher.NodeFinished, Graph: g, Node: out}, 1},
+		{hypher.Event{Type: hypher.NodeSkipped, Graph: g, Node: MustNodeByUID(t, g, "in")}, 1},
+		{hypher.Event{Type: hypher.RunFinished, Graph: g}, 2},
+	}
+	for _, ev := range events {
+		ev.e.RunID = "r"
+		ev.e.Time = time.Now()
+		s.Observe(ctx, ev.e)
+		if n := executions(); n != ev.want {
+			t.Errorf("%v: expected %d node executions, got: %d", ev.e.Type, ev.want, n)
+		}
+	}
+}
+
+func TestRunStore_ObserveConcAll(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	s := MustRunStore(t, db)
+
+	ctx := context.Background()
+	g := MustRunGraph(t, &greetOp{Greeting: "hello"})
+
+	if err := g.Run(ctx, nil, hypher.WithObserver(s), hypher.WithConcMode(hypher.ConcAllMode)); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+
+	runs, err := s.List(ctx, RunFilter{})
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected 1 run, got: %d (%v)", len(runs), err)
+	}
+	r, err := s.Get(ctx, runs[0].ID)
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	if r.Status != StatusSucceeded || len(r.Nodes) != 2 {
+		t.Errorf("unexpected run: %s with %d node executions", r.Status, len(r.Nodes))
+	}
+}
+
+func TestRunStore_List(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	s := MustRunStore(t, db)
+
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"r0", "r1", "r2", "r3"} {
+		r := &Run{
+			ID:       id,
+			GraphUID: "g1",
+			Status:   StatusSucceeded,
+			Start:    start.Add(time.Duration(i) * time.Millisecond),
+			End:      start.Add(time.Second),
+			Usage:    hypher.Usage{TokensIn: 10, Counters: map[string]float64{"calls": 1}},
+			Nodes: []*NodeExecution{
+				{NodeUID: "n", Status: StatusSucceeded, Attempts: 1, Outputs: []hypher.Value{{"i": i}}},
+			},
+		}
+		if i == 3 {
+			r.GraphUID = "g2"
+			r.Status = StatusFailed
+		}
+		if err := s.Save(ctx, r); err != nil {
+			t.Fatalf("failed to save run: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		filter RunFilter
+		want   []string
+	}{
+		{"all", RunFilter{}, []string{"r3", "r2", "r1", "r0"}},
+		{"graph", RunFilter{GraphUID: "g1"}, []string{"r2", "r1", "r0"}},
+		{"status", RunFilter{Status: StatusFailed}, []string{"r3"}},
+		{"since", RunFilter{Since: start.Add(2 * time.Millisecond)}, []string{"r3", "r2"}},
+		{"until", RunFilter{Until: start.Add(time.Millisecond)}, []string{"r0"}},
+		{"page", RunFilter{Limit: 2, Offset: 1}, []string{"r2", "r1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runs, err := s.List(ctx, tc.filter)
+			if err != nil {
+				t.Fatalf("failed to list runs: %v", err)
+			}
+			var ids []string
+			for _, r := range runs {
+				ids = append(ids, r.ID)
+			}
+			if len(ids) != len(tc.want) {
+				t.Fatalf("expected runs: %v, got: %v", tc.want, ids)
+			}
+			for i := range ids {
+				if ids[i] != tc.want[i] {
+					t.Fatalf("expected runs: %v, got: %v", tc.want, ids)
+				}
+			}
+		})
+	}
+
+	r, err := s.Get(ctx, "r1")
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	if r.Usage.TokensIn != 10 || r.Usage.Counters["calls"] != 1 {
+		t.Errorf("unexpected usage: %+v", r.Usage)
+	}
+	if !r.Start.Equal(start.Add(time.Millisecond)) {
+		t.Errorf("expected start: %v, got: %v", start.Add(time.Millisecond), r.Start)
+	}
+
+	if err := s.Delete(ctx, "r1"); err != nil {
+		t.Fatalf("failed to delete run: %v", err)
+	}
+	if _, err := s.Get(ctx, "r1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected %v, got: %v", sql.ErrNoRows, err)
+	}
+	if err := s.Delete(ctx, "r1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected %v, got: %v", sql.ErrNoRows, err)
+	}
+
+	var n int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM node_executions WHERE run = 'r1'`).Scan(&n); err != nil {
+		t.Fatalf("failed to count node executions: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected node executions to be deleted, got: %d", n)
+	}
+}
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_edges_graph_from_target;
+DROP INDEX IF EXISTS idx_edges_from_target;
+DROP INDEX IF EXISTS idx_edges_label;
+DROP INDEX IF EXISTS idx_edges_target;
+DROP INDEX IF EXISTS idx_edges_source;
+DROP INDEX IF EXISTS idx_nodes_graph_id;
+DROP INDEX IF EXISTS idx_nodes_graph_uid;
+DROP INDEX IF EXISTS idx_nodes_label;
+DROP INDEX IF EXISTS idx_graphs_label;
+
+DROP TABLE IF EXISTS edges;
+DROP TABLE IF EXISTS nodes;
+DROP TABLE IF EXISTS graphs;
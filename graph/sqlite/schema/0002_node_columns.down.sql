@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_nodes_graph_local_id;
+
+ALTER TABLE nodes DROP COLUMN is_output;
+ALTER TABLE nodes DROP COLUMN is_input;
+ALTER TABLE nodes DROP COLUMN local_id;
+ALTER TABLE nodes DROP COLUMN op_spec;
+ALTER TABLE nodes DROP COLUMN op_type;
+ALTER TABLE nodes DROP COLUMN dotid;
+
+ALTER TABLE graphs DROP COLUMN dotid;
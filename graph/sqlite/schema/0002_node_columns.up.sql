@@ -0,0 +1,18 @@
+-- Add graph DOT ID
+ALTER TABLE graphs ADD COLUMN dotid TEXT;
+
+-- Add node DOT ID and Op
+ALTER TABLE nodes ADD COLUMN dotid TEXT;
+ALTER TABLE nodes ADD COLUMN op_type TEXT;
+ALTER TABLE nodes ADD COLUMN op_spec TEXT;
+
+-- Add graph-local node ID
+-- Existing nodes are assigned their row IDs which are unique in every graph.
+ALTER TABLE nodes ADD COLUMN local_id INTEGER NOT NULL DEFAULT 0;
+UPDATE nodes SET local_id = id;
+
+-- Add graph input and output roles
+ALTER TABLE nodes ADD COLUMN is_input INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE nodes ADD COLUMN is_output INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_nodes_graph_local_id ON nodes (graph, local_id);
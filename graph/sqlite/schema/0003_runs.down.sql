@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_node_executions_node;
+DROP INDEX IF EXISTS idx_runs_status_started_at;
+DROP INDEX IF EXISTS idx_runs_graph_started_at;
+DROP INDEX IF EXISTS idx_runs_started_at;
+
+DROP TABLE IF EXISTS node_executions;
+DROP TABLE IF EXISTS runs;
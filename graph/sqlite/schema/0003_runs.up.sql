@@ -0,0 +1,47 @@
+-- Create runs table
+-- Runs are not linked to graphs: graphs need not be synced to be run.
+CREATE TABLE runs (
+    id TEXT PRIMARY KEY NOT NULL CHECK(id <> ''),
+    graph TEXT NOT NULL,
+    -- graph revision at the start of the run
+    graph_version INTEGER NOT NULL DEFAULT 0,
+    conc_mode TEXT,
+    inputs TEXT,
+    status TEXT NOT NULL,
+    error TEXT,
+    tokens_in INTEGER NOT NULL DEFAULT 0,
+    tokens_out INTEGER NOT NULL DEFAULT 0,
+    cost REAL NOT NULL DEFAULT 0,
+    counters TEXT,
+    started_at TEXT NOT NULL,
+    finished_at TEXT
+);
+
+-- Create node_executions table with a foreign key to runs
+CREATE TABLE node_executions (
+    run TEXT NOT NULL,
+    node TEXT NOT NULL,
+    label TEXT,
+    op_type TEXT,
+    lane INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL,
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    inputs TEXT,
+    outputs TEXT,
+    tokens_in INTEGER NOT NULL DEFAULT 0,
+    tokens_out INTEGER NOT NULL DEFAULT 0,
+    cost REAL NOT NULL DEFAULT 0,
+    counters TEXT,
+    scheduled_at TEXT,
+    started_at TEXT,
+    finished_at TEXT,
+    PRIMARY KEY (run, node),
+    FOREIGN KEY (run) REFERENCES runs (id) ON DELETE CASCADE
+);
+
+-- Indexes for efficient querying
+CREATE INDEX idx_runs_started_at ON runs (started_at);
+CREATE INDEX idx_runs_graph_started_at ON runs (graph, started_at);
+CREATE INDEX idx_runs_status_started_at ON runs (status, started_at);
+CREATE INDEX idx_node_executions_node ON node_executions (node);
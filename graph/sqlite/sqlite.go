@@ -5,19 +5,23 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
-	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"sort"
+	"strings"
 
 	// sqlite blank import
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/internal/logging"
 )
 
 const (
 	// MemoryDSN is the in-memory data source name.
 	MemoryDSN = "sqlite://:memory:"
 	// Migrations is the file path glob for migrations.
+	// Migration files are named <version>_<name>.<up|down>.sql.
 	Migrations = "schema/*.sql"
 	// Scehem is required sqlite scheme
 	Scheme = "sqlite"
@@ -28,21 +32,29 @@ const (
 //go:embed schema/*.sql
 var migrationFS embed.FS
 
+// DB is sqlite database.
 type DB struct {
 	db     *sql.DB
 	ctx    context.Context // background context
 	cancel func()          // cancel background context
+	logger *slog.Logger
+	ops    *graph.OpRegistry
 
 	// Datasource name.
 	DSN string
 }
 
 // NewDB returns a new instance of DB associated with the given datasource name.
-func NewDB(dsn string) (*DB, error) {
+func NewDB(dsn string, opts ...Option) (*DB, error) {
 	if dsn == "" {
 		return nil, fmt.Errorf("missing DSN")
 	}
 
+	dbOpts := Options{}
+	for _, apply := range opts {
+		apply(&dbOpts)
+	}
+
 	dsn, err := parseDSN(dsn)
 	if err != nil {
 		return nil, err
@@ -55,11 +67,18 @@ func NewDB(dsn string) (*DB, error) {
 	}
 
 	s := &DB{
-		DSN: dsn,
+		DSN:    dsn,
+		logger: logging.New(dbOpts.Logger, dbOpts.LogLevel),
+		ops:    dbOpts.OpRegistry,
 	}
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 
-	if s.db, err = sql.Open("sqlite3", dsn); err != nil {
+	// NOTE: foreign keys must be enabled on every pooled connection.
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	if s.db, err = sql.Open("sqlite3", dsn+sep+"_foreign_keys=1"); err != nil {
 		return nil, err
 	}
 
@@ -67,10 +86,6 @@ func NewDB(dsn string) (*DB, error) {
 		return nil, fmt.Errorf("enable wal: %w", err)
 	}
 
-	if _, err := s.db.Exec(`PRAGMA foreign_keys = ON;`); err != nil {
-		return nil, fmt.Errorf("foreign keys pragma: %w", err)
-	}
-
 	if err := s.migrate(); err != nil {
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
@@ -78,34 +93,6 @@ func NewDB(dsn string) (*DB, error) {
 	return s, nil
 }
 
-// migrate database with the attached migration files.
-func (s *DB) migrate() error {
-	names, err := fs.Glob(migrationFS, Migrations)
-	if err != nil {
-		return err
-	}
-	sort.Strings(names)
-
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
-	// nolint:errcheck
-	defer tx.Rollback()
-
-	for _, name := range names {
-		buf, err := fs.ReadFile(migrationFS, name)
-		if err != nil {
-			return err
-		}
-		if _, err := tx.Exec(string(buf)); err != nil {
-			return err
-		}
-	}
-
-	return tx.Commit()
-}
-
 // Close closes the database connection.
 func (s *DB) Close() error {
 	// Cancel background context.
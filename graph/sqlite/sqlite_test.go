@@ -1,15 +1,21 @@
 package sqlite
 
 import (
+	"bytes"
+	"context"
 	"flag"
+	"log/slog"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/milosgajdos/go-hypher/graph"
 )
 
 var dump = flag.Bool("dump", false, "save work data")
 
 // MustOpenDB returns a new, open DB. Fatal on error.
-func MustOpenDB(tb testing.TB) *DB {
+func MustOpenDB(tb testing.TB, opts ...Option) *DB {
 	tb.Helper()
 
 	// Write to an in-memory database by default.
@@ -21,7 +27,7 @@ func MustOpenDB(tb testing.TB) *DB {
 		println("DUMP=" + dsn)
 	}
 
-	db, err := NewDB(dsn)
+	db, err := NewDB(dsn, opts...)
 	if err != nil {
 		tb.Fatal(err)
 	}
@@ -35,3 +41,44 @@ func MustCloseDB(tb testing.TB, db *DB) {
 		tb.Fatal(err)
 	}
 }
+
+func TestDBLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	db := MustOpenDB(t, WithLogger(logger))
+	defer MustCloseDB(t, db)
+
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	if _, err := g.NewNode(); err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := MustSyncer(t, db).Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+	if _, err := MustLoader(t, db).Load(ctx, g.UID()); err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+	if _, err := MustLoader(t, db).Load(ctx, "missing"); err == nil {
+		t.Fatal("expected load error")
+	}
+
+	out := buf.String()
+	for _, msg := range []string{
+		"applying migration",
+		"database migrated",
+		"graph synced",
+		"graph loaded",
+		"graph load failed",
+		"nodes=1",
+	} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("expected %q in log output:\n%s", msg, out)
+		}
+	}
+}
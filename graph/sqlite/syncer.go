@@ -4,82 +4,384 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
 )
 
 // Syncer syncs graph to sqlite.
 type Syncer struct {
-	db *DB
+	db          *DB
+	incremental bool
+	// last synced graphs and their revisions indexed by graph UID
+	revs map[string]syncedRev
+	now  func() time.Time
+	mu   sync.Mutex
 }
 
 // NewSyncer creates a new sqlite syncer and returns it.
-func NewSyncer(db *DB) (*Syncer, error) {
+func NewSyncer(db *DB, opts ...SyncerOption) (*Syncer, error) {
+	sOpts := SyncerOptions{}
+	for _, apply := range opts {
+		apply(&sOpts)
+	}
+
 	return &Syncer{
-		db: db,
+		db:          db,
+		incremental: sOpts.Incremental,
+		revs:        make(map[string]syncedRev),
+		now:         time.Now,
 	}, nil
 }
 
+// syncedRev is the revision of a synced graph.
+type syncedRev struct {
+	g   *graph.Graph
+	rev uint64
+}
+
+// syncStats are sync statistics.
+type syncStats struct {
+	nodes        int
+	edges        int
+	removedNodes int
+	removedEdges int
+}
+
 // Sync sync graph g to sqlite DB.
+// Graph, node and edge rows are upserted: their creation
+// times are kept and their update times are bumped.
+// The rows of nodes and edges which no longer exist in g are deleted.
+// If the incremental sync is enabled and g is the same *graph.Graph
+// which was last synced with its UID, only the changes made since
+// the last sync are synced.
 func (s *Syncer) Sync(ctx context.Context, g hypher.Graph) error {
-	tx, err := s.db.db.BeginTx(ctx, nil)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+
+	var (
+		stats       syncStats
+		incremental bool
+		err         error
+	)
+
+	hg, ok := g.(*graph.Graph)
+	// other graphs with the same UID, such as loaded
+	// graphs or graph copies, have unrelated revisions.
+	last, synced := s.revs[g.UID()]
+	rev := last.rev
+	switch {
+	case s.incremental && ok && synced && last.g == hg:
+		incremental = true
+		stats, rev, err = s.syncChanges(ctx, hg, rev)
+	case ok:
+		rev = hg.Revision()
+		stats, err = s.sync(ctx, g)
+	default:
+		stats, err = s.sync(ctx, g)
+	}
 	if err != nil {
+		s.db.logger.ErrorContext(ctx, "graph sync failed",
+			slog.String("graph_uid", g.UID()),
+			slog.Any("error", err),
+		)
 		return err
 	}
+
+	if ok {
+		s.revs[g.UID()] = syncedRev{g: hg, rev: rev}
+	} else {
+		delete(s.revs, g.UID())
+	}
+
+	s.db.logger.InfoContext(ctx, "graph synced",
+		slog.String("graph_uid", g.UID()),
+		slog.Bool("incremental", incremental),
+		slog.Int("nodes", stats.nodes),
+		slog.Int("edges", stats.edges),
+		slog.Int("removed_nodes", stats.removedNodes),
+		slog.Int("removed_edges", stats.removedEdges),
+		slog.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
+
+// sync syncs graph g to sqlite DB and returns the sync statistics.
+func (s *Syncer) sync(ctx context.Context, g hypher.Graph) (syncStats, error) {
+	var stats syncStats
+
+	tx, err := s.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, err
+	}
 	// nolint:errcheck
 	defer tx.Rollback()
 
-	if err := s.syncGraph(ctx, tx, g); err != nil {
-		return err
+	now := s.now()
+
+	if err := s.syncGraph(ctx, tx, g, now); err != nil {
+		return stats, err
+	}
+	roles := graphRoles(g)
+
+	var (
+		nodes []hypher.Node
+		edges []hypher.Edge
+	)
+	nodeUIDs := make(map[string]bool)
+	edgeUIDs := make(map[string]bool)
+
+	nit := g.Nodes()
+	for nit.Next() {
+		if n, ok := nit.Node().(hypher.Node); ok {
+			nodes = append(nodes, n)
+			nodeUIDs[n.UID()] = true
+		}
+	}
+
+	eit := g.Edges()
+	for eit.Next() {
+		if e, ok := eit.Edge().(hypher.Edge); ok {
+			edges = append(edges, e)
+			edgeUIDs[e.UID()] = true
+		}
+	}
+
+	// edges must be deleted first as they reference nodes.
+	staleEdges, err := staleUIDs(ctx, tx, `SELECT uid FROM edges WHERE graph = ?`, g.UID(), edgeUIDs)
+	if err != nil {
+		return stats, err
+	}
+	for _, uid := range staleEdges {
+		if err := s.deleteEdge(ctx, tx, g.UID(), uid); err != nil {
+			return stats, err
+		}
+	}
+	stats.removedEdges = len(staleEdges)
+
+	staleNodes, err := staleUIDs(ctx, tx, `SELECT uid FROM nodes WHERE graph = ?`, g.UID(), nodeUIDs)
+	if err != nil {
+		return stats, err
+	}
+	for _, uid := range staleNodes {
+		if err := s.deleteNode(ctx, tx, g.UID(), uid); err != nil {
+			return stats, err
+		}
+	}
+	stats.removedNodes = len(staleNodes)
+
+	for _, n := range nodes {
+		if err := s.syncNode(ctx, tx, g.UID(), n, roles, now); err != nil {
+			return stats, err
+		}
+	}
+	stats.nodes = len(nodes)
+
+	for _, e := range edges {
+		if err := s.syncEdge(ctx, tx, g.UID(), e, now); err != nil {
+			return stats, err
+		}
+	}
+	stats.edges = len(edges)
+
+	if err := tx.Commit(); err != nil {
+		return syncStats{}, err
+	}
+
+	return stats, nil
+}
+
+// syncChanges syncs the changes of graph g made since revision rev
+// to sqlite DB. It returns the sync statistics and the synced graph revision.
+// Changed elements which are no longer in g are deleted.
+func (s *Syncer) syncChanges(ctx context.Context, g *graph.Graph, rev uint64) (syncStats, uint64, error) {
+	var stats syncStats
+
+	changes := g.ChangesSince(rev)
+	if changes.IsEmpty() {
+		return stats, changes.Revision, nil
+	}
+
+	tx, err := s.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, rev, err
+	}
+	// nolint:errcheck
+	defer tx.Rollback()
+
+	now := s.now()
+
+	// the graph row is always upserted to bump its update time.
+	if err := s.syncGraph(ctx, tx, g, now); err != nil {
+		return stats, rev, err
 	}
+	roles := graphRoles(g)
 
-	nodes := g.Nodes()
-	for nodes.Next() {
-		n, ok := nodes.Node().(hypher.Node)
+	nodes := make(map[string]hypher.Node)
+	nit := g.Nodes()
+	for nit.Next() {
+		if n, ok := nit.Node().(hypher.Node); ok {
+			nodes[n.UID()] = n
+		}
+	}
+
+	edges := make(map[string]hypher.Edge)
+	eit := g.Edges()
+	for eit.Next() {
+		if e, ok := eit.Edge().(hypher.Edge); ok {
+			edges[e.UID()] = e
+		}
+	}
+
+	removedEdges := changes.RemovedEdges
+	for _, uid := range changes.Edges {
+		if _, ok := edges[uid]; !ok {
+			removedEdges = append(removedEdges, uid)
+		}
+	}
+	for _, uid := range removedEdges {
+		if _, ok := edges[uid]; ok {
+			continue
+		}
+		if err := s.deleteEdge(ctx, tx, g.UID(), uid); err != nil {
+			return stats, rev, err
+		}
+		stats.removedEdges++
+	}
+
+	removedNodes := changes.RemovedNodes
+	for _, uid := range changes.Nodes {
+		if _, ok := nodes[uid]; !ok {
+			removedNodes = append(removedNodes, uid)
+		}
+	}
+	for _, uid := range removedNodes {
+		if _, ok := nodes[uid]; ok {
+			continue
+		}
+		if err := s.deleteNode(ctx, tx, g.UID(), uid); err != nil {
+			return stats, rev, err
+		}
+		stats.removedNodes++
+	}
+
+	for _, uid := range changes.Nodes {
+		n, ok := nodes[uid]
 		if !ok {
 			continue
 		}
-		if err := s.syncNode(ctx, tx, g.UID(), n); err != nil {
-			return err
+		if err := s.syncNode(ctx, tx, g.UID(), n, roles, now); err != nil {
+			return stats, rev, err
 		}
+		stats.nodes++
 	}
 
-	edges := g.Edges()
-	for edges.Next() {
-		e, ok := edges.Edge().(hypher.Edge)
+	for _, uid := range changes.Edges {
+		e, ok := edges[uid]
 		if !ok {
 			continue
 		}
-		if err := s.syncEdge(ctx, tx, g.UID(), e); err != nil {
-			return err
+		if err := s.syncEdge(ctx, tx, g.UID(), e, now); err != nil {
+			return stats, rev, err
+		}
+		stats.edges++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return syncStats{}, rev, err
+	}
+
+	return stats, changes.Revision, nil
+}
+
+// ioRoles are graph input and output node UIDs.
+type ioRoles struct {
+	inputs  map[string]bool
+	outputs map[string]bool
+}
+
+// graphRoles returns the input and output nodes of graph g.
+func graphRoles(g hypher.Graph) ioRoles {
+	roles := ioRoles{
+		inputs:  make(map[string]bool),
+		outputs: make(map[string]bool),
+	}
+	if hg, ok := g.(*graph.Graph); ok {
+		for _, n := range hg.Inputs() {
+			roles.inputs[n.UID()] = true
+		}
+		for _, n := range hg.Outputs() {
+			roles.outputs[n.UID()] = true
+		}
+	}
+	return roles
+}
+
+// staleUIDs returns the UIDs returned by query for graph graphUID which are not in uids.
+func staleUIDs(ctx context.Context, tx *sql.Tx, query, graphUID string, uids map[string]bool) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, graphUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stale []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		if !uids[uid] {
+			stale = append(stale, uid)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return tx.Commit()
+	return stale, nil
 }
 
-// syncGraph initializes the graph entry in the database.
-func (s *Syncer) syncGraph(ctx context.Context, tx *sql.Tx, g hypher.Graph) error {
+// syncGraph upserts the graph entry in the database.
+func (s *Syncer) syncGraph(ctx context.Context, tx *sql.Tx, g hypher.Graph, now time.Time) error {
 	attrs, err := json.Marshal(g.Attrs())
 	if err != nil {
 		return err
 	}
 
-	createdAt := time.Now()
-	updatedAt := createdAt
+	createdAt := now
+	updatedAt := now
+
+	var dotid sql.NullString
+	if d, ok := g.(interface{ DOTID() string }); ok {
+		dotid = sql.NullString{String: d.DOTID(), Valid: true}
+	}
 
 	if _, err := tx.ExecContext(ctx, `
 		INSERT INTO graphs (
 			uid,
+			dotid,
 			label,
 			attrs,
 			created_at,
 			updated_at
 		)
-		VALUES (?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (uid) DO UPDATE SET
+			dotid = excluded.dotid,
+			label = excluded.label,
+			attrs = excluded.attrs,
+			updated_at = excluded.updated_at
 	`,
 		g.UID(),
+		dotid,
 		g.Label(),
 		attrs,
 		(*NullTime)(&createdAt),
@@ -91,45 +393,91 @@ func (s *Syncer) syncGraph(ctx context.Context, tx *sql.Tx, g hypher.Graph) erro
 	return nil
 }
 
-// syncNode stores node in the sqlite DB.
-func (s *Syncer) syncNode(ctx context.Context, tx *sql.Tx, graphUID string, n hypher.Node) error {
-	createdAt := time.Now()
-	updatedAt := createdAt
+// syncNode upserts node in the sqlite DB.
+// The node Op type and spec are stored if the node has an Op.
+// The node graph ID, DOT ID and its graph I/O roles are stored, too.
+// It returns error if the node is stored in another graph.
+func (s *Syncer) syncNode(ctx context.Context, tx *sql.Tx, graphUID string, n hypher.Node, roles ioRoles, now time.Time) error {
+	createdAt := now
+	updatedAt := now
 
 	attrs, err := json.Marshal(n.Attrs())
 	if err != nil {
 		return err
 	}
 
-	// Execute insertion query.
-	if _, err := tx.ExecContext(ctx, `
+	var (
+		opType sql.NullString
+		opSpec sql.NullString
+	)
+	if o, ok := n.(interface{ Op() hypher.Op }); ok {
+		t, spec, err := graph.MarshalOp(o.Op())
+		if err != nil {
+			return fmt.Errorf("node %s: %w", n.UID(), err)
+		}
+		opType = sql.NullString{String: t, Valid: t != ""}
+		opSpec = sql.NullString{String: string(spec), Valid: spec != nil}
+	}
+
+	var dotid sql.NullString
+	if d, ok := n.(interface{ DOTID() string }); ok {
+		dotid = sql.NullString{String: d.DOTID(), Valid: true}
+	}
+
+	// Execute upsert query.
+	res, err := tx.ExecContext(ctx, `
 		INSERT INTO nodes (
 			uid,
 			graph,
+			local_id,
+			dotid,
 			label,
 			attrs,
+			op_type,
+			op_spec,
+			is_input,
+			is_output,
 			created_at,
 			updated_at
 		)
-		VALUES (?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (uid) DO UPDATE SET
+			local_id = excluded.local_id,
+			dotid = excluded.dotid,
+			label = excluded.label,
+			attrs = excluded.attrs,
+			op_type = excluded.op_type,
+			op_spec = excluded.op_spec,
+			is_input = excluded.is_input,
+			is_output = excluded.is_output,
+			updated_at = excluded.updated_at
+		WHERE nodes.graph = excluded.graph
 	`,
 		n.UID(),
 		graphUID,
+		n.ID(),
+		dotid,
 		n.Label(),
 		string(attrs),
+		opType,
+		opSpec,
+		roles.inputs[n.UID()],
+		roles.outputs[n.UID()],
 		(*NullTime)(&createdAt),
 		(*NullTime)(&updatedAt),
-	); err != nil {
+	)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return checkUpserted(res, "node", n.UID())
 }
 
-// syncEdge stores edge in the sqlite DB.
-func (s *Syncer) syncEdge(ctx context.Context, tx *sql.Tx, graphUID string, e hypher.Edge) error {
-	createdAt := time.Now()
-	updatedAt := createdAt
+// syncEdge upserts edge in the sqlite DB.
+// It returns error if the edge is stored in another graph.
+func (s *Syncer) syncEdge(ctx context.Context, tx *sql.Tx, graphUID string, e hypher.Edge, now time.Time) error {
+	createdAt := now
+	updatedAt := now
 
 	attrs, err := json.Marshal(e.Attrs())
 	if err != nil {
@@ -140,8 +488,8 @@ func (s *Syncer) syncEdge(ctx context.Context, tx *sql.Tx, graphUID string, e hy
 	sourceUID := e.From().(hypher.Node).UID()
 	targetUID := e.To().(hypher.Node).UID()
 
-	// Execute insertion query.
-	_, err = tx.ExecContext(ctx, `
+	// Execute upsert query.
+	res, err := tx.ExecContext(ctx, `
 		INSERT INTO edges (
 			uid,
 			graph,
@@ -153,7 +501,15 @@ func (s *Syncer) syncEdge(ctx context.Context, tx *sql.Tx, graphUID string, e hy
 			created_at,
 			updated_at
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (uid) DO UPDATE SET
+			source = excluded.source,
+			target = excluded.target,
+			label = excluded.label,
+			weight = excluded.weight,
+			attrs = excluded.attrs,
+			updated_at = excluded.updated_at
+		WHERE edges.graph = excluded.graph
 	`,
 		e.UID(),
 		graphUID,
@@ -169,5 +525,30 @@ func (s *Syncer) syncEdge(ctx context.Context, tx *sql.Tx, graphUID string, e hy
 		return err
 	}
 
+	return checkUpserted(res, "edge", e.UID())
+}
+
+// checkUpserted returns error if no row was upserted by res.
+func checkUpserted(res sql.Result, kind, uid string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %s belongs to another graph", kind, uid)
+	}
 	return nil
 }
+
+// deleteNode deletes node uid of graph graphUID from the sqlite DB.
+// The node edges are deleted, too.
+func (s *Syncer) deleteNode(ctx context.Context, tx *sql.Tx, graphUID, uid string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM nodes WHERE graph = ? AND uid = ?`, graphUID, uid)
+	return err
+}
+
+// deleteEdge deletes edge uid of graph graphUID from the sqlite DB.
+func (s *Syncer) deleteEdge(ctx context.Context, tx *sql.Tx, graphUID, uid string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM edges WHERE graph = ? AND uid = ?`, graphUID, uid)
+	return err
+}
@@ -184,3 +184,214 @@ func TestSyncer_Sync(t *testing.T) {
 		t.Errorf("expected edge attributes %v, got %v", edge.Attrs(), edgeAttributes)
 	}
 }
+
+// MustSyncGraph returns graph: n1 -> n2 -> n3.
+func MustSyncGraph(t *testing.T) *graph.Graph {
+	g, err := graph.NewGraph(hypher.WithLabel("sync"))
+	if err != nil {
+		t.Fatalf("failed to create new graph: %v", err)
+	}
+
+	nodes := make(map[string]*graph.Node)
+	for _, uid := range []string{"n1", "n2", "n3"} {
+		n, err := g.NewNode(hypher.WithUID(uid), hypher.WithLabel(uid))
+		if err != nil {
+			t.Fatalf("failed to create new node: %v", err)
+		}
+		nodes[uid] = n
+	}
+
+	for _, e := range [][2]string{{"n1", "n2"}, {"n2", "n3"}} {
+		if _, err := g.NewEdge(nodes[e[0]], nodes[e[1]], hypher.WithUID(e[0]+"-"+e[1])); err != nil {
+			t.Fatalf("failed to create new edge: %v", err)
+		}
+	}
+
+	return g
+}
+
+func count(t *testing.T, db *DB, table, graphUID string) int {
+	var n int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE graph = ?`, graphUID).Scan(&n); err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+func label(t *testing.T, db *DB, table, uid string) string {
+	var l string
+	if err := db.db.QueryRow(`SELECT label FROM `+table+` WHERE uid = ?`, uid).Scan(&l); err != nil {
+		t.Fatalf("failed to query %s label: %v", table, err)
+	}
+	return l
+}
+
+func TestSyncer_SyncUpsert(t *testing.T) {
+	db := MustOpenDB(t)
+	defer db.Close()
+	s := MustSyncer(t, db)
+
+	ctx := context.Background()
+	g := MustSyncGraph(t)
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return created }
+	if err := s.Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	g.SetLabel("resynced")
+	g.RemoveNode(MustNodeByUID(t, g, "n3").ID())
+
+	updated := created.Add(time.Hour)
+	s.now = func() time.Time { return updated }
+	if err := s.Sync(ctx, g); err != nil {
+		t.Fatalf("failed to resync graph: %v", err)
+	}
+
+	if n := count(t, db, "nodes", g.UID()); n != 2 {
+		t.Errorf("expected 2 nodes, got: %d", n)
+	}
+	if n := count(t, db, "edges", g.UID()); n != 1 {
+		t.Errorf("expected 1 edge, got: %d", n)
+	}
+	if l := label(t, db, "graphs", g.UID()); l != "resynced" {
+		t.Errorf("expected graph label: resynced, got: %s", l)
+	}
+
+	for _, table := range []string{"graphs", "nodes", "edges"} {
+		var createdAt, updatedAt time.Time
+		if err := db.db.QueryRow(`SELECT created_at, updated_at FROM `+table+` LIMIT 1`).
+			Scan((*NullTime)(&createdAt), (*NullTime)(&updatedAt)); err != nil {
+			t.Fatalf("failed to query %s: %v", table, err)
+		}
+		if !createdAt.Equal(created) {
+			t.Errorf("expected %s created_at: %v, got: %v", table, created, createdAt)
+		}
+		if !updatedAt.Equal(updated) {
+			t.Errorf("expected %s updated_at: %v, got: %v", table, updated, updatedAt)
+		}
+	}
+}
+
+func TestSyncer_SyncForeignNode(t *testing.T) {
+	db := MustOpenDB(t)
+	defer db.Close()
+	s := MustSyncer(t, db)
+
+	ctx := context.Background()
+	if err := s.Sync(ctx, MustSyncGraph(t)); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	// node UIDs are unique across graphs
+	if err := s.Sync(ctx, MustSyncGraph(t)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSyncer_SyncIncremental(t *testing.T) {
+	db := MustOpenDB(t)
+	defer db.Close()
+	s, err := NewSyncer(db, WithIncremental(true))
+	if err != nil {
+		t.Fatalf("failed to create syncer: %v", err)
+	}
+
+	ctx := context.Background()
+	g := MustSyncGraph(t)
+
+	if err := s.Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	// rows of unchanged nodes must not be synced again
+	if _, err := db.db.Exec(`UPDATE nodes SET label = 'stale' WHERE uid = 'n1'`); err != nil {
+		t.Fatalf("failed to update node: %v", err)
+	}
+
+	MustNodeByUID(t, g, "n2").SetLabel("changed")
+	g.RemoveEdge(MustNodeByUID(t, g, "n2").ID(), MustNodeByUID(t, g, "n3").ID())
+
+	if err := s.Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	if l := label(t, db, "nodes", "n1"); l != "stale" {
+		t.Errorf("expected unchanged node label: stale, got: %s", l)
+	}
+	if l := label(t, db, "nodes", "n2"); l != "changed" {
+		t.Errorf("expected changed node label: changed, got: %s", l)
+	}
+	if n := count(t, db, "edges", g.UID()); n != 1 {
+		t.Errorf("expected 1 edge, got: %d", n)
+	}
+
+	g.RemoveNode(MustNodeByUID(t, g, "n1").ID())
+
+	if err := s.Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+	if n := count(t, db, "nodes", g.UID()); n != 2 {
+		t.Errorf("expected 2 nodes, got: %d", n)
+	}
+	if n := count(t, db, "edges", g.UID()); n != 0 {
+		t.Errorf("expected no edges, got: %d", n)
+	}
+}
+
+func TestSyncer_SyncIncrementalLoaded(t *testing.T) {
+	db := MustOpenDB(t)
+	defer db.Close()
+	s, err := NewSyncer(db, WithIncremental(true))
+	if err != nil {
+		t.Fatalf("failed to create syncer: %v", err)
+	}
+
+	ctx := context.Background()
+	g := MustSyncGraph(t)
+
+	// make the synced graph revision higher than the loaded graph revision
+	for i := 0; i < 10; i++ {
+		g.SetLabel("sync")
+	}
+	if err := s.Sync(ctx, g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	lg, err := MustLoader(t, db).Load(ctx, g.UID())
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+	if lg.Revision() >= g.Revision() {
+		t.Fatalf("expected loaded graph revision below %d, got: %d", g.Revision(), lg.Revision())
+	}
+
+	MustNodeByUID(t, lg, "n2").SetLabel("loaded")
+	lg.RemoveNode(MustNodeByUID(t, lg, "n3").ID())
+
+	if err := s.Sync(ctx, lg); err != nil {
+		t.Fatalf("failed to sync loaded graph: %v", err)
+	}
+
+	if l := label(t, db, "nodes", "n2"); l != "loaded" {
+		t.Errorf("expected node label: loaded, got: %s", l)
+	}
+	if n := count(t, db, "nodes", g.UID()); n != 2 {
+		t.Errorf("expected 2 nodes, got: %d", n)
+	}
+	if n := count(t, db, "edges", g.UID()); n != 1 {
+		t.Errorf("expected 1 edge, got: %d", n)
+	}
+}
+
+func MustNodeByUID(t *testing.T, g *graph.Graph, uid string) *graph.Node {
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if n := nodes.Node().(*graph.Node); n.UID() == uid {
+			return n
+		}
+	}
+	t.Fatalf("node %s not found", uid)
+	return nil
+}
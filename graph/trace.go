@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+const (
+	// RunSpanName is the name of the graph run span.
+	RunSpanName = "hypher.Run"
+	// ExecSpanName is the name of the node exec span.
+	ExecSpanName = "hypher.Exec"
+)
+
+// Span attribute keys.
+const (
+	// AttrGraphUID is graph UID span attribute key.
+	AttrGraphUID = "hypher.graph.uid"
+	// AttrGraphLabel is graph label span attribute key.
+	AttrGraphLabel = "hypher.graph.label"
+	// AttrConcMode is run concurrency mode span attribute key.
+	AttrConcMode = "hypher.run.conc_mode"
+	// AttrNodeUID is node UID span attribute key.
+	AttrNodeUID = "hypher.node.uid"
+	// AttrNodeLabel is node label span attribute key.
+	AttrNodeLabel = "hypher.node.label"
+	// AttrOpType is node Op type span attribute key.
+	AttrOpType = "hypher.op.type"
+	// AttrInputs is node input count span attribute key.
+	AttrInputs = "hypher.node.inputs"
+	// AttrOutputs is node output count span attribute key.
+	AttrOutputs = "hypher.node.outputs"
+)
+
+// noopSpan is a span which does nothing.
+type noopSpan struct{}
+
+func (noopSpan) SetAttrs(map[string]any) {}
+func (noopSpan) RecordError(error)       {}
+func (noopSpan) End()                    {}
+
+// noopTracer is used when no tracer is configured.
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...hypher.Span) (context.Context, hypher.Span) {
+	return ctx, noopSpan{}
+}
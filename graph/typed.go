@@ -0,0 +1,413 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+// ValueTag is the struct tag used to map struct fields to Value keys.
+// If a field has no ValueTag, its json tag name is used.
+// If neither is present, the field name is used.
+// The required tag option makes the Value key mandatory, e.g. `hypher:"city,required"`.
+const ValueTag = "hypher"
+
+// ConversionError is returned when a Value can not be converted
+// to a Go type or when a Go type can not be converted to a Value.
+type ConversionError struct {
+	// Node is the UID of the node which ran the Op.
+	// It is set by Node.Exec which also includes it in its error message.
+	Node string
+	// Op is the Op type.
+	Op string
+	// Field is the path of the field which failed to convert.
+	Field string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements error.
+func (e *ConversionError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "op %s: ", e.Op)
+	if e.Field != "" {
+		fmt.Fprintf(&b, "field %s: ", e.Field)
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// TypedOp is a hypher.Op which runs a function with typed input and output.
+// The input Values are decoded into In and the returned Out is encoded into
+// a Value. In and Out must be either structs or maps with string keys.
+// See ValueTag for how struct fields are mapped to Value keys.
+type TypedOp[In, Out any] struct {
+	name string
+	desc string
+	fn   func(context.Context, In) (Out, error)
+}
+
+// NewTypedOp creates a new TypedOp which runs fn and returns it.
+func NewTypedOp[In, Out any](name, desc string, fn func(ctx context.Context, in In) (Out, error)) (*TypedOp[In, Out], error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing op name")
+	}
+	if fn == nil {
+		return nil, fmt.Errorf("missing op func")
+	}
+
+	for _, t := range []reflect.Type{reflect.TypeFor[In](), reflect.TypeFor[Out]()} {
+		if !isRecordType(t) {
+			return nil, fmt.Errorf("unsupported type: %s", t)
+		}
+	}
+
+	return &TypedOp[In, Out]{
+		name: name,
+		desc: desc,
+		fn:   fn,
+	}, nil
+}
+
+// Type returns Op type.
+func (op *TypedOp[In, Out]) Type() string { return op.name }
+
+// Desc returns Op description.
+func (op *TypedOp[In, Out]) Desc() string { return op.desc }
+
+// String implements fmt.Stringer.
+func (op *TypedOp[In, Out]) String() string {
+	return fmt.Sprintf("Op: %s, Desc: %s", op.Type(), op.Desc())
+}
+
+// Do merges inputs into a single Value and decodes it into In.
+// The inputs are merged in order so latter inputs override former ones.
+// It then runs the Op function and returns its result encoded as a single Value.
+// Conversion failures are returned as *ConversionError.
+func (op *TypedOp[In, Out]) Do(ctx context.Context, inputs ...hypher.Value) ([]hypher.Value, error) {
+	merged := make(map[string]any)
+	for _, in := range inputs {
+		for k, v := range in {
+			merged[k] = v
+		}
+	}
+
+	var in In
+	if err := decodeValue(reflect.ValueOf(&in).Elem(), merged, ""); err != nil {
+		return nil, op.convErr(err)
+	}
+
+	out, err := op.fn(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := encodeValue(reflect.ValueOf(out))
+	if err != nil {
+		return nil, op.convErr(err)
+	}
+
+	return []hypher.Value{v}, nil
+}
+
+func (op *TypedOp[In, Out]) convErr(err error) error {
+	cerr := &ConversionError{Op: op.name, Err: err}
+	if ferr, ok := err.(*fieldError); ok {
+		cerr.Field, cerr.Err = ferr.field, ferr.err
+	}
+	return cerr
+}
+
+// fieldError is a conversion error of a field.
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return fmt.Sprintf("field %s: %v", e.field, e.err)
+}
+
+// isRecordType returns true if t is a struct or a map with string keys.
+func isRecordType(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct ||
+		(t.Kind() == reflect.Map && t.Key().Kind() == reflect.String)
+}
+
+// fieldName returns the Value key of the struct field f
+// and reports whether it is required and whether it is skipped.
+func fieldName(f reflect.StructField) (name string, required bool, skip bool) {
+	name = f.Name
+
+	tag, ok := f.Tag.Lookup(ValueTag)
+	if !ok {
+		tag, ok = f.Tag.Lookup("json")
+	}
+	if !ok {
+		return name, false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+
+	n, opts, _ := strings.Cut(tag, ",")
+	if n != "" {
+		name = n
+	}
+	for _, o := range strings.Split(opts, ",") {
+		if o == "required" {
+			required = true
+		}
+	}
+
+	return name, required, false
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// decodeValue decodes v into dst which is either a struct or a map.
+func decodeValue(dst reflect.Value, v map[string]any, path string) error {
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decodeValue(dst.Elem(), v, path)
+	}
+
+	if dst.Kind() == reflect.Map {
+		return assign(dst, v, path)
+	}
+
+	for _, f := range reflect.VisibleFields(dst.Type()) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+
+		name, required, skip := fieldName(f)
+		if skip {
+			continue
+		}
+
+		fpath := joinPath(path, name)
+
+		val, ok := v[name]
+		if !ok {
+			if required {
+				return &fieldError{field: fpath, err: fmt.Errorf("missing required value")}
+			}
+			continue
+		}
+
+		fv, err := dst.FieldByIndexErr(f.Index)
+		if err != nil {
+			return &fieldError{field: fpath, err: err}
+		}
+		if err := assign(fv, val, fpath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assign assigns src to dst converting it if necessary.
+func assign(dst reflect.Value, src any, path string) error {
+	if src == nil {
+		dst.SetZero()
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+
+	convErr := func(reason string) error {
+		err := fmt.Errorf("cannot convert %T to %s", src, dst.Type())
+		if reason != "" {
+			err = fmt.Errorf("%w: %s", err, reason)
+		}
+		return &fieldError{field: path, err: err}
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(dst.Type().Elem())
+		if err := assign(elem.Elem(), src, path); err != nil {
+			return err
+		}
+		dst.Set(elem)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch {
+		case sv.CanInt():
+			i = sv.Int()
+		case sv.CanUint():
+			if sv.Uint() > math.MaxInt64 {
+				return convErr("overflow")
+			}
+			i = int64(sv.Uint())
+		case sv.CanFloat():
+			f := sv.Float()
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return convErr("not an integer")
+			}
+			i = int64(f)
+		default:
+			return convErr("")
+		}
+		if dst.OverflowInt(i) {
+			return convErr("overflow")
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		switch {
+		case sv.CanUint():
+			u = sv.Uint()
+		case sv.CanInt():
+			if sv.Int() < 0 {
+				return convErr("negative value")
+			}
+			u = uint64(sv.Int())
+		case sv.CanFloat():
+			f := sv.Float()
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return convErr("not an unsigned integer")
+			}
+			u = uint64(f)
+		default:
+			return convErr("")
+		}
+		if dst.OverflowUint(u) {
+			return convErr("overflow")
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		switch {
+		case sv.CanFloat():
+			dst.SetFloat(sv.Float())
+		case sv.CanInt():
+			dst.SetFloat(float64(sv.Int()))
+		case sv.CanUint():
+			dst.SetFloat(float64(sv.Uint()))
+		default:
+			return convErr("")
+		}
+	case reflect.String:
+		if sv.Kind() != reflect.String {
+			return convErr("")
+		}
+		dst.SetString(sv.String())
+	case reflect.Bool:
+		if sv.Kind() != reflect.Bool {
+			return convErr("")
+		}
+		dst.SetBool(sv.Bool())
+	case reflect.Slice:
+		if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+			return convErr("")
+		}
+		s := reflect.MakeSlice(dst.Type(), sv.Len(), sv.Len())
+		for i := 0; i < sv.Len(); i++ {
+			if err := assign(s.Index(i), sv.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.Map:
+		if sv.Kind() != reflect.Map || sv.Type().Key().Kind() != reflect.String || dst.Type().Key().Kind() != reflect.String {
+			return convErr("")
+		}
+		m := reflect.MakeMapWithSize(dst.Type(), sv.Len())
+		iter := sv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(elem, iter.Value().Interface(), joinPath(path, key)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+		}
+		dst.Set(m)
+	case reflect.Struct:
+		m, ok := toStringMap(src)
+		if !ok {
+			return convErr("")
+		}
+		return decodeValue(dst, m, path)
+	default:
+		return convErr("")
+	}
+
+	return nil
+}
+
+// toStringMap returns src as map[string]any if possible.
+func toStringMap(src any) (map[string]any, bool) {
+	switch m := src.(type) {
+	case map[string]any:
+		return m, true
+	case hypher.Value:
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+// encodeValue encodes v which is either a struct or a map into Value.
+func encodeValue(v reflect.Value) (hypher.Value, error) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return hypher.Value{}, nil
+		}
+		v = v.Elem()
+	}
+
+	out := make(hypher.Value)
+
+	switch v.Kind() {
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = iter.Value().Interface()
+		}
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(v.Type()) {
+			if !f.IsExported() || f.Anonymous {
+				continue
+			}
+			name, _, skip := fieldName(f)
+			if skip {
+				continue
+			}
+			fv, err := v.FieldByIndexErr(f.Index)
+			if err != nil {
+				continue
+			}
+			out[name] = fv.Interface()
+		}
+	default:
+		return nil, fmt.Errorf("cannot convert %s to Value", v.Type())
+	}
+
+	return out, nil
+}
@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+type forecastIn struct {
+	City    string                `hypher:"city,required"`
+	Days    int                   `json:"days"`
+	Scale   *float64              `hypher:"scale"`
+	Tags    []string              `hypher:"tags"`
+	Limits  map[string]uint8      `hypher:"limits"`
+	Geo     struct{ Lat float64 } `hypher:"geo"`
+	Ignored string                `hypher:"-"`
+}
+
+type forecastOut struct {
+	Summary string `hypher:"summary"`
+	Days    int    `json:"days"`
+	Secret  string `hypher:"-"`
+}
+
+func forecast(_ context.Context, in forecastIn) (forecastOut, error) {
+	return forecastOut{Summary: in.City, Days: in.Days, Secret: "x"}, nil
+}
+
+func TestNewTypedOp(t *testing.T) {
+	if _, err := NewTypedOp[forecastIn, forecastOut]("", "", forecast); err == nil {
+		t.Error("expected missing name error")
+	}
+	if _, err := NewTypedOp[forecastIn, forecastOut]("Forecast", "", nil); err == nil {
+		t.Error("expected missing func error")
+	}
+	if _, err := NewTypedOp("Invalid", "", func(context.Context, int) (forecastOut, error) {
+		return forecastOut{}, nil
+	}); err == nil {
+		t.Error("expected unsupported type error")
+	}
+}
+
+func TestTypedOpDo(t *testing.T) {
+	op, err := NewTypedOp("Forecast", "Weather forecast", forecast)
+	if err != nil {
+		t.Fatalf("failed to create op: %v", err)
+	}
+
+	var in forecastIn
+	op2, err := NewTypedOp("Capture", "", func(_ context.Context, i forecastIn) (map[string]any, error) {
+		in = i
+		return map[string]any{"ok": true}, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create op: %v", err)
+	}
+
+	inputs := []hypher.Value{
+		{"city": "Rome", "days": 1},
+		{
+			"city":   "Oslo",
+			"days":   float64(3),
+			"scale":  2,
+			"tags":   []any{"a", "b"},
+			"limits": map[string]any{"max": 10},
+			"geo":    hypher.Value{"Lat": 59.9},
+		},
+	}
+
+	outputs, err := op.Do(context.Background(), inputs...)
+	if err != nil {
+		t.Fatalf("failed to run op: %v", err)
+	}
+	exp := []hypher.Value{{"summary": "Oslo", "days": 3}}
+	if !reflect.DeepEqual(outputs, exp) {
+		t.Errorf("expected outputs: %v, got: %v", exp, outputs)
+	}
+
+	outputs, err = op2.Do(context.Background(), inputs...)
+	if err != nil {
+		t.Fatalf("failed to run op: %v", err)
+	}
+	if !reflect.DeepEqual(outputs, []hypher.Value{{"ok": true}}) {
+		t.Errorf("unexpected outputs: %v", outputs)
+	}
+
+	scale := 2.0
+	expIn := forecastIn{
+		City:   "Oslo",
+		Days:   3,
+		Scale:  &scale,
+		Tags:   []string{"a", "b"},
+		Limits: map[string]uint8{"max": 10},
+	}
+	expIn.Geo.Lat = 59.9
+	if !reflect.DeepEqual(in, expIn) {
+		t.Errorf("expected input: %#v, got: %#v", expIn, in)
+	}
+}
+
+func TestTypedOpConversionError(t *testing.T) {
+	op, err := NewTypedOp("Forecast", "Weather forecast", forecast)
+	if err != nil {
+		t.Fatalf("failed to create op: %v", err)
+	}
+
+	testCases := []struct {
+		name  string
+		input hypher.Value
+		field string
+	}{
+		{"Missing", hypher.Value{}, "city"},
+		{"String", hypher.Value{"city": 1}, "city"},
+		{"Fraction", hypher.Value{"city": "Oslo", "days": 1.5}, "days"},
+		{"Item", hypher.Value{"city": "Oslo", "tags": []any{"a", 1}}, "tags[1]"},
+		{"Overflow", hypher.Value{"city": "Oslo", "limits": map[string]any{"max": 1000}}, "limits.max"},
+		{"Nested", hypher.Value{"city": "Oslo", "geo": hypher.Value{"Lat": "north"}}, "geo.Lat"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := op.Do(context.Background(), tc.input)
+			var cerr *ConversionError
+			if !errors.As(err, &cerr) {
+				t.Fatalf("expected conversion error, got: %v", err)
+			}
+			if cerr.Op != "Forecast" || cerr.Field != tc.field {
+				t.Errorf("expected op Forecast field %s, got: %s %s", tc.field, cerr.Op, cerr.Field)
+			}
+		})
+	}
+}
+
+func TestAssignFloatBounds(t *testing.T) {
+	testCases := []struct {
+		name  string
+		dst   any
+		src   float64
+		valid bool
+	}{
+		{"Int64Min", new(int64), math.MinInt64, true},
+		{"Int64BelowPow63", new(int64), math.Nextafter(1<<63, 0), true},
+		{"Int64Pow63", new(int64), 1 << 63, false},
+		{"Int64MaxRounded", new(int64), float64(math.MaxInt64), false},
+		{"Uint64BelowPow64", new(uint64), math.Nextafter(1<<64, 0), true},
+		{"Uint64Pow64", new(uint64), 1 << 64, false},
+		{"Uint64MaxRounded", new(uint64), float64(math.MaxUint64), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := assign(reflect.ValueOf(tc.dst).Elem(), tc.src, "n")
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("expected error, got: %v", reflect.ValueOf(tc.dst).Elem())
+			}
+		})
+	}
+}
+
+func TestTypedOpNodeExec(t *testing.T) {
+	op, err := NewTypedOp("Forecast", "Weather forecast", forecast)
+	if err != nil {
+		t.Fatalf("failed to create op: %v", err)
+	}
+
+	n := MustNode(t, hypher.WithUID("forecast"), hypher.WithOp(op))
+
+	_, err = n.Exec(context.Background(), hypher.Value{"city": true})
+	var cerr *ConversionError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expected conversion error, got: %v", err)
+	}
+	if cerr.Node != "forecast" {
+		t.Errorf("expected node: forecast, got: %s", cerr.Node)
+	}
+}
@@ -17,6 +17,7 @@ package hypher
 
 import (
 	"context"
+	"time"
 
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/encoding"
@@ -200,6 +201,38 @@ type Execer interface {
 	Exec(ctx context.Context, inputs ...Value) ([]Value, error)
 }
 
+// Span is a traced unit of work.
+type Span interface {
+	// SetAttrs sets span attributes.
+	SetAttrs(attrs map[string]any)
+	// RecordError records err and marks the span as failed.
+	RecordError(err error)
+	// End ends the span.
+	End()
+}
+
+// Tracer traces hypher runs.
+type Tracer interface {
+	// Start starts a new span with the given name linked to links.
+	// The returned context carries the new span and is
+	// propagated to all the operations done within the span.
+	Start(ctx context.Context, name string, links ...Span) (context.Context, Span)
+}
+
+// Metrics records hypher run metrics.
+// Nodes are neither retried nor cached, so
+// no retry or cache hit metrics are recorded.
+type Metrics interface {
+	// RunStarted records a started Run.
+	RunStarted()
+	// RunFinished records a finished Run, its duration and error.
+	RunFinished(d time.Duration, err error)
+	// NodeExec records node execution latency and error by Op type.
+	NodeExec(opType string, d time.Duration, err error)
+	// NodeQueueWait records the time a scheduled node waited for its execution.
+	NodeQueueWait(opType string, d time.Duration)
+}
+
 // Op is an operation run by a Node.
 type Op interface {
 	// Type of the Op.
@@ -211,3 +244,14 @@ type Op interface {
 	// String is useful for debugging.
 	String() string
 }
+
+// OpSpec is implemented by Ops whose config can be serialized.
+// Ops which implement it can be persisted and restored via OpFactory.
+type OpSpec interface {
+	// Spec returns JSON encoded Op config.
+	Spec() ([]byte, error)
+}
+
+// OpFactory creates an Op from its JSON encoded config.
+// The spec is nil for Ops which do not implement OpSpec.
+type OpFactory func(spec []byte) (Op, error)
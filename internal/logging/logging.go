@@ -0,0 +1,94 @@
+// Package logging provides log/slog helpers.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+const (
+	// MaxPayloadSize is the maximum size of a logged payload in bytes.
+	// Larger payloads are redacted.
+	MaxPayloadSize = 256
+)
+
+// discardHandler discards all log records.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// Discard returns a logger which discards all log records.
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+// levelHandler only handles records with the minimum level.
+type levelHandler struct {
+	level   slog.Leveler
+	handler slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithGroup(name)}
+}
+
+// New returns a logger which logs to l with the minimum level.
+// If l is nil and level is nil it returns a logger which discards all records.
+// If l is nil and level is not nil it logs to slog.Default().
+// If level is nil it returns l.
+func New(l *slog.Logger, level slog.Leveler) *slog.Logger {
+	if l == nil {
+		if level == nil {
+			return Discard()
+		}
+		l = slog.Default()
+	}
+
+	if level == nil {
+		return l
+	}
+
+	return slog.New(&levelHandler{level: level, handler: l.Handler()})
+}
+
+// Payload is a log payload which is redacted
+// if its JSON encoding exceeds MaxPayloadSize.
+type Payload struct {
+	v any
+}
+
+// NewPayload returns log payload for v.
+func NewPayload(v any) Payload {
+	return Payload{v: v}
+}
+
+// LogValue implements slog.LogValuer.
+func (p Payload) LogValue() slog.Value {
+	b, err := json.Marshal(p.v)
+	if err != nil {
+		return slog.StringValue(fmt.Sprintf("<unencodable: %v>", err))
+	}
+
+	if len(b) > MaxPayloadSize {
+		return slog.StringValue(fmt.Sprintf("<redacted: %d bytes>", len(b)))
+	}
+
+	return slog.StringValue(string(b))
+}
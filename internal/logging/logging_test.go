@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	logger := New(l, slog.LevelWarn)
+	logger.Info("info")
+	logger.Warn("warn")
+
+	if out := buf.String(); strings.Contains(out, "info") || !strings.Contains(out, "warn") {
+		t.Errorf("unexpected log output: %s", out)
+	}
+
+	if New(l, nil) != l {
+		t.Error("expected the same logger")
+	}
+
+	if New(nil, nil).Enabled(context.Background(), slog.LevelError) {
+		t.Error("expected discard logger")
+	}
+}
+
+func TestPayload(t *testing.T) {
+	small := NewPayload(map[string]any{"foo": "bar"}).LogValue().String()
+	if small != `{"foo":"bar"}` {
+		t.Errorf("unexpected payload: %s", small)
+	}
+
+	large := NewPayload(strings.Repeat("x", MaxPayloadSize)).LogValue().String()
+	if !strings.HasPrefix(large, "<redacted:") {
+		t.Errorf("expected redacted payload, got: %s", large)
+	}
+}
@@ -0,0 +1,344 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/tool"
+)
+
+// Value keys used by ChatOp.
+const (
+	// RoleKey is the message role key.
+	RoleKey = "role"
+	// ContentKey is the message content key.
+	ContentKey = "content"
+	// TextKey is an alias of ContentKey for user messages.
+	TextKey = "text"
+	// PromptKey is an alias of ContentKey for user messages.
+	PromptKey = "prompt"
+	// MessagesKey is the key of a list of messages.
+	MessagesKey = "messages"
+	// ModelKey is the model name key.
+	ModelKey = "model"
+	// FinishReasonKey is the finish reason key.
+	FinishReasonKey = "finish_reason"
+	// ToolCallsKey is the key of tool calls requested by the model.
+	ToolCallsKey = tool.CallsKey
+	// ToolCallIDKey is the key of the tool call ID of tool messages.
+	ToolCallIDKey = tool.CallIDKey
+)
+
+// ChatOp is a hypher.Op which runs chat completions.
+type ChatOp struct {
+	provider    Provider
+	model       string
+	system      string
+	temperature *float64
+	maxTokens   int
+	pricing     Pricing
+	tools       *tool.Registry
+	toolRounds  int
+}
+
+// NewChatOp creates a new ChatOp which uses provider p and returns it.
+func NewChatOp(p Provider, opts ...Option) (*ChatOp, error) {
+	if p == nil {
+		return nil, fmt.Errorf("invalid provider: %v", p)
+	}
+
+	copts := Options{}
+	for _, apply := range opts {
+		apply(&copts)
+	}
+
+	return &ChatOp{
+		provider:    p,
+		model:       copts.Model,
+		system:      copts.System,
+		temperature: copts.Temperature,
+		maxTokens:   copts.MaxTokens,
+		pricing:     copts.Pricing,
+		tools:       copts.Tools,
+		toolRounds:  copts.MaxToolRounds,
+	}, nil
+}
+
+// chatSpec is ChatOp spec.
+type chatSpec struct {
+	Model         string   `json:"model,omitempty"`
+	System        string   `json:"system,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	MaxTokens     int      `json:"max_tokens,omitempty"`
+	Pricing       *Pricing `json:"pricing,omitempty"`
+	MaxToolRounds int      `json:"max_tool_rounds,omitempty"`
+}
+
+// Spec returns JSON encoded ChatOp config.
+// The provider and the tools are not part of the spec.
+// It implements hypher.OpSpec.
+func (op *ChatOp) Spec() ([]byte, error) {
+	s := chatSpec{
+		Model:         op.model,
+		System:        op.system,
+		Temperature:   op.temperature,
+		MaxTokens:     op.maxTokens,
+		MaxToolRounds: op.toolRounds,
+	}
+	if op.pricing != (Pricing{}) {
+		s.Pricing = &op.pricing
+	}
+	return json.Marshal(s)
+}
+
+// ChatOpFactory returns hypher.OpFactory which creates ChatOp
+// from its spec. The created ChatOp uses provider p and it is
+// configured with opts which are overridden by the spec.
+func ChatOpFactory(p Provider, opts ...Option) hypher.OpFactory {
+	return func(spec []byte) (hypher.Op, error) {
+		var s chatSpec
+		if len(spec) > 0 {
+			if err := json.Unmarshal(spec, &s); err != nil {
+				return nil, fmt.Errorf("invalid spec: %w", err)
+			}
+		}
+
+		copts := append([]Option{}, opts...)
+		if s.Model != "" {
+			copts = append(copts, WithModel(s.Model))
+		}
+		if s.System != "" {
+			copts = append(copts, WithSystem(s.System))
+		}
+		if s.Temperature != nil {
+			copts = append(copts, WithTemperature(*s.Temperature))
+		}
+		if s.MaxTokens != 0 {
+			copts = append(copts, WithMaxTokens(s.MaxTokens))
+		}
+		if s.Pricing != nil {
+			copts = append(copts, WithPricing(*s.Pricing))
+		}
+		if s.MaxToolRounds != 0 {
+			copts = append(copts, WithMaxToolRounds(s.MaxToolRounds))
+		}
+
+		return NewChatOp(p, copts...)
+	}
+}
+
+// Type returns Op type.
+func (op *ChatOp) Type() string { return "ChatOp" }
+
+// Desc returns Op description.
+func (op *ChatOp) Desc() string { return "ChatOp runs chat completion" }
+
+// String implements fmt.Stringer.
+func (op *ChatOp) String() string {
+	return fmt.Sprintf("Op: %s, Desc: %s, Model: %s", op.Type(), op.Desc(), op.model)
+}
+
+// Do maps inputs to chat messages, runs the chat completion and returns
+// a single Value with the generated message role and content.
+// The token usage and its cost are recorded via hypher.RecordUsage.
+//
+// Each input Value is mapped to messages as follows:
+//   - MessagesKey holds a list of messages which are appended in order
+//   - ContentKey holds message content with an optional RoleKey role,
+//     ToolCallsKey tool calls and ToolCallIDKey tool call ID
+//   - TextKey and PromptKey hold user message content
+//
+// Values with none of the keys are ignored. The system prompt,
+// if configured, is always the first message.
+//
+// If tools are configured, they are offered to the model. The tool calls
+// requested by the model are returned under ToolCallsKey so they can be
+// handled by a downstream tool.ToolCallOp. If MaxToolRounds is set, ChatOp
+// calls the requested tools itself and sends their results back to the model
+// until it stops requesting tools or the number of rounds is exhausted.
+func (op *ChatOp) Do(ctx context.Context, inputs ...hypher.Value) ([]hypher.Value, error) {
+	var messages []Message
+	if op.system != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: op.system})
+	}
+
+	for _, in := range inputs {
+		msgs, err := ValueToMessages(in)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msgs...)
+	}
+
+	var tools []tool.Tool
+	if op.tools != nil {
+		tools = op.tools.Tools()
+	}
+
+	for round := 0; ; round++ {
+		resp, err := op.chat(ctx, Request{
+			Model:       op.model,
+			Messages:    messages,
+			Temperature: op.temperature,
+			MaxTokens:   op.maxTokens,
+			Tools:       tools,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Message.ToolCalls) == 0 || op.tools == nil || round >= op.toolRounds {
+			return []hypher.Value{ResponseToValue(resp)}, nil
+		}
+
+		messages = append(messages, resp.Message)
+		for _, res := range op.tools.CallAll(ctx, resp.Message.ToolCalls) {
+			messages = append(messages, Message{
+				Role:       RoleTool,
+				Content:    res.Content,
+				ToolCallID: res.ID,
+			})
+		}
+	}
+}
+
+// chat runs chat completion and records its usage.
+func (op *ChatOp) chat(ctx context.Context, req Request) (*Response, error) {
+	resp, err := op.provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := resp.Usage
+	usage.Cost += (float64(usage.TokensIn)*op.pricing.Input + float64(usage.TokensOut)*op.pricing.Output) / 1e6
+	if err := hypher.RecordUsage(ctx, usage); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ResponseToValue maps chat completion response to Value.
+func ResponseToValue(resp *Response) hypher.Value {
+	v := hypher.Value{
+		RoleKey:         resp.Message.Role,
+		ContentKey:      resp.Message.Content,
+		ModelKey:        resp.Model,
+		FinishReasonKey: resp.FinishReason,
+	}
+	if len(resp.Message.ToolCalls) > 0 {
+		v[ToolCallsKey] = resp.Message.ToolCalls
+	}
+	return v
+}
+
+// ValueToMessages maps Value v to chat messages.
+// See ChatOp.Do for the mapping rules.
+func ValueToMessages(v hypher.Value) ([]Message, error) {
+	var messages []Message
+
+	if m, ok := v[MessagesKey]; ok {
+		msgs, err := toMessages(m)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msgs...)
+	}
+
+	if c, ok := v[ContentKey]; ok {
+		msg, err := toMessage(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message %v: %w", c, err)
+		}
+		messages = append(messages, msg)
+	}
+
+	for _, k := range []string{TextKey, PromptKey} {
+		if c, ok := v[k]; ok {
+			content, ok := c.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: %T", k, c)
+			}
+			messages = append(messages, Message{Role: RoleUser, Content: content})
+		}
+	}
+
+	return messages, nil
+}
+
+// toMessages converts m to a slice of messages.
+func toMessages(m any) ([]Message, error) {
+	switch msgs := m.(type) {
+	case []Message:
+		return msgs, nil
+	case Message:
+		return []Message{msgs}, nil
+	case []map[string]any:
+		messages := make([]Message, 0, len(msgs))
+		for _, mm := range msgs {
+			msg, err := toMessage(mm)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, msg)
+		}
+		return messages, nil
+	case []any:
+		messages := make([]Message, 0, len(msgs))
+		for _, mm := range msgs {
+			switch mv := mm.(type) {
+			case Message:
+				messages = append(messages, mv)
+			case map[string]any:
+				msg, err := toMessage(mv)
+				if err != nil {
+					return nil, err
+				}
+				messages = append(messages, msg)
+			case hypher.Value:
+				msg, err := toMessage(mv)
+				if err != nil {
+					return nil, err
+				}
+				messages = append(messages, msg)
+			default:
+				return nil, fmt.Errorf("invalid message: %T", mm)
+			}
+		}
+		return messages, nil
+	default:
+		return nil, fmt.Errorf("invalid messages: %T", m)
+	}
+}
+
+// toMessage converts m to a message.
+// Messages without a role are user messages.
+func toMessage(m map[string]any) (Message, error) {
+	content, ok := m[ContentKey].(string)
+	if !ok {
+		return Message{}, fmt.Errorf("invalid content: %T", m[ContentKey])
+	}
+
+	role := RoleUser
+	if r, ok := m[RoleKey]; ok {
+		if role, ok = r.(string); !ok {
+			return Message{}, fmt.Errorf("invalid role: %T", r)
+		}
+	}
+
+	calls, err := tool.CallsFromValue(m)
+	if err != nil {
+		return Message{}, err
+	}
+
+	var callID string
+	if id, ok := m[ToolCallIDKey]; ok {
+		if callID, ok = id.(string); !ok {
+			return Message{}, fmt.Errorf("invalid tool call id: %T", id)
+		}
+	}
+
+	return Message{Role: role, Content: content, ToolCalls: calls, ToolCallID: callID}, nil
+}
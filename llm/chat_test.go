@@ -0,0 +1,274 @@
+package llm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/tool"
+)
+
+func MustChatOp(t *testing.T, p Provider, opts ...Option) *ChatOp {
+	op, err := NewChatOp(p, opts...)
+	if err != nil {
+		t.Fatalf("failed to create chat op: %v", err)
+	}
+	return op
+}
+
+func TestNewChatOp(t *testing.T) {
+	if _, err := NewChatOp(nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestChatOpDo(t *testing.T) {
+	p := NewFakeProvider("hello")
+	op := MustChatOp(t, p,
+		WithModel("test-model"),
+		WithSystem("be nice"),
+		WithTemperature(0.5),
+		WithMaxTokens(10),
+		WithPricing(Pricing{Input: 1e6, Output: 2e6}),
+	)
+
+	meter := hypher.NewMeter(hypher.Budget{})
+	ctx := hypher.ContextWithMeter(context.Background(), meter)
+
+	outputs, err := op.Do(ctx,
+		hypher.Value{MessagesKey: []any{
+			map[string]any{RoleKey: RoleUser, ContentKey: "hi"},
+			Message{Role: RoleAssistant, Content: "hey"},
+		}},
+		hypher.Value{ContentKey: "how are you", RoleKey: RoleUser},
+		hypher.Value{PromptKey: "answer briefly"},
+		hypher.Value{"ignored": true},
+	)
+	if err != nil {
+		t.Fatalf("failed to run op: %v", err)
+	}
+
+	exp := []hypher.Value{{
+		RoleKey:         RoleAssistant,
+		ContentKey:      "hello",
+		ModelKey:        "test-model",
+		FinishReasonKey: "stop",
+	}}
+	if !reflect.DeepEqual(outputs, exp) {
+		t.Errorf("expected outputs: %v, got: %v", exp, outputs)
+	}
+
+	reqs := p.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got: %d", len(reqs))
+	}
+
+	expMsgs := []Message{
+		{Role: RoleSystem, Content: "be nice"},
+		{Role: RoleUser, Content: "hi"},
+		{Role: RoleAssistant, Content: "hey"},
+		{Role: RoleUser, Content: "how are you"},
+		{Role: RoleUser, Content: "answer briefly"},
+	}
+	if !reflect.DeepEqual(reqs[0].Messages, expMsgs) {
+		t.Errorf("expected messages: %v, got: %v", expMsgs, reqs[0].Messages)
+	}
+	if reqs[0].Model != "test-model" || *reqs[0].Temperature != 0.5 || reqs[0].MaxTokens != 10 {
+		t.Errorf("unexpected request: %#v", reqs[0])
+	}
+
+	usage := meter.Usage()
+	// 7 + 2 + 3 + 11 + 14 input characters and 5 output characters
+	if usage.TokensIn != 37 || usage.TokensOut != 5 || usage.Cost != 37+2*5 {
+		t.Errorf("unexpected usage: %#v", usage)
+	}
+}
+
+func TestChatOpInvalidInput(t *testing.T) {
+	op := MustChatOp(t, NewFakeProvider())
+
+	for _, in := range []hypher.Value{
+		{ContentKey: 1},
+		{ContentKey: "foo", RoleKey: 1},
+		{TextKey: []string{"foo"}},
+		{MessagesKey: "foo"},
+		{MessagesKey: []any{1}},
+	} {
+		if _, err := op.Do(context.Background(), in); err == nil {
+			t.Errorf("expected error for input: %v", in)
+		}
+	}
+}
+
+func TestChatOpGraph(t *testing.T) {
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+
+	p := NewFakeProvider()
+	n1, err := g.NewNode(hypher.WithOp(MustChatOp(t, p)))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	n2, err := g.NewNode(hypher.WithOp(MustChatOp(t, p, WithSystem("summarize"))))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if _, err := g.NewEdge(n1, n2); err != nil {
+		t.Fatalf("failed to create edge: %v", err)
+	}
+	g.SetInputs([]*graph.Node{n1})
+	g.SetOutputs([]*graph.Node{n2})
+
+	inputs := map[string]hypher.Value{n1.UID(): {PromptKey: "ping"}}
+	if err := g.Run(context.Background(), inputs); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+
+	outputs := n2.Outputs()
+	if len(outputs) != 1 || outputs[0][ContentKey] != "ping" {
+		t.Errorf("unexpected outputs: %v", outputs)
+	}
+}
+
+func TestChatOpTools(t *testing.T) {
+	type args struct {
+		City string `json:"city"`
+	}
+
+	reg := tool.NewRegistry()
+	if err := tool.Register(reg, "weather", "Get weather", func(_ context.Context, a args) (string, error) {
+		return "rain in " + a.City, nil
+	}); err != nil {
+		t.Fatalf("failed to register tool: %v", err)
+	}
+
+	var requests []Request
+	p := ProviderFunc(func(_ context.Context, req Request) (*Response, error) {
+		requests = append(requests, req)
+		last := req.Messages[len(req.Messages)-1]
+		if last.Role == RoleTool {
+			return &Response{Message: Message{Role: RoleAssistant, Content: last.Content}, FinishReason: "stop"}, nil
+		}
+		return &Response{
+			Message: Message{
+				Role:      RoleAssistant,
+				ToolCalls: []tool.Call{{ID: "1", Name: "weather", Arguments: `{"city":"Oslo"}`}},
+			},
+			FinishReason: "tool_calls",
+		}, nil
+	})
+
+	t.Run("Calls", func(t *testing.T) {
+		requests = nil
+		op := MustChatOp(t, p, WithTools(reg))
+
+		outputs, err := op.Do(context.Background(), hypher.Value{PromptKey: "weather?"})
+		if err != nil {
+			t.Fatalf("failed to run op: %v", err)
+		}
+
+		if len(requests) != 1 {
+			t.Fatalf("expected 1 request, got: %d", len(requests))
+		}
+		if !reflect.DeepEqual(requests[0].Tools, reg.Tools()) {
+			t.Errorf("expected tools: %v, got: %v", reg.Tools(), requests[0].Tools)
+		}
+
+		calls, err := tool.CallsFromValue(outputs[0])
+		if err != nil || len(calls) != 1 || calls[0].Name != "weather" {
+			t.Errorf("unexpected tool calls: %v, err: %v", calls, err)
+		}
+	})
+
+	t.Run("Rounds", func(t *testing.T) {
+		requests = nil
+		op := MustChatOp(t, p, WithTools(reg), WithMaxToolRounds(1))
+
+		outputs, err := op.Do(context.Background(), hypher.Value{PromptKey: "weather?"})
+		if err != nil {
+			t.Fatalf("failed to run op: %v", err)
+		}
+
+		if len(requests) != 2 {
+			t.Fatalf("expected 2 requests, got: %d", len(requests))
+		}
+		if n := len(requests[1].Messages); n != 3 {
+			t.Errorf("expected 3 messages, got: %d", n)
+		}
+		if content := outputs[0][ContentKey]; content != `"rain in Oslo"` {
+			t.Errorf("unexpected content: %v", content)
+		}
+		if _, ok := outputs[0][ToolCallsKey]; ok {
+			t.Errorf("unexpected tool calls: %v", outputs[0])
+		}
+	})
+}
+
+func TestValueToMessagesTools(t *testing.T) {
+	msgs, err := ValueToMessages(hypher.Value{MessagesKey: []any{
+		map[string]any{
+			RoleKey:    RoleAssistant,
+			ContentKey: "",
+			ToolCallsKey: []any{
+				map[string]any{"id": "1", "name": "weather", "arguments": map[string]any{"city": "Oslo"}},
+			},
+		},
+		tool.ResultToValue(tool.Result{ID: "1", Name: "weather", Content: `"rain"`}),
+	}})
+	if err != nil {
+		t.Fatalf("failed to map value: %v", err)
+	}
+
+	exp := []Message{
+		{Role: RoleAssistant, ToolCalls: []tool.Call{{ID: "1", Name: "weather", Arguments: `{"city":"Oslo"}`}}},
+		{Role: RoleTool, Content: `"rain"`, ToolCallID: "1"},
+	}
+	if !reflect.DeepEqual(msgs, exp) {
+		t.Errorf("expected messages: %#v, got: %#v", exp, msgs)
+	}
+}
+
+func TestChatOpSpec(t *testing.T) {
+	p := NewFakeProvider()
+	op := MustChatOp(t, p,
+		WithModel("test-model"),
+		WithSystem("be nice"),
+		WithTemperature(0.5),
+		WithMaxTokens(10),
+		WithPricing(Pricing{Input: 1, Output: 2}),
+		WithMaxToolRounds(2),
+	)
+
+	spec, err := op.Spec()
+	if err != nil {
+		t.Fatalf("failed to get spec: %v", err)
+	}
+
+	reg := tool.NewRegistry()
+	op2, err := ChatOpFactory(p, WithModel("other"), WithTools(reg))(spec)
+	if err != nil {
+		t.Fatalf("failed to create op from spec: %v", err)
+	}
+
+	exp := *op
+	exp.tools = reg
+	if !reflect.DeepEqual(op2, &exp) {
+		t.Errorf("expected op: %#v, got: %#v", &exp, op2)
+	}
+
+	op3, err := ChatOpFactory(p, WithModel("default"))(nil)
+	if err != nil {
+		t.Fatalf("failed to create op: %v", err)
+	}
+	if model := op3.(*ChatOp).model; model != "default" {
+		t.Errorf("expected model: default, got: %s", model)
+	}
+
+	if _, err := ChatOpFactory(p)([]byte("{")); err == nil {
+		t.Error("expected invalid spec error")
+	}
+}
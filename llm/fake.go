@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+// FakeProvider is a deterministic Provider for testing.
+// It returns the configured replies in order and then cycles through them.
+// If no replies are configured it echoes the content of the last message.
+// Token usage is the number of message characters.
+type FakeProvider struct {
+	replies  []string
+	requests []Request
+	mu       sync.Mutex
+}
+
+// NewFakeProvider creates a new fake provider and returns it.
+func NewFakeProvider(replies ...string) *FakeProvider {
+	return &FakeProvider{
+		replies: replies,
+	}
+}
+
+// Chat returns a fake chat completion.
+func (p *FakeProvider) Chat(ctx context.Context, req Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var content string
+	if len(p.replies) > 0 {
+		content = p.replies[len(p.requests)%len(p.replies)]
+	} else if len(req.Messages) > 0 {
+		content = req.Messages[len(req.Messages)-1].Content
+	}
+	p.requests = append(p.requests, req)
+
+	var tokensIn int64
+	for _, m := range req.Messages {
+		tokensIn += int64(len(m.Content))
+	}
+
+	return &Response{
+		Model:        req.Model,
+		Message:      Message{Role: RoleAssistant, Content: content},
+		FinishReason: "stop",
+		Usage: hypher.Usage{
+			TokensIn:  tokensIn,
+			TokensOut: int64(len(content)),
+		},
+	}, nil
+}
+
+// Requests returns all the received requests.
+func (p *FakeProvider) Requests() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	requests := make([]Request, len(p.requests))
+	copy(requests, p.requests)
+
+	return requests
+}
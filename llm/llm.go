@@ -0,0 +1,76 @@
+// Package llm provides hypher Ops which use Large Language Models.
+package llm
+
+import (
+	"context"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/tool"
+)
+
+// Chat message roles.
+const (
+	// RoleSystem is system message role.
+	RoleSystem = "system"
+	// RoleUser is user message role.
+	RoleUser = "user"
+	// RoleAssistant is assistant message role.
+	RoleAssistant = "assistant"
+	// RoleTool is tool result message role.
+	RoleTool = tool.RoleTool
+)
+
+// Message is a chat message.
+type Message struct {
+	// Role is message role.
+	Role string `json:"role"`
+	// Content is message content.
+	Content string `json:"content"`
+	// ToolCalls are tool calls requested by the assistant.
+	ToolCalls []tool.Call `json:"tool_calls,omitempty"`
+	// ToolCallID is the ID of the tool call the tool message responds to.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// Request is a chat completion request.
+type Request struct {
+	// Model is the name of the model.
+	Model string
+	// Messages are chat messages.
+	Messages []Message
+	// Temperature is sampling temperature.
+	// If nil, the provider default is used.
+	Temperature *float64
+	// MaxTokens limits the number of generated tokens.
+	// If zero, the provider default is used.
+	MaxTokens int
+	// Tools are tools the model may call.
+	Tools []tool.Tool
+}
+
+// Response is a chat completion response.
+type Response struct {
+	// Model is the name of the model which generated the response.
+	Model string
+	// Message is the generated message.
+	Message Message
+	// FinishReason is the reason the generation finished.
+	FinishReason string
+	// Usage is the token usage.
+	Usage hypher.Usage
+}
+
+// Provider provides chat completions.
+type Provider interface {
+	// Chat returns a chat completion for the given request.
+	Chat(ctx context.Context, req Request) (*Response, error)
+}
+
+// ProviderFunc is an adapter which allows to use
+// ordinary functions as chat completion providers.
+type ProviderFunc func(ctx context.Context, req Request) (*Response, error)
+
+// Chat calls f(ctx, req).
+func (f ProviderFunc) Chat(ctx context.Context, req Request) (*Response, error) {
+	return f(ctx, req)
+}
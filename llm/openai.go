@@ -0,0 +1,229 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/tool"
+)
+
+const (
+	// OpenAIBaseURL is the default OpenAI API base URL.
+	OpenAIBaseURL = "https://api.openai.com/v1"
+	// chatCompletionsPath is chat completions API path.
+	chatCompletionsPath = "/chat/completions"
+	// maxResponseSize is the maximum size of chat completion response body.
+	maxResponseSize = 10 << 20
+	// maxErrorSize is the maximum size of error response body which is read.
+	maxErrorSize = 64 << 10
+)
+
+// OpenAIProvider is an OpenAI-compatible chat completions provider.
+// It works with the OpenAI API as well as with local model servers
+// which expose an OpenAI-compatible API e.g. Ollama, llama.cpp or vLLM.
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewOpenAIProvider creates a new OpenAI-compatible provider and returns it.
+// If baseURL is empty, OpenAIBaseURL is used. If apiKey is empty, no
+// Authorization header is sent. If client is nil, http.DefaultClient is used.
+func NewOpenAIProvider(baseURL, apiKey string, client *http.Client) (*OpenAIProvider, error) {
+	if baseURL == "" {
+		baseURL = OpenAIBaseURL
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &OpenAIProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  client,
+	}, nil
+}
+
+// openAIToolType is the only tool type supported by the API.
+const openAIToolType = "function"
+
+type openAIFunction struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Parameters  *tool.Schema `json:"parameters,omitempty"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type openAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Tools       []openAITool    `json:"tools,omitempty"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// Chat returns a chat completion for the given request.
+func (p *OpenAIProvider) Chat(ctx context.Context, req Request) (*Response, error) {
+	body, err := json.Marshal(toOpenAIRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+chatCompletionsPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		var apiErr openAIError
+		errBody, err := io.ReadAll(io.LimitReader(httpResp.Body, maxErrorSize))
+		if err == nil && json.Unmarshal(errBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("chat completion failed: %s: %s", httpResp.Status, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("chat completion failed: %s", httpResp.Status)
+	}
+
+	// NOTE: one extra byte is read to detect oversized responses.
+	respBody, err := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(respBody) > maxResponseSize {
+		return nil, fmt.Errorf("chat completion response exceeds %d bytes", maxResponseSize)
+	}
+
+	var resp openAIResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("invalid chat completion response: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty chat completion response")
+	}
+
+	return &Response{
+		Model:        resp.Model,
+		Message:      fromOpenAIMessage(resp.Choices[0].Message),
+		FinishReason: resp.Choices[0].FinishReason,
+		Usage: hypher.Usage{
+			TokensIn:  resp.Usage.PromptTokens,
+			TokensOut: resp.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+func toOpenAIRequest(req Request) openAIRequest {
+	oreq := openAIRequest{
+		Model:       req.Model,
+		Messages:    make([]openAIMessage, 0, len(req.Messages)),
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+
+	for _, m := range req.Messages {
+		om := openAIMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCallID: m.ToolCallID,
+		}
+		for _, c := range m.ToolCalls {
+			om.ToolCalls = append(om.ToolCalls, openAIToolCall{
+				ID:   c.ID,
+				Type: openAIToolType,
+				Function: openAIFunctionCall{
+					Name:      c.Name,
+					Arguments: c.Arguments,
+				},
+			})
+		}
+		oreq.Messages = append(oreq.Messages, om)
+	}
+
+	for _, t := range req.Tools {
+		oreq.Tools = append(oreq.Tools, openAITool{
+			Type: openAIToolType,
+			Function: openAIFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+
+	return oreq
+}
+
+func fromOpenAIMessage(om openAIMessage) Message {
+	m := Message{
+		Role:       om.Role,
+		Content:    om.Content,
+		ToolCallID: om.ToolCallID,
+	}
+	for _, c := range om.ToolCalls {
+		m.ToolCalls = append(m.ToolCalls, tool.Call{
+			ID:        c.ID,
+			Name:      c.Function.Name,
+			Arguments: c.Function.Arguments,
+		})
+	}
+	return m
+}
@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher/tool"
+)
+
+func TestOpenAIProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != chatCompletionsPath {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("unexpected authorization: %s", auth)
+		}
+
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		exp := openAIRequest{
+			Model:     "test-model",
+			Messages:  []openAIMessage{{Role: RoleUser, Content: "hi"}},
+			MaxTokens: 10,
+		}
+		if !reflect.DeepEqual(req, exp) {
+			t.Errorf("expected request: %#v, got: %#v", exp, req)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"model": "test-model-1",
+			"choices": [{"message": {"role": "assistant", "content": "hello"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 3, "completion_tokens": 2}
+		}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAIProvider(srv.URL+"/", "secret", srv.Client())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	resp, err := p.Chat(context.Background(), Request{
+		Model:     "test-model",
+		Messages:  []Message{{Role: RoleUser, Content: "hi"}},
+		MaxTokens: 10,
+	})
+	if err != nil {
+		t.Fatalf("chat failed: %v", err)
+	}
+
+	if resp.Model != "test-model-1" ||
+		!reflect.DeepEqual(resp.Message, Message{Role: RoleAssistant, Content: "hello"}) ||
+		resp.FinishReason != "stop" ||
+		resp.Usage.TokensIn != 3 ||
+		resp.Usage.TokensOut != 2 {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+}
+
+func TestOpenAIProviderTools(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		exp := map[string]any{
+			"model": "",
+			"messages": []any{
+				map[string]any{"role": "user", "content": "weather?"},
+				map[string]any{"role": "assistant", "content": "", "tool_calls": []any{
+					map[string]any{"id": "1", "type": "function", "function": map[string]any{"name": "weather", "arguments": `{"city":"Oslo"}`}},
+				}},
+				map[string]any{"role": "tool", "content": `"rain"`, "tool_call_id": "1"},
+			},
+			"tools": []any{
+				map[string]any{"type": "function", "function": map[string]any{
+					"name":        "weather",
+					"description": "Get weather",
+					"parameters":  map[string]any{"type": "object"},
+				}},
+			},
+		}
+		if !reflect.DeepEqual(req, exp) {
+			t.Errorf("expected request: %#v, got: %#v", exp, req)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"choices": [{
+				"message": {
+					"role": "assistant",
+					"content": null,
+					"tool_calls": [{"id": "2", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Rome\"}"}}]
+				},
+				"finish_reason": "tool_calls"
+			}]
+		}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAIProvider(srv.URL, "", srv.Client())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	resp, err := p.Chat(context.Background(), Request{
+		Messages: []Message{
+			{Role: RoleUser, Content: "weather?"},
+			{Role: RoleAssistant, ToolCalls: []tool.Call{{ID: "1", Name: "weather", Arguments: `{"city":"Oslo"}`}}},
+			{Role: RoleTool, Content: `"rain"`, ToolCallID: "1"},
+		},
+		Tools: []tool.Tool{{Name: "weather", Description: "Get weather", Parameters: &tool.Schema{Type: tool.TypeObject}}},
+	})
+	if err != nil {
+		t.Fatalf("chat failed: %v", err)
+	}
+
+	exp := Message{
+		Role:      RoleAssistant,
+		ToolCalls: []tool.Call{{ID: "2", Name: "weather", Arguments: `{"city":"Rome"}`}},
+	}
+	if !reflect.DeepEqual(resp.Message, exp) {
+		t.Errorf("expected message: %#v, got: %#v", exp, resp.Message)
+	}
+}
+
+func TestOpenAIProviderError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"APIError", http.StatusBadRequest, `{"error": {"message": "bad model", "type": "invalid_request_error"}}`},
+		{"Status", http.StatusInternalServerError, `oops`},
+		{"NoChoices", http.StatusOK, `{"choices": []}`},
+		{"InvalidJSON", http.StatusOK, `{`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			p, err := NewOpenAIProvider(srv.URL, "", nil)
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+
+			if _, err := p.Chat(context.Background(), Request{}); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestOpenAIProviderLargeResponse(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status int
+		want   string
+	}{
+		{"Response", http.StatusOK, "exceeds"},
+		{"Error", http.StatusBadRequest, "400 Bad Request"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "`))
+				_, _ = w.Write(bytes.Repeat([]byte("x"), maxResponseSize))
+				_, _ = w.Write([]byte(`"}}]}`))
+			}))
+			defer srv.Close()
+
+			p, err := NewOpenAIProvider(srv.URL, "", nil)
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+
+			_, err = p.Chat(context.Background(), Request{})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
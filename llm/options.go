@@ -0,0 +1,82 @@
+package llm
+
+import "github.com/milosgajdos/go-hypher/tool"
+
+// Pricing is model token pricing.
+type Pricing struct {
+	// Input is the price of a million input tokens.
+	Input float64 `json:"input"`
+	// Output is the price of a million output tokens.
+	Output float64 `json:"output"`
+}
+
+// Options configure ChatOp.
+type Options struct {
+	// Model configures the model name.
+	Model string
+	// System configures the system prompt.
+	System string
+	// Temperature configures sampling temperature.
+	Temperature *float64
+	// MaxTokens configures the maximum number of generated tokens.
+	MaxTokens int
+	// Pricing configures model pricing.
+	Pricing Pricing
+	// Tools configures tools the model may call.
+	Tools *tool.Registry
+	// MaxToolRounds configures the maximum number of rounds
+	// in which ChatOp calls the tools requested by the model.
+	MaxToolRounds int
+}
+
+// Option is functional ChatOp option.
+type Option func(*Options)
+
+// WithModel sets Model.
+func WithModel(model string) Option {
+	return func(o *Options) {
+		o.Model = model
+	}
+}
+
+// WithSystem sets System prompt.
+func WithSystem(system string) Option {
+	return func(o *Options) {
+		o.System = system
+	}
+}
+
+// WithTemperature sets Temperature.
+func WithTemperature(t float64) Option {
+	return func(o *Options) {
+		o.Temperature = &t
+	}
+}
+
+// WithMaxTokens sets MaxTokens.
+func WithMaxTokens(n int) Option {
+	return func(o *Options) {
+		o.MaxTokens = n
+	}
+}
+
+// WithPricing sets Pricing.
+func WithPricing(p Pricing) Option {
+	return func(o *Options) {
+		o.Pricing = p
+	}
+}
+
+// WithTools sets Tools.
+func WithTools(r *tool.Registry) Option {
+	return func(o *Options) {
+		o.Tools = r
+	}
+}
+
+// WithMaxToolRounds sets MaxToolRounds.
+func WithMaxToolRounds(n int) Option {
+	return func(o *Options) {
+		o.MaxToolRounds = n
+	}
+}
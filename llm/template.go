@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+// MissingKey configures template behavior for missing keys.
+type MissingKey string
+
+const (
+	// MissingKeyDefault renders missing keys as "<no value>".
+	MissingKeyDefault MissingKey = "default"
+	// MissingKeyZero renders missing keys as zero values.
+	MissingKeyZero MissingKey = "zero"
+	// MissingKeyError fails rendering on missing keys.
+	MissingKeyError MissingKey = "error"
+)
+
+// Node attributes used to store TemplateOp.
+const (
+	// TemplateAttr stores template text.
+	TemplateAttr = "template"
+	// TemplateNameAttr stores template name.
+	TemplateNameAttr = "template_name"
+	// TemplateMissingKeyAttr stores template missing key mode.
+	TemplateMissingKeyAttr = "template_missingkey"
+)
+
+// TemplateFuncs returns template helper functions:
+//   - json encodes its argument to JSON
+//   - join joins a list of values with a separator: {{ .list | join ", " }}
+//   - truncate truncates a string to n characters: {{ .text | truncate 10 }}
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"json":     tmplJSON,
+		"join":     tmplJoin,
+		"truncate": tmplTruncate,
+	}
+}
+
+func tmplJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func tmplJoin(sep string, v any) (string, error) {
+	switch l := v.(type) {
+	case []string:
+		return strings.Join(l, sep), nil
+	case []any:
+		s := make([]string, 0, len(l))
+		for _, item := range l {
+			s = append(s, fmt.Sprint(item))
+		}
+		return strings.Join(s, sep), nil
+	default:
+		return "", fmt.Errorf("join: invalid list: %T", v)
+	}
+}
+
+func tmplTruncate(n int, s string) string {
+	r := []rune(s)
+	if n < 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// TemplateOp is a hypher.Op which renders text/template templates.
+type TemplateOp struct {
+	name       string
+	text       string
+	missingKey MissingKey
+	tmpl       *template.Template
+}
+
+// NewTemplateOp parses the template text, creates a new TemplateOp
+// which renders the template with the given name and returns it.
+// The text may define several templates via the define action.
+// If missingKey is empty, MissingKeyDefault is used.
+func NewTemplateOp(name, text string, missingKey MissingKey) (*TemplateOp, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing template name")
+	}
+
+	if missingKey == "" {
+		missingKey = MissingKeyDefault
+	}
+
+	switch missingKey {
+	case MissingKeyDefault, MissingKeyZero, MissingKeyError:
+	default:
+		return nil, fmt.Errorf("invalid missing key mode: %s", missingKey)
+	}
+
+	tmpl, err := template.New(name).
+		Funcs(TemplateFuncs()).
+		Option("missingkey=" + string(missingKey)).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", name, err)
+	}
+
+	return &TemplateOp{
+		name:       name,
+		text:       text,
+		missingKey: missingKey,
+		tmpl:       tmpl,
+	}, nil
+}
+
+// NewTemplateOpFromAttrs creates a new TemplateOp from attrs and returns it.
+// The attrs are usually node attributes which were set from TemplateOp.Attrs.
+func NewTemplateOpFromAttrs(attrs map[string]any) (*TemplateOp, error) {
+	text, ok := attrs[TemplateAttr].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s attribute: %T", TemplateAttr, attrs[TemplateAttr])
+	}
+
+	name, ok := attrs[TemplateNameAttr].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s attribute: %T", TemplateNameAttr, attrs[TemplateNameAttr])
+	}
+
+	var missingKey MissingKey
+	if mk, ok := attrs[TemplateMissingKeyAttr]; ok {
+		s, ok := mk.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s attribute: %T", TemplateMissingKeyAttr, mk)
+		}
+		missingKey = MissingKey(s)
+	}
+
+	return NewTemplateOp(name, text, missingKey)
+}
+
+// Attrs returns the attributes which store the template.
+// They can be set as node attributes and used
+// to recreate the Op via NewTemplateOpFromAttrs.
+func (op *TemplateOp) Attrs() map[string]any {
+	return map[string]any{
+		TemplateAttr:           op.text,
+		TemplateNameAttr:       op.name,
+		TemplateMissingKeyAttr: string(op.missingKey),
+	}
+}
+
+// templateSpec is TemplateOp spec.
+type templateSpec struct {
+	Name       string     `json:"name"`
+	Template   string     `json:"template"`
+	MissingKey MissingKey `json:"missing_key,omitempty"`
+}
+
+// Spec returns JSON encoded TemplateOp config.
+// It implements hypher.OpSpec.
+func (op *TemplateOp) Spec() ([]byte, error) {
+	return json.Marshal(templateSpec{
+		Name:       op.name,
+		Template:   op.text,
+		MissingKey: op.missingKey,
+	})
+}
+
+// TemplateOpFactory creates TemplateOp from its spec.
+// It is a hypher.OpFactory.
+func TemplateOpFactory(spec []byte) (hypher.Op, error) {
+	var s templateSpec
+	if err := json.Unmarshal(spec, &s); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	return NewTemplateOp(s.Name, s.Template, s.MissingKey)
+}
+
+// Type returns Op type.
+func (op *TemplateOp) Type() string { return "TemplateOp" }
+
+// Desc returns Op description.
+func (op *TemplateOp) Desc() string { return "TemplateOp renders text templates" }
+
+// String implements fmt.Stringer.
+func (op *TemplateOp) String() string {
+	return fmt.Sprintf("Op: %s, Desc: %s, Template: %s", op.Type(), op.Desc(), op.name)
+}
+
+// Do merges inputs into a single Value, renders the template against it
+// and returns a single Value with the rendered text stored in TextKey.
+// The inputs are merged in order so latter inputs override former ones.
+func (op *TemplateOp) Do(_ context.Context, inputs ...hypher.Value) ([]hypher.Value, error) {
+	data := make(map[string]any)
+	for _, in := range inputs {
+		for k, v := range in {
+			data[k] = v
+		}
+	}
+
+	var b strings.Builder
+	if err := op.tmpl.ExecuteTemplate(&b, op.name, data); err != nil {
+		return nil, fmt.Errorf("render template %s: %w", op.name, err)
+	}
+
+	return []hypher.Value{{TextKey: b.String()}}, nil
+}
@@ -0,0 +1,204 @@
+package llm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+	"github.com/milosgajdos/go-hypher/graph/sqlite"
+)
+
+func MustTemplateOp(t *testing.T, name, text string, mk MissingKey) *TemplateOp {
+	op, err := NewTemplateOp(name, text, mk)
+	if err != nil {
+		t.Fatalf("failed to create template op: %v", err)
+	}
+	return op
+}
+
+func TestNewTemplateOp(t *testing.T) {
+	testCases := []struct {
+		name string
+		tmpl string
+		mk   MissingKey
+	}{
+		{name: "", tmpl: "hi"},
+		{name: "prompt", tmpl: "{{ .foo"},
+		{name: "prompt", tmpl: "hi", mk: "invalid"},
+	}
+
+	for _, tc := range testCases {
+		if _, err := NewTemplateOp(tc.name, tc.tmpl, tc.mk); err == nil {
+			t.Errorf("expected error for template %q: %q", tc.name, tc.tmpl)
+		}
+	}
+}
+
+func TestTemplateOpDo(t *testing.T) {
+	text := `{{ define "prompt" }}Q: {{ .question | truncate 5 }}; tags: {{ .tags | join ", " }}; ctx: {{ json .ctx }}{{ end }}`
+	op := MustTemplateOp(t, "prompt", text, MissingKeyError)
+
+	outputs, err := op.Do(context.Background(),
+		hypher.Value{"question": "ignored", "tags": []any{"a", 1}},
+		hypher.Value{"question": "what is hypher", "ctx": map[string]any{"k": "v"}},
+	)
+	if err != nil {
+		t.Fatalf("failed to render template: %v", err)
+	}
+
+	if len(outputs) != 1 {
+		t.Fatalf("expected 1 output, got: %d", len(outputs))
+	}
+
+	want := `Q: what ; tags: a, 1; ctx: {"k":"v"}`
+	if got := outputs[0][TextKey]; got != want {
+		t.Errorf("expected text: %q, got: %q", want, got)
+	}
+}
+
+func TestTemplateOpMissingKey(t *testing.T) {
+	testCases := []struct {
+		mk      MissingKey
+		want    string
+		wantErr bool
+	}{
+		{mk: MissingKeyDefault, want: "hi <no value>"},
+		{mk: MissingKeyZero, want: "hi <no value>"},
+		{mk: MissingKeyError, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		op := MustTemplateOp(t, "prompt", "hi {{ .name }}", tc.mk)
+		outputs, err := op.Do(context.Background(), hypher.Value{})
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tc.mk)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: failed to render template: %v", tc.mk, err)
+		}
+		if got := outputs[0][TextKey]; got != tc.want {
+			t.Errorf("%s: expected text: %q, got: %q", tc.mk, tc.want, got)
+		}
+	}
+}
+
+func TestTemplateOpAttrsRoundTrip(t *testing.T) {
+	op := MustTemplateOp(t, "prompt", "Hello {{ .name }}", MissingKeyError)
+
+	db, err := sqlite.NewDB(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	g, err := graph.NewGraph()
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+
+	n, err := graph.NewNode(hypher.WithUID("tmpl"), hypher.WithOp(op), hypher.WithAttrs(op.Attrs()))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if err := g.AddNode(n); err != nil {
+		t.Fatalf("failed to add node: %v", err)
+	}
+
+	chat, err := graph.NewNode(hypher.WithUID("chat"), hypher.WithOp(MustChatOp(t, NewFakeProvider())))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if err := g.AddNode(chat); err != nil {
+		t.Fatalf("failed to add node: %v", err)
+	}
+
+	e, err := graph.NewEdge(n, chat)
+	if err != nil {
+		t.Fatalf("failed to create edge: %v", err)
+	}
+	g.SetWeightedEdge(e)
+
+	s, err := sqlite.NewSyncer(db)
+	if err != nil {
+		t.Fatalf("failed to create syncer: %v", err)
+	}
+	if err := s.Sync(context.Background(), g); err != nil {
+		t.Fatalf("failed to sync graph: %v", err)
+	}
+
+	l, err := sqlite.NewLoader(db)
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+	lg, err := l.Load(context.Background(), g.UID())
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+
+	var attrs map[string]any
+	nodes := lg.Nodes()
+	for nodes.Next() {
+		if ln := nodes.Node().(hypher.Node); ln.UID() == n.UID() {
+			attrs = ln.Attrs()
+		}
+	}
+
+	lop, err := NewTemplateOpFromAttrs(attrs)
+	if err != nil {
+		t.Fatalf("failed to create template op from attrs: %v", err)
+	}
+
+	outputs, err := lop.Do(context.Background(), hypher.Value{"name": "hypher"})
+	if err != nil {
+		t.Fatalf("failed to render template: %v", err)
+	}
+	if got := outputs[0][TextKey]; got != "Hello hypher" {
+		t.Errorf("unexpected text: %q", got)
+	}
+
+	if _, err := lop.Do(context.Background(), hypher.Value{}); err == nil || !strings.Contains(err.Error(), "name") {
+		t.Errorf("expected missing key error, got: %v", err)
+	}
+}
+
+func TestNewTemplateOpFromAttrs(t *testing.T) {
+	testCases := []map[string]any{
+		{},
+		{TemplateAttr: "hi"},
+		{TemplateAttr: "hi", TemplateNameAttr: "prompt", TemplateMissingKeyAttr: 1},
+	}
+
+	for _, attrs := range testCases {
+		if _, err := NewTemplateOpFromAttrs(attrs); err == nil {
+			t.Errorf("expected error for attrs: %v", attrs)
+		}
+	}
+}
+
+func TestTemplateOpSpec(t *testing.T) {
+	op := MustTemplateOp(t, "prompt", "Hi {{ .name }}", MissingKeyZero)
+
+	spec, err := op.Spec()
+	if err != nil {
+		t.Fatalf("failed to get spec: %v", err)
+	}
+
+	op2, err := TemplateOpFactory(spec)
+	if err != nil {
+		t.Fatalf("failed to create op from spec: %v", err)
+	}
+
+	if !reflect.DeepEqual(op.Attrs(), op2.(*TemplateOp).Attrs()) {
+		t.Errorf("expected op: %v, got: %v", op.Attrs(), op2.(*TemplateOp).Attrs())
+	}
+
+	if _, err := TemplateOpFactory([]byte("{")); err == nil {
+		t.Error("expected invalid spec error")
+	}
+}
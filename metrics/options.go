@@ -0,0 +1,26 @@
+package metrics
+
+// Options configure metrics.
+type Options struct {
+	// Namespace configures metrics namespace.
+	Namespace string
+	// Buckets configures histogram buckets.
+	Buckets []float64
+}
+
+// Option is functional metrics option.
+type Option func(*Options)
+
+// WithNamespace sets metrics Namespace.
+func WithNamespace(ns string) Option {
+	return func(o *Options) {
+		o.Namespace = ns
+	}
+}
+
+// WithBuckets sets histogram Buckets.
+func WithBuckets(b []float64) Option {
+	return func(o *Options) {
+		o.Buckets = b
+	}
+}
@@ -0,0 +1,130 @@
+// Package metrics provides Prometheus metrics of hypher runs.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Namespace is the default metrics namespace.
+	Namespace = "hypher"
+	// StatusSuccess is the status label value of successful executions.
+	StatusSuccess = "success"
+	// StatusError is the status label value of failed executions.
+	StatusError = "error"
+)
+
+// Prometheus records hypher metrics in Prometheus.
+type Prometheus struct {
+	runsStarted   prometheus.Counter
+	runsSucceeded prometheus.Counter
+	runsFailed    prometheus.Counter
+	runDuration   prometheus.Histogram
+	nodeExec      *prometheus.HistogramVec
+	nodeQueueWait *prometheus.HistogramVec
+}
+
+// NewPrometheus creates new Prometheus metrics,
+// registers them with reg and returns them.
+// If reg is nil, prometheus.DefaultRegisterer is used.
+func NewPrometheus(reg prometheus.Registerer, opts ...Option) (*Prometheus, error) {
+	popts := Options{
+		Namespace: Namespace,
+		Buckets:   prometheus.DefBuckets,
+	}
+
+	for _, apply := range opts {
+		apply(&popts)
+	}
+
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	p := &Prometheus{
+		runsStarted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: popts.Namespace,
+			Name:      "runs_started_total",
+			Help:      "Total number of started graph runs.",
+		}),
+		runsSucceeded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: popts.Namespace,
+			Name:      "runs_succeeded_total",
+			Help:      "Total number of succeeded graph runs.",
+		}),
+		runsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: popts.Namespace,
+			Name:      "runs_failed_total",
+			Help:      "Total number of failed graph runs.",
+		}),
+		runDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: popts.Namespace,
+			Name:      "run_duration_seconds",
+			Help:      "Graph run duration in seconds.",
+			Buckets:   popts.Buckets,
+		}),
+		nodeExec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: popts.Namespace,
+			Name:      "node_exec_duration_seconds",
+			Help:      "Node execution latency in seconds by Op type.",
+			Buckets:   popts.Buckets,
+		}, []string{"op_type", "status"}),
+		nodeQueueWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: popts.Namespace,
+			Name:      "node_queue_wait_seconds",
+			Help:      "Time scheduled nodes waited for execution in seconds by Op type.",
+			Buckets:   popts.Buckets,
+		}, []string{"op_type"}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		p.runsStarted,
+		p.runsSucceeded,
+		p.runsFailed,
+		p.runDuration,
+		p.nodeExec,
+		p.nodeQueueWait,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// RunStarted increments started runs counter.
+func (p *Prometheus) RunStarted() {
+	p.runsStarted.Inc()
+}
+
+// RunFinished increments succeeded or failed runs counter
+// depending on err and observes the run duration d.
+func (p *Prometheus) RunFinished(d time.Duration, err error) {
+	if err != nil {
+		p.runsFailed.Inc()
+	} else {
+		p.runsSucceeded.Inc()
+	}
+	p.runDuration.Observe(d.Seconds())
+}
+
+// NodeExec observes node execution latency d of the Op with the given type.
+func (p *Prometheus) NodeExec(opType string, d time.Duration, err error) {
+	p.nodeExec.WithLabelValues(opType, status(err)).Observe(d.Seconds())
+}
+
+// NodeQueueWait observes queue wait time d of the node with the given Op type.
+func (p *Prometheus) NodeQueueWait(opType string, d time.Duration) {
+	p.nodeQueueWait.WithLabelValues(opType).Observe(d.Seconds())
+}
+
+// status returns status label value for err.
+func status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusSuccess
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+type failOp struct{ graph.NoOp }
+
+func (failOp) Type() string { return "failOp" }
+
+func (failOp) Do(context.Context, ...hypher.Value) ([]hypher.Value, error) {
+	return nil, errors.New("failOp error")
+}
+
+func TestPrometheus(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	m, err := NewPrometheus(reg)
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+
+	g, err := graph.NewGraph(hypher.WithMetrics(m))
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+
+	n1, err := g.NewNode()
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	n2, err := g.NewNode()
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if _, err := g.NewEdge(n1, n2); err != nil {
+		t.Fatalf("failed to create edge: %v", err)
+	}
+	g.SetInputs([]*graph.Node{n1})
+	g.SetOutputs([]*graph.Node{n2})
+
+	if err := g.Run(context.Background(), nil); err != nil {
+		t.Fatalf("failed to run graph: %v", err)
+	}
+
+	n3, err := g.NewNode(hypher.WithOp(failOp{}))
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if _, err := g.NewEdge(n2, n3); err != nil {
+		t.Fatalf("failed to create edge: %v", err)
+	}
+	g.SetOutputs([]*graph.Node{n3})
+
+	if err := g.Run(context.Background(), nil); err == nil {
+		t.Fatal("expected run error")
+	}
+
+	if v := testutil.ToFloat64(m.runsStarted); v != 2 {
+		t.Errorf("expected 2 started runs, got: %v", v)
+	}
+	if v := testutil.ToFloat64(m.runsSucceeded); v != 1 {
+		t.Errorf("expected 1 succeeded run, got: %v", v)
+	}
+	if v := testutil.ToFloat64(m.runsFailed); v != 1 {
+		t.Errorf("expected 1 failed run, got: %v", v)
+	}
+
+	// NoOp successes and failOp errors
+	if n := testutil.CollectAndCount(m.nodeExec); n != 2 {
+		t.Errorf("expected 2 node exec series, got: %d", n)
+	}
+	if n := testutil.CollectAndCount(m.nodeQueueWait); n != 2 {
+		t.Errorf("expected 2 queue wait series, got: %d", n)
+	}
+
+	if _, err := NewPrometheus(reg); err == nil {
+		t.Error("expected duplicate registration error")
+	}
+}
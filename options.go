@@ -1,6 +1,10 @@
 package hypher
 
-import "maps"
+import (
+	"log/slog"
+	"maps"
+	"time"
+)
 
 // ConcMode is Graph run concurrency mode.
 type ConcMode int
@@ -12,6 +16,18 @@ const (
 	ConcAllMode
 )
 
+// String implements fmt.Stringer.
+func (m ConcMode) String() string {
+	switch m {
+	case ConcLevelMode:
+		return "ConcLevel"
+	case ConcAllMode:
+		return "ConcAll"
+	default:
+		return "Unknown"
+	}
+}
+
 // Options configure graph.
 type Options struct {
 	// ID configures ID
@@ -32,6 +48,21 @@ type Options struct {
 	ConcMode ConcMode
 	// Op configures Node's Op.
 	Op Op
+	// Timeout configures Node's Op execution timeout.
+	Timeout time.Duration
+	// Tracer configures Graph run tracer.
+	Tracer Tracer
+	// Observers configures Graph run observers.
+	Observers []Observer
+	// Metrics configures Graph metrics.
+	Metrics Metrics
+	// Logger configures Graph or Graph run logger.
+	Logger *slog.Logger
+	// LogLevel configures the minimum level of logged records.
+	// When passed to Graph run, it overrides Graph log level.
+	LogLevel slog.Leveler
+	// Budget configures Graph run usage budget.
+	Budget Budget
 }
 
 // Option is functional graph option.
@@ -99,3 +130,52 @@ func WithOp(op Op) Option {
 		o.Op = op
 	}
 }
+
+// WithTimeout sets Timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+// WithTracer sets Tracer.
+func WithTracer(t Tracer) Option {
+	return func(o *Options) {
+		o.Tracer = t
+	}
+}
+
+// WithObserver adds Observer to Observers.
+func WithObserver(obs Observer) Option {
+	return func(o *Options) {
+		o.Observers = append(o.Observers, obs)
+	}
+}
+
+// WithMetrics sets Metrics.
+func WithMetrics(m Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = m
+	}
+}
+
+// WithLogger sets Logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithLogLevel sets LogLevel.
+func WithLogLevel(level slog.Leveler) Option {
+	return func(o *Options) {
+		o.LogLevel = level
+	}
+}
+
+// WithBudget sets Budget.
+func WithBudget(b Budget) Option {
+	return func(o *Options) {
+		o.Budget = b
+	}
+}
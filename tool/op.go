@@ -0,0 +1,85 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+// ToolCallOp is a hypher.Op which calls tools from a Registry.
+// nolint:revive
+type ToolCallOp struct {
+	registry *Registry
+}
+
+// NewToolCallOp creates a new ToolCallOp which calls tools from r and returns it.
+func NewToolCallOp(r *Registry) (*ToolCallOp, error) {
+	if r == nil {
+		return nil, fmt.Errorf("invalid registry: %v", r)
+	}
+
+	return &ToolCallOp{
+		registry: r,
+	}, nil
+}
+
+// Type returns Op type.
+func (op *ToolCallOp) Type() string { return "ToolCallOp" }
+
+// Desc returns Op description.
+func (op *ToolCallOp) Desc() string { return "ToolCallOp calls tools" }
+
+// String implements fmt.Stringer.
+func (op *ToolCallOp) String() string {
+	return fmt.Sprintf("Op: %s, Desc: %s", op.Type(), op.Desc())
+}
+
+// Do collects the tool calls stored under CallsKey in all inputs,
+// calls the requested tools in parallel and returns a Value per call.
+//
+// Each returned Value is a tool result message which contains
+// the tool call ID, the tool name and the JSON encoded tool output.
+// Failed tool calls do not fail the Op: their error message is returned
+// as the Value content and under ErrorKey so it can be fed back to a model.
+func (op *ToolCallOp) Do(ctx context.Context, inputs ...hypher.Value) ([]hypher.Value, error) {
+	var calls []Call
+	for _, in := range inputs {
+		c, err := CallsFromValue(in)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, c...)
+	}
+
+	results := op.registry.CallAll(ctx, calls)
+
+	outputs := make([]hypher.Value, 0, len(results))
+	for _, res := range results {
+		outputs = append(outputs, ResultToValue(res))
+	}
+
+	return outputs, nil
+}
+
+// ResultToValue converts tool call result to a tool result message Value.
+func ResultToValue(res Result) hypher.Value {
+	v := hypher.Value{
+		RoleKey:    RoleTool,
+		CallIDKey:  res.ID,
+		NameKey:    res.Name,
+		ContentKey: res.Content,
+	}
+	if res.Err != nil {
+		v[ErrorKey] = res.Err.Error()
+	}
+	return v
+}
+
+// ToolCallOpFactory returns hypher.OpFactory which creates
+// ToolCallOp calling tools from r. ToolCallOp has no spec.
+func ToolCallOpFactory(r *Registry) hypher.OpFactory {
+	return func([]byte) (hypher.Op, error) {
+		return NewToolCallOp(r)
+	}
+}
@@ -0,0 +1,54 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+func TestNewToolCallOp(t *testing.T) {
+	if _, err := NewToolCallOp(nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestToolCallOpDo(t *testing.T) {
+	op, err := NewToolCallOp(MustRegistry(t))
+	if err != nil {
+		t.Fatalf("failed to create op: %v", err)
+	}
+
+	outputs, err := op.Do(context.Background(),
+		hypher.Value{CallsKey: []Call{{ID: "1", Name: "add", Arguments: `{"a": 1, "b": 1}`}}},
+		hypher.Value{"ignored": true},
+		hypher.Value{CallsKey: []any{
+			map[string]any{IDKey: "2", NameKey: "add", ArgumentsKey: map[string]any{"a": 2, "b": 3}},
+			map[string]any{IDKey: "3", NameKey: "fail"},
+		}},
+	)
+	if err != nil {
+		t.Fatalf("failed to run op: %v", err)
+	}
+
+	exp := []hypher.Value{
+		{RoleKey: RoleTool, CallIDKey: "1", NameKey: "add", ContentKey: "2"},
+		{RoleKey: RoleTool, CallIDKey: "2", NameKey: "add", ContentKey: "5"},
+		{RoleKey: RoleTool, CallIDKey: "3", NameKey: "fail", ContentKey: "tool fail: boom", ErrorKey: "tool fail: boom"},
+	}
+
+	if len(outputs) != len(exp) {
+		t.Fatalf("expected %d outputs, got: %d", len(exp), len(outputs))
+	}
+	for i := range exp {
+		for k, v := range exp[i] {
+			if outputs[i][k] != v {
+				t.Errorf("output %d: expected %s: %v, got: %v", i, k, v, outputs[i][k])
+			}
+		}
+	}
+
+	if _, err := op.Do(context.Background(), hypher.Value{CallsKey: "invalid"}); err == nil {
+		t.Error("expected invalid tool calls error")
+	}
+}
@@ -0,0 +1,20 @@
+package tool
+
+import "time"
+
+// Options configure tools.
+type Options struct {
+	// Timeout configures tool call timeout.
+	Timeout time.Duration
+}
+
+// Option is functional tool option.
+type Option func(*Options)
+
+// WithTimeout sets Timeout.
+// Zero timeout means no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
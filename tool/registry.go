@@ -0,0 +1,209 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler handles tool calls with JSON encoded arguments
+// and returns the tool output which is encoded to JSON.
+type Handler func(ctx context.Context, args json.RawMessage) (any, error)
+
+// entry is a registered tool.
+type entry struct {
+	tool    Tool
+	handler Handler
+	timeout time.Duration
+}
+
+// Registry is a registry of tools.
+// It is safe for concurrent use.
+type Registry struct {
+	tools map[string]*entry
+	mu    sync.RWMutex
+}
+
+// NewRegistry creates a new tool registry and returns it.
+func NewRegistry() *Registry {
+	return &Registry{
+		tools: make(map[string]*entry),
+	}
+}
+
+// Add adds tool t handled by h to the registry.
+// The tool arguments are validated against t.Parameters
+// before they are passed to h.
+func (r *Registry) Add(t Tool, h Handler, opts ...Option) error {
+	if t.Name == "" {
+		return fmt.Errorf("missing tool name")
+	}
+	if h == nil {
+		return fmt.Errorf("invalid handler for tool %s", t.Name)
+	}
+	if t.Parameters == nil {
+		t.Parameters = &Schema{Type: TypeObject}
+	}
+
+	topts := Options{}
+	for _, apply := range opts {
+		apply(&topts)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tools[t.Name]; ok {
+		return fmt.Errorf("tool %s already registered", t.Name)
+	}
+
+	r.tools[t.Name] = &entry{
+		tool:    t,
+		handler: h,
+		timeout: topts.Timeout,
+	}
+
+	return nil
+}
+
+// Register registers fn as a tool with the given name and description.
+// The tool arguments JSON Schema is derived from In via SchemaFor.
+// The arguments are validated and decoded into In before fn is called.
+func Register[In, Out any](r *Registry, name, desc string, fn func(context.Context, In) (Out, error), opts ...Option) error {
+	schema, err := SchemaFor[In]()
+	if err != nil {
+		return fmt.Errorf("tool %s: %w", name, err)
+	}
+
+	h := func(ctx context.Context, args json.RawMessage) (any, error) {
+		var in In
+		if err := json.Unmarshal(args, &in); err != nil {
+			return nil, fmt.Errorf("decode arguments: %w", err)
+		}
+		return fn(ctx, in)
+	}
+
+	return r.Add(Tool{Name: name, Description: desc, Parameters: schema}, h, opts...)
+}
+
+// Tool returns the tool with the given name.
+func (r *Registry) Tool(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.tools[name]
+	if !ok {
+		return Tool{}, false
+	}
+	return e.tool, true
+}
+
+// Tools returns all registered tools sorted by name.
+func (r *Registry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.tools))
+	for _, e := range r.tools {
+		tools = append(tools, e.tool)
+	}
+	slices.SortFunc(tools, func(a, b Tool) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return tools
+}
+
+// Call calls the tool requested by c and returns the result.
+// If the tool was registered with a timeout, the call is canceled
+// when the timeout expires.
+func (r *Registry) Call(ctx context.Context, c Call) Result {
+	res := Result{ID: c.ID, Name: c.Name}
+
+	content, err := r.call(ctx, c)
+	if err != nil {
+		res.Err = fmt.Errorf("tool %s: %w", c.Name, err)
+		res.Content = res.Err.Error()
+		return res
+	}
+	res.Content = content
+
+	return res
+}
+
+func (r *Registry) call(ctx context.Context, c Call) (string, error) {
+	r.mu.RLock()
+	e, ok := r.tools[c.Name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("not found")
+	}
+
+	args := json.RawMessage(c.Arguments)
+	if len(strings.TrimSpace(c.Arguments)) == 0 {
+		args = json.RawMessage("{}")
+	}
+
+	if err := e.tool.Parameters.Validate(args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	type output struct {
+		val any
+		err error
+	}
+
+	// NOTE: the handler runs in its own goroutine so the call
+	// returns on timeout even if the handler ignores ctx.
+	outChan := make(chan output, 1)
+	go func() {
+		val, err := e.handler(ctx, args)
+		outChan <- output{val: val, err: err}
+	}()
+
+	var out output
+	select {
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	case out = <-outChan:
+	}
+
+	if out.err != nil {
+		return "", out.err
+	}
+
+	b, err := json.Marshal(out.val)
+	if err != nil {
+		return "", fmt.Errorf("encode output: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// CallAll calls all tools requested by calls in parallel
+// and returns their results in the order of calls.
+func (r *Registry) CallAll(ctx context.Context, calls []Call) []Result {
+	results := make([]Result, len(calls))
+
+	var wg sync.WaitGroup
+	for i, c := range calls {
+		wg.Add(1)
+		go func(i int, c Call) {
+			defer wg.Done()
+			results[i] = r.Call(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	return results
+}
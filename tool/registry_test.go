@@ -0,0 +1,144 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type addArgs struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func add(_ context.Context, args addArgs) (int, error) {
+	return args.A + args.B, nil
+}
+
+func MustRegistry(t *testing.T) *Registry {
+	r := NewRegistry()
+
+	if err := Register(r, "add", "Add two numbers", add); err != nil {
+		t.Fatalf("failed to register tool: %v", err)
+	}
+
+	if err := Register(r, "fail", "Always fails", func(context.Context, struct{}) (any, error) {
+		return nil, errors.New("boom")
+	}); err != nil {
+		t.Fatalf("failed to register tool: %v", err)
+	}
+
+	if err := Register(r, "sleep", "Sleeps", func(ctx context.Context, _ struct{}) (any, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return "awake", nil
+		}
+	}, WithTimeout(10*time.Millisecond)); err != nil {
+		t.Fatalf("failed to register tool: %v", err)
+	}
+
+	return r
+}
+
+func TestRegistryAdd(t *testing.T) {
+	r := MustRegistry(t)
+
+	if err := Register(r, "add", "Add again", add); err == nil {
+		t.Error("expected duplicate tool error")
+	}
+	if err := r.Add(Tool{}, func(context.Context, json.RawMessage) (any, error) { return nil, nil }); err == nil {
+		t.Error("expected missing name error")
+	}
+	if err := r.Add(Tool{Name: "nil"}, nil); err == nil {
+		t.Error("expected invalid handler error")
+	}
+
+	tools := r.Tools()
+	if len(tools) != 3 {
+		t.Fatalf("expected 3 tools, got: %d", len(tools))
+	}
+	for i, name := range []string{"add", "fail", "sleep"} {
+		if tools[i].Name != name {
+			t.Errorf("expected tool %d: %s, got: %s", i, name, tools[i].Name)
+		}
+	}
+
+	tool, ok := r.Tool("add")
+	if !ok {
+		t.Fatal("tool add not found")
+	}
+	if tool.Description != "Add two numbers" || len(tool.Parameters.Required) != 2 {
+		t.Errorf("unexpected tool: %#v", tool)
+	}
+}
+
+func TestRegistryCall(t *testing.T) {
+	r := MustRegistry(t)
+
+	testCases := []struct {
+		name    string
+		call    Call
+		content string
+		errMsg  string
+	}{
+		{"OK", Call{ID: "1", Name: "add", Arguments: `{"a": 1, "b": 2}`}, "3", ""},
+		{"NotFound", Call{ID: "2", Name: "sub"}, "", "not found"},
+		{"InvalidArgs", Call{ID: "3", Name: "add", Arguments: `{"a": "1", "b": 2}`}, "", "invalid arguments"},
+		{"Failed", Call{ID: "4", Name: "fail"}, "", "boom"},
+		{"Timeout", Call{ID: "5", Name: "sleep"}, "", "deadline exceeded"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := r.Call(context.Background(), tc.call)
+			if res.ID != tc.call.ID || res.Name != tc.call.Name {
+				t.Errorf("unexpected result call: %s/%s", res.ID, res.Name)
+			}
+			if tc.errMsg != "" {
+				if res.Err == nil || !strings.Contains(res.Err.Error(), tc.errMsg) {
+					t.Fatalf("expected error %q, got: %v", tc.errMsg, res.Err)
+				}
+				if res.Content != res.Err.Error() {
+					t.Errorf("expected error content, got: %s", res.Content)
+				}
+				return
+			}
+			if res.Err != nil {
+				t.Fatalf("unexpected error: %v", res.Err)
+			}
+			if res.Content != tc.content {
+				t.Errorf("expected content: %s, got: %s", tc.content, res.Content)
+			}
+		})
+	}
+}
+
+func TestRegistryCallAll(t *testing.T) {
+	r := MustRegistry(t)
+
+	calls := []Call{
+		{ID: "1", Name: "sleep"},
+		{ID: "2", Name: "add", Arguments: `{"a": 2, "b": 2}`},
+	}
+
+	start := time.Now()
+	results := r.CallAll(context.Background(), calls)
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("calls took too long: %v", d)
+	}
+
+	if len(results) != len(calls) {
+		t.Fatalf("expected %d results, got: %d", len(calls), len(results))
+	}
+	if results[0].ID != "1" || results[0].Err == nil {
+		t.Errorf("unexpected result: %#v", results[0])
+	}
+	if results[1].ID != "2" || results[1].Content != "4" {
+		t.Errorf("unexpected result: %#v", results[1])
+	}
+}
@@ -0,0 +1,303 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// JSON Schema types.
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Schema is a subset of JSON Schema used to describe tool arguments.
+type Schema struct {
+	// Type is the JSON type. Empty type accepts any value.
+	Type string `json:"type,omitempty"`
+	// Description describes the value.
+	Description string `json:"description,omitempty"`
+	// Properties are object properties.
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// Required lists required object properties.
+	Required []string `json:"required,omitempty"`
+	// AdditionalProperties is either a bool or a *Schema
+	// of the values of object properties not in Properties.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+	// Items is the schema of array items.
+	Items *Schema `json:"items,omitempty"`
+	// Enum lists allowed values.
+	Enum []any `json:"enum,omitempty"`
+}
+
+// SchemaFor derives JSON Schema from type T.
+//
+// Struct fields are mapped to object properties using their json tags.
+// Fields tagged with omitempty and pointer fields are optional, all the
+// other fields are required. Field descriptions are read from the desc tag
+// and allowed values from the comma separated enum tag. The enum values
+// are parsed into the field JSON type. time.Time is mapped to string.
+func SchemaFor[T any]() (*Schema, error) {
+	return SchemaOf(reflect.TypeFor[T]())
+}
+
+// SchemaOf derives JSON Schema from type t.
+// See SchemaFor for details.
+func SchemaOf(t reflect.Type) (*Schema, error) {
+	return schemaOf(t, make(map[reflect.Type]bool))
+}
+
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// time.Time is encoded as RFC 3339 string.
+	if t == timeType {
+		return &Schema{Type: TypeString}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: TypeString}, nil
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TypeString}, nil
+		}
+		items, err := schemaOf(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: TypeArray, Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type: %s", t.Key())
+		}
+		values, err := schemaOf(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: TypeObject, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if seen[t] {
+			return nil, fmt.Errorf("recursive type: %s", t)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return structSchema(t, seen)
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", t)
+	}
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) (*Schema, error) {
+	s := &Schema{
+		Type:                 TypeObject,
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+
+		name, omitempty := f.Name, false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			n, opts, _ := strings.Cut(tag, ",")
+			if n != "" {
+				name = n
+			}
+			omitempty = slices.Contains(strings.Split(opts, ","), "omitempty")
+		}
+
+		fs, err := schemaOf(f.Type, seen)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		fs.Description = f.Tag.Get("desc")
+		if enum := f.Tag.Get("enum"); enum != "" {
+			// enums of array fields restrict array items
+			es := fs
+			if fs.Type == TypeArray && fs.Items != nil {
+				es = fs.Items
+			}
+			if es.Enum, err = parseEnum(es.Type, enum); err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+		}
+
+		s.Properties[name] = fs
+		if !omitempty && f.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s, nil
+}
+
+// parseEnum parses comma separated enum values of JSON type typ.
+func parseEnum(typ, enum string) ([]any, error) {
+	var values []any
+	for _, e := range strings.Split(enum, ",") {
+		var (
+			v   any
+			err error
+		)
+		switch typ {
+		case TypeString:
+			v = e
+		case TypeInteger:
+			v, err = strconv.ParseInt(e, 10, 64)
+		case TypeNumber:
+			v, err = strconv.ParseFloat(e, 64)
+		case TypeBoolean:
+			v, err = strconv.ParseBool(e)
+		default:
+			return nil, fmt.Errorf("unsupported enum type: %q", typ)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s enum value %q: %w", typ, e, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// enumEqual reports whether enum value e equals decoded JSON value v.
+// Numbers are compared by their values.
+func enumEqual(e, v any) bool {
+	if f, ok := v.(float64); ok {
+		switch e := e.(type) {
+		case int64:
+			return float64(e) == f
+		case int:
+			return float64(e) == f
+		case float64:
+			return e == f
+		}
+	}
+	return reflect.DeepEqual(e, v)
+}
+
+// Validate validates JSON encoded data against the schema.
+func (s *Schema) Validate(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return s.validate("$", v)
+}
+
+func (s *Schema) validate(path string, v any) error {
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool {
+		return enumEqual(e, v)
+	}) {
+		return fmt.Errorf("%s: value %v not in %v", path, v, s.Enum)
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case TypeString:
+		if _, ok := v.(string); !ok {
+			return typeError(path, s.Type, v)
+		}
+	case TypeBoolean:
+		if _, ok := v.(bool); !ok {
+			return typeError(path, s.Type, v)
+		}
+	case TypeNumber:
+		if _, ok := v.(float64); !ok {
+			return typeError(path, s.Type, v)
+		}
+	case TypeInteger:
+		f, ok := v.(float64)
+		if !ok || f != float64(int64(f)) {
+			return typeError(path, s.Type, v)
+		}
+	case TypeArray:
+		items, ok := v.([]any)
+		if !ok {
+			return typeError(path, s.Type, v)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case TypeObject:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return typeError(path, s.Type, v)
+		}
+		return s.validateObject(path, obj)
+	default:
+		return fmt.Errorf("%s: unsupported schema type: %s", path, s.Type)
+	}
+
+	return nil
+}
+
+func (s *Schema) validateObject(path string, obj map[string]any) error {
+	for _, r := range s.Required {
+		if _, ok := obj[r]; !ok {
+			return fmt.Errorf("%s: missing required property: %s", path, r)
+		}
+	}
+
+	for k, val := range obj {
+		p := path + "." + k
+		if ps, ok := s.Properties[k]; ok {
+			// optional properties can be null
+			if val == nil && !slices.Contains(s.Required, k) {
+				continue
+			}
+			if err := ps.validate(p, val); err != nil {
+				return err
+			}
+			continue
+		}
+		switch ap := s.AdditionalProperties.(type) {
+		case bool:
+			if !ap {
+				return fmt.Errorf("%s: unknown property", p)
+			}
+		case *Schema:
+			if err := ap.validate(p, val); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func typeError(path, typ string, v any) error {
+	return fmt.Errorf("%s: expected %s, got %T", path, typ, v)
+}
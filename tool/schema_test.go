@@ -0,0 +1,143 @@
+package tool
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testArgs struct {
+	City    string              `json:"city" desc:"City name"`
+	Unit    string              `json:"unit,omitempty" enum:"C,F"`
+	Days    int                 `json:"days"`
+	Detail  *bool               `json:"detail"`
+	Tags    []string            `json:"tags,omitempty"`
+	Extra   map[string]any      `json:"extra,omitempty"`
+	Ignored string              `json:"-"`
+	Nested  struct{ X float64 } `json:"nested,omitempty"`
+	Level   int                 `json:"level,omitempty" enum:"1,2"`
+	Ratio   float64             `json:"ratio,omitempty" enum:"0.5,1"`
+	Strict  bool                `json:"strict,omitempty" enum:"true"`
+	Modes   []string            `json:"modes,omitempty" enum:"fast,slow"`
+	Since   time.Time           `json:"since,omitempty"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	s, err := SchemaFor[testArgs]()
+	if err != nil {
+		t.Fatalf("failed to derive schema: %v", err)
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to unmarshal schema: %v", err)
+	}
+
+	exp := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"city":   map[string]any{"type": "string", "description": "City name"},
+			"unit":   map[string]any{"type": "string", "enum": []any{"C", "F"}},
+			"days":   map[string]any{"type": "integer"},
+			"detail": map[string]any{"type": "boolean"},
+			"tags":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"extra":  map[string]any{"type": "object", "additionalProperties": map[string]any{}},
+			"nested": map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"X": map[string]any{"type": "number"}},
+				"required":             []any{"X"},
+				"additionalProperties": false,
+			},
+			"level":  map[string]any{"type": "integer", "enum": []any{1.0, 2.0}},
+			"ratio":  map[string]any{"type": "number", "enum": []any{0.5, 1.0}},
+			"strict": map[string]any{"type": "boolean", "enum": []any{true}},
+			"modes": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string", "enum": []any{"fast", "slow"}},
+			},
+			"since": map[string]any{"type": "string"},
+		},
+		"required":             []any{"city", "days"},
+		"additionalProperties": false,
+	}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected schema:\n%v\ngot:\n%v", exp, got)
+	}
+}
+
+func TestSchemaForUnsupported(t *testing.T) {
+	type rec struct {
+		Next *rec `json:"next"`
+	}
+
+	if _, err := SchemaFor[rec](); err == nil {
+		t.Error("expected recursive type error")
+	}
+	if _, err := SchemaFor[map[int]string](); err == nil {
+		t.Error("expected map key error")
+	}
+	if _, err := SchemaFor[chan int](); err == nil {
+		t.Error("expected unsupported type error")
+	}
+	if _, err := SchemaFor[struct {
+		N int `json:"n" enum:"1,x"`
+	}](); err == nil {
+		t.Error("expected invalid enum value error")
+	}
+	if _, err := SchemaFor[struct {
+		M map[string]int `json:"m" enum:"a"`
+	}](); err == nil {
+		t.Error("expected unsupported enum type error")
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s, err := SchemaFor[testArgs]()
+	if err != nil {
+		t.Fatalf("failed to derive schema: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"Valid", `{"city": "Oslo", "days": 2, "unit": "C", "tags": ["a"], "extra": {"k": 1}}`, false},
+		{"NullOptional", `{"city": "Oslo", "days": 2, "detail": null}`, false},
+		{"InvalidJSON", `{`, true},
+		{"NotObject", `[]`, true},
+		{"MissingRequired", `{"city": "Oslo"}`, true},
+		{"WrongType", `{"city": 1, "days": 2}`, true},
+		{"NotInteger", `{"city": "Oslo", "days": 2.5}`, true},
+		{"NotInEnum", `{"city": "Oslo", "days": 2, "unit": "K"}`, true},
+		{"IntEnum", `{"city": "Oslo", "days": 2, "level": 2, "ratio": 0.5, "strict": true}`, false},
+		{"IntNotInEnum", `{"city": "Oslo", "days": 2, "level": 3}`, true},
+		{"IntEnumString", `{"city": "Oslo", "days": 2, "level": "1"}`, true},
+		{"BoolNotInEnum", `{"city": "Oslo", "days": 2, "strict": false}`, true},
+		{"ItemEnum", `{"city": "Oslo", "days": 2, "modes": ["fast"]}`, false},
+		{"ItemNotInEnum", `{"city": "Oslo", "days": 2, "modes": ["warp"]}`, true},
+		{"Time", `{"city": "Oslo", "days": 2, "since": "2024-07-01T12:00:00Z"}`, false},
+		{"WrongItem", `{"city": "Oslo", "days": 2, "tags": [1]}`, true},
+		{"Unknown", `{"city": "Oslo", "days": 2, "foo": 1}`, true},
+		{"NestedUnknown", `{"city": "Oslo", "days": 2, "nested": {"X": 1, "Y": 2}}`, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.Validate([]byte(tc.data))
+			if tc.wantErr && err == nil {
+				t.Fatal("expected error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
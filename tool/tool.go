@@ -0,0 +1,138 @@
+// Package tool provides a registry of tools which can be called by agents.
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Value keys used by tool calls.
+const (
+	// CallsKey is the key of a list of tool calls.
+	CallsKey = "tool_calls"
+	// CallIDKey is the tool call ID key.
+	CallIDKey = "tool_call_id"
+	// IDKey is the tool call ID key in a tool call.
+	IDKey = "id"
+	// NameKey is the tool name key.
+	NameKey = "name"
+	// ArgumentsKey is the tool call arguments key.
+	ArgumentsKey = "arguments"
+	// RoleKey is the tool result message role key.
+	RoleKey = "role"
+	// ContentKey is the tool result content key.
+	ContentKey = "content"
+	// ErrorKey is the tool call error key.
+	ErrorKey = "error"
+)
+
+// RoleTool is the role of tool result messages.
+const RoleTool = "tool"
+
+// Tool is a tool definition.
+type Tool struct {
+	// Name is the tool name.
+	Name string `json:"name"`
+	// Description describes what the tool does.
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON Schema of the tool arguments.
+	Parameters *Schema `json:"parameters"`
+}
+
+// Call is a tool call request.
+type Call struct {
+	// ID is the tool call ID.
+	ID string `json:"id"`
+	// Name is the name of the called tool.
+	Name string `json:"name"`
+	// Arguments are JSON encoded tool arguments.
+	Arguments string `json:"arguments"`
+}
+
+// Result is a tool call result.
+type Result struct {
+	// ID is the tool call ID.
+	ID string
+	// Name is the name of the called tool.
+	Name string
+	// Content is JSON encoded tool output.
+	// If the call failed it contains the error message.
+	Content string
+	// Err is the tool call error.
+	Err error
+}
+
+// CallsFromValue returns the tool calls stored in v under CallsKey.
+// It returns nil if v contains no tool calls.
+func CallsFromValue(v map[string]any) ([]Call, error) {
+	c, ok := v[CallsKey]
+	if !ok {
+		return nil, nil
+	}
+
+	switch calls := c.(type) {
+	case []Call:
+		return calls, nil
+	case []map[string]any:
+		res := make([]Call, 0, len(calls))
+		for _, m := range calls {
+			call, err := callFromMap(m)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, call)
+		}
+		return res, nil
+	case []any:
+		res := make([]Call, 0, len(calls))
+		for _, cv := range calls {
+			switch cc := cv.(type) {
+			case Call:
+				res = append(res, cc)
+			case map[string]any:
+				call, err := callFromMap(cc)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, call)
+			default:
+				return nil, fmt.Errorf("invalid tool call: %T", cv)
+			}
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("invalid tool calls: %T", c)
+	}
+}
+
+// callFromMap converts m to a tool call.
+// Arguments can be either a JSON string or a JSON object.
+func callFromMap(m map[string]any) (Call, error) {
+	name, ok := m[NameKey].(string)
+	if !ok {
+		return Call{}, fmt.Errorf("invalid tool call name: %T", m[NameKey])
+	}
+
+	var id string
+	if i, ok := m[IDKey]; ok {
+		if id, ok = i.(string); !ok {
+			return Call{}, fmt.Errorf("invalid tool call id: %T", i)
+		}
+	}
+
+	var args string
+	switch a := m[ArgumentsKey].(type) {
+	case nil:
+		args = "{}"
+	case string:
+		args = a
+	default:
+		b, err := json.Marshal(a)
+		if err != nil {
+			return Call{}, fmt.Errorf("invalid tool call arguments: %w", err)
+		}
+		args = string(b)
+	}
+
+	return Call{ID: id, Name: name, Arguments: args}, nil
+}
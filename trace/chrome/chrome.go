@@ -0,0 +1,201 @@
+// Package chrome exports hypher run results in Chrome Trace Event format.
+//
+// The exported traces can be opened in chrome://tracing or in Perfetto.
+// See: https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+package chrome
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+const (
+	// CriticalColor is the color of the critical path events.
+	CriticalColor = "terrible"
+	// FailedColor is the color of the failed node events.
+	FailedColor = "bad"
+	// CriticalPathName is the name of the critical path flow events.
+	CriticalPathName = "critical path"
+)
+
+// Event is a Chrome trace event.
+type Event struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Ph    string         `json:"ph"`
+	Ts    float64        `json:"ts"`
+	Dur   float64        `json:"dur,omitempty"`
+	Pid   int            `json:"pid"`
+	Tid   int            `json:"tid"`
+	ID    int            `json:"id,omitempty"`
+	Bp    string         `json:"bp,omitempty"`
+	Cname string         `json:"cname,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// Trace is a Chrome trace.
+type Trace struct {
+	TraceEvents     []Event        `json:"traceEvents"`
+	DisplayTimeUnit string         `json:"displayTimeUnit"`
+	OtherData       map[string]any `json:"otherData,omitempty"`
+}
+
+// Marshaler marshals run results to Chrome Trace Event JSON.
+type Marshaler struct {
+	prefix string
+	indent string
+}
+
+// NewMarshaler creates a new Marshaler and returns it.
+func NewMarshaler(prefix, indent string) (*Marshaler, error) {
+	return &Marshaler{
+		prefix: prefix,
+		indent: indent,
+	}, nil
+}
+
+// Marshal marshals run result r into Chrome Trace Event JSON.
+func (m *Marshaler) Marshal(r *graph.RunResult) ([]byte, error) {
+	if r == nil {
+		return nil, fmt.Errorf("invalid run result: %v", r)
+	}
+
+	return json.MarshalIndent(NewTrace(r), m.prefix, m.indent)
+}
+
+// NewTrace creates a new Chrome trace from run result r and returns it.
+// Every executed node is a complete event in the lane it was executed in:
+// a graph level in hypher.ConcLevelMode or a goroutine in hypher.ConcAllMode.
+// The nodes on the run critical path are annotated and linked by flow events.
+func NewTrace(r *graph.RunResult) *Trace {
+	nodes := make([]*graph.NodeResult, 0, len(r.Nodes))
+	for _, n := range r.Nodes {
+		if !n.Start.IsZero() {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Start.Equal(nodes[j].Start) {
+			return nodes[i].UID < nodes[j].UID
+		}
+		return nodes[i].Start.Before(nodes[j].Start)
+	})
+
+	critical := make(map[string]int)
+	path := r.CriticalPath()
+	for i, uid := range path {
+		critical[uid] = i
+	}
+
+	events := []Event{{
+		Name: "process_name",
+		Ph:   "M",
+		Pid:  1,
+		Args: map[string]any{"name": fmt.Sprintf("Run %s", r.ID)},
+	}}
+
+	lanes := make(map[int]bool)
+	for _, n := range nodes {
+		if !lanes[n.Lane] {
+			lanes[n.Lane] = true
+			events = append(events, Event{
+				Name: "thread_name",
+				Ph:   "M",
+				Pid:  1,
+				Tid:  n.Lane,
+				Args: map[string]any{"name": laneName(r.ConcMode, n.Lane)},
+			})
+		}
+
+		args := map[string]any{
+			"uid":        n.UID,
+			"inputs":     len(n.Inputs),
+			"outputs":    len(n.Outputs),
+			"queue_wait": micros(n.Start.Sub(n.Scheduled)),
+		}
+
+		e := Event{
+			Name: n.Label,
+			Cat:  n.OpType,
+			Ph:   "X",
+			Ts:   micros(n.Start.Sub(r.Start)),
+			Dur:  micros(n.Duration()),
+			Pid:  1,
+			Tid:  n.Lane,
+			Args: args,
+		}
+
+		if _, ok := critical[n.UID]; ok {
+			args["critical_path"] = true
+			e.Cname = CriticalColor
+		}
+
+		if n.Err != nil {
+			args["error"] = n.Err.Error()
+			e.Cname = FailedColor
+		}
+
+		events = append(events, e)
+	}
+
+	// link the critical path nodes with flow events
+	for i := 1; i < len(path); i++ {
+		from, to := r.Nodes[path[i-1]], r.Nodes[path[i]]
+		events = append(events,
+			Event{
+				Name: CriticalPathName,
+				Cat:  CriticalPathName,
+				Ph:   "s",
+				Ts:   micros(from.End.Sub(r.Start)),
+				Pid:  1,
+				Tid:  from.Lane,
+				ID:   i,
+			},
+			Event{
+				Name: CriticalPathName,
+				Cat:  CriticalPathName,
+				Ph:   "f",
+				Bp:   "e",
+				Ts:   micros(to.Start.Sub(r.Start)),
+				Pid:  1,
+				Tid:  to.Lane,
+				ID:   i,
+			},
+		)
+	}
+
+	otherData := map[string]any{
+		"run_id":        r.ID,
+		"graph_uid":     r.GraphUID,
+		"conc_mode":     r.ConcMode.String(),
+		"duration":      micros(r.Duration()),
+		"critical_path": path,
+	}
+	if r.Err != nil {
+		otherData["error"] = r.Err.Error()
+	}
+
+	return &Trace{
+		TraceEvents:     events,
+		DisplayTimeUnit: "ms",
+		OtherData:       otherData,
+	}
+}
+
+// laneName returns the name of the lane for the given concurrency mode.
+func laneName(mode hypher.ConcMode, lane int) string {
+	if mode == hypher.ConcAllMode {
+		return fmt.Sprintf("Goroutine %d", lane)
+	}
+	return fmt.Sprintf("Level %d", lane)
+}
+
+// micros returns d in microseconds.
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
@@ -0,0 +1,87 @@
+package chrome
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+func TestMarshal(t *testing.T) {
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	res := &graph.RunResult{
+		ID:       "run",
+		GraphUID: "graph",
+		ConcMode: hypher.ConcAllMode,
+		Start:    start,
+		End:      at(60),
+		Nodes: map[string]*graph.NodeResult{
+			"a": {UID: "a", Label: "A", Lane: 0, Scheduled: at(0), Start: at(0), End: at(10)},
+			"b": {UID: "b", Label: "B", Lane: 1, Deps: []string{"a"}, Scheduled: at(0), Start: at(10), End: at(50)},
+			"c": {UID: "c", Label: "C", Lane: 2, Deps: []string{"a"}, Scheduled: at(0), Start: at(10), End: at(20), Err: errors.New("boom")},
+			"d": {UID: "d", Label: "D", Lane: 3, Deps: []string{"b"}, Scheduled: at(0)},
+		},
+	}
+
+	m, err := NewMarshaler("", "  ")
+	if err != nil {
+		t.Fatalf("failed to create marshaler: %v", err)
+	}
+
+	b, err := m.Marshal(res)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	var tr Trace
+	if err := json.Unmarshal(b, &tr); err != nil {
+		t.Fatalf("failed to unmarshal trace: %v", err)
+	}
+
+	complete := make(map[string]Event)
+	var flows, lanes int
+	for _, e := range tr.TraceEvents {
+		switch e.Ph {
+		case "X":
+			complete[e.Args["uid"].(string)] = e
+		case "s", "f":
+			flows++
+		case "M":
+			if e.Name == "thread_name" {
+				lanes++
+			}
+		}
+	}
+
+	if len(complete) != 3 {
+		t.Fatalf("expected 3 complete events, got: %d", len(complete))
+	}
+	if lanes != 3 {
+		t.Errorf("expected 3 lanes, got: %d", lanes)
+	}
+	if flows != 2 {
+		t.Errorf("expected 2 flow events, got: %d", flows)
+	}
+
+	if b := complete["b"]; b.Ts != 10000 || b.Dur != 40000 || b.Tid != 1 || b.Cname != CriticalColor {
+		t.Errorf("unexpected event: %#v", b)
+	}
+	if c := complete["c"]; c.Cname != FailedColor || c.Args["error"] != "boom" {
+		t.Errorf("unexpected event: %#v", c)
+	}
+	if _, ok := complete["a"].Args["critical_path"]; !ok {
+		t.Errorf("expected node a to be on critical path")
+	}
+	if _, ok := complete["c"].Args["critical_path"]; ok {
+		t.Errorf("node c must not be on critical path")
+	}
+
+	if _, err := m.Marshal(nil); err == nil {
+		t.Error("expected error marshaling nil result")
+	}
+}
@@ -0,0 +1,56 @@
+package trace
+
+import (
+	"context"
+	"sync"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// MemoryExporter is an OpenTelemetry span exporter
+// which stores the exported spans in memory.
+// It's useful for testing and debugging.
+type MemoryExporter struct {
+	spans []sdktrace.ReadOnlySpan
+	mu    sync.RWMutex
+}
+
+// NewMemoryExporter creates a new in-memory exporter and returns it.
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{
+		spans: []sdktrace.ReadOnlySpan{},
+	}
+}
+
+// ExportSpans stores spans in memory.
+func (e *MemoryExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// Shutdown shuts down the exporter.
+func (e *MemoryExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// Spans returns the exported spans.
+func (e *MemoryExporter) Spans() []sdktrace.ReadOnlySpan {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	spans := make([]sdktrace.ReadOnlySpan, len(e.spans))
+	copy(spans, e.spans)
+
+	return spans
+}
+
+// Reset removes all the exported spans.
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = []sdktrace.ReadOnlySpan{}
+}
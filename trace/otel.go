@@ -0,0 +1,113 @@
+// Package trace provides OpenTelemetry tracing of hypher runs.
+package trace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/milosgajdos/go-hypher"
+)
+
+const (
+	// InstrumentationName is the OpenTelemetry instrumentation name.
+	InstrumentationName = "github.com/milosgajdos/go-hypher"
+)
+
+// Tracer is an OpenTelemetry hypher.Tracer adapter.
+type Tracer struct {
+	tracer oteltrace.Tracer
+}
+
+// NewTracer creates a new OpenTelemetry tracer and returns it.
+// If tp is nil, the global OpenTelemetry TracerProvider is used.
+func NewTracer(tp oteltrace.TracerProvider) (*Tracer, error) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return &Tracer{
+		tracer: tp.Tracer(InstrumentationName),
+	}, nil
+}
+
+// Start starts a new OpenTelemetry span linked to the given links.
+// Links which are not OpenTelemetry spans are ignored.
+// The returned context carries the new span.
+func (t *Tracer) Start(ctx context.Context, name string, links ...hypher.Span) (context.Context, hypher.Span) {
+	otelLinks := make([]oteltrace.Link, 0, len(links))
+	for _, l := range links {
+		if s, ok := l.(*Span); ok {
+			otelLinks = append(otelLinks, oteltrace.Link{
+				SpanContext: s.span.SpanContext(),
+			})
+		}
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, oteltrace.WithLinks(otelLinks...))
+
+	return ctx, &Span{span: span}
+}
+
+// Span is an OpenTelemetry hypher.Span adapter.
+type Span struct {
+	span oteltrace.Span
+}
+
+// SpanContext returns OpenTelemetry span context.
+func (s *Span) SpanContext() oteltrace.SpanContext {
+	return s.span.SpanContext()
+}
+
+// SetAttrs sets span attributes.
+func (s *Span) SetAttrs(attrs map[string]any) {
+	s.span.SetAttributes(toKeyValues(attrs)...)
+}
+
+// RecordError records err and sets the span status to error.
+func (s *Span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End ends the span.
+func (s *Span) End() {
+	s.span.End()
+}
+
+// toKeyValues converts attrs to OpenTelemetry attributes.
+// Values of unsupported types are converted to strings.
+func toKeyValues(attrs map[string]any) []attribute.KeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, k := range keys {
+		switch v := attrs[k].(type) {
+		case string:
+			kvs = append(kvs, attribute.String(k, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(k, v))
+		case int:
+			kvs = append(kvs, attribute.Int(k, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(k, v))
+		case float64:
+			kvs = append(kvs, attribute.Float64(k, v))
+		case []string:
+			kvs = append(kvs, attribute.StringSlice(k, v))
+		default:
+			kvs = append(kvs, attribute.String(k, fmt.Sprint(v)))
+		}
+	}
+
+	return kvs
+}
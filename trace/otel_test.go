@@ -0,0 +1,159 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/milosgajdos/go-hypher"
+	"github.com/milosgajdos/go-hypher/graph"
+)
+
+// spanOp records the span IDs found in the Do context.
+type spanOp struct {
+	mu    sync.Mutex
+	spans map[oteltrace.SpanID]bool
+}
+
+func (op *spanOp) Type() string   { return "spanOp" }
+func (op *spanOp) Desc() string   { return "spanOp records span IDs" }
+func (op *spanOp) String() string { return "spanOp" }
+
+func (op *spanOp) Do(ctx context.Context, _ ...hypher.Value) ([]hypher.Value, error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	op.spans[oteltrace.SpanContextFromContext(ctx).SpanID()] = true
+	return []hypher.Value{{"foo": "bar"}}, nil
+}
+
+func attrValue(attrs []attribute.KeyValue, key string) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracer(t *testing.T) {
+	for _, mode := range []hypher.ConcMode{hypher.ConcLevelMode, hypher.ConcAllMode} {
+		t.Run(mode.String(), func(t *testing.T) {
+			exp := NewMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+			tracer, err := NewTracer(tp)
+			if err != nil {
+				t.Fatalf("failed to create tracer: %v", err)
+			}
+
+			g, err := graph.NewGraph()
+			if err != nil {
+				t.Fatalf("failed to create graph: %v", err)
+			}
+
+			op := &spanOp{spans: make(map[oteltrace.SpanID]bool)}
+			nodes := make([]*graph.Node, 4)
+			for i := range nodes {
+				nodes[i], err = g.NewNode(hypher.WithOp(op))
+				if err != nil {
+					t.Fatalf("failed to create node: %v", err)
+				}
+			}
+			for _, e := range [][2]int{{0, 1}, {0, 2}, {1, 3}, {2, 3}} {
+				if _, err := g.NewEdge(nodes[e[0]], nodes[e[1]]); err != nil {
+					t.Fatalf("failed to create edge: %v", err)
+				}
+			}
+			g.SetInputs([]*graph.Node{nodes[0]})
+			g.SetOutputs([]*graph.Node{nodes[3]})
+
+			inputs := map[string]hypher.Value{nodes[0].UID(): {"in": 1}}
+			if err := g.Run(context.Background(), inputs, hypher.WithConcMode(mode), hypher.WithTracer(tracer)); err != nil {
+				t.Fatalf("failed to run graph: %v", err)
+			}
+
+			spans := exp.Spans()
+			if len(spans) != len(nodes)+1 {
+				t.Fatalf("expected %d spans, got: %d", len(nodes)+1, len(spans))
+			}
+
+			var runSpan sdktrace.ReadOnlySpan
+			execSpans := make(map[string]sdktrace.ReadOnlySpan)
+			for _, s := range spans {
+				switch s.Name() {
+				case graph.RunSpanName:
+					runSpan = s
+				case graph.ExecSpanName:
+					uid, ok := attrValue(s.Attributes(), graph.AttrNodeUID)
+					if !ok {
+						t.Fatalf("missing %s attribute", graph.AttrNodeUID)
+					}
+					execSpans[uid.AsString()] = s
+				default:
+					t.Fatalf("unexpected span: %s", s.Name())
+				}
+			}
+
+			if runSpan == nil {
+				t.Fatal("missing run span")
+			}
+			if uid, _ := attrValue(runSpan.Attributes(), graph.AttrGraphUID); uid.AsString() != g.UID() {
+				t.Errorf("expected graph UID: %s, got: %s", g.UID(), uid.AsString())
+			}
+
+			for _, n := range nodes {
+				s, ok := execSpans[n.UID()]
+				if !ok {
+					t.Fatalf("missing exec span for node %s", n.UID())
+				}
+				if s.Parent().SpanID() != runSpan.SpanContext().SpanID() {
+					t.Errorf("node %s: exec span is not a child of run span", n.UID())
+				}
+				if !op.spans[s.SpanContext().SpanID()] {
+					t.Errorf("node %s: exec span not propagated to Op", n.UID())
+				}
+				if opType, _ := attrValue(s.Attributes(), graph.AttrOpType); opType.AsString() != op.Type() {
+					t.Errorf("node %s: expected op type: %s, got: %s", n.UID(), op.Type(), opType.AsString())
+				}
+				if outs, _ := attrValue(s.Attributes(), graph.AttrOutputs); outs.AsInt64() != 1 {
+					t.Errorf("node %s: expected 1 output, got: %d", n.UID(), outs.AsInt64())
+				}
+			}
+
+			links := execSpans[nodes[3].UID()].Links()
+			if len(links) != 2 {
+				t.Fatalf("expected 2 links, got: %d", len(links))
+			}
+			for _, l := range links {
+				id := l.SpanContext.SpanID()
+				if id != execSpans[nodes[1].UID()].SpanContext().SpanID() &&
+					id != execSpans[nodes[2].UID()].SpanContext().SpanID() {
+					t.Errorf("unexpected span link: %s", id)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryExporterReset(t *testing.T) {
+	exp := NewMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "foo")
+	span.End()
+
+	if n := len(exp.Spans()); n != 1 {
+		t.Fatalf("expected 1 span, got: %d", n)
+	}
+
+	exp.Reset()
+
+	if n := len(exp.Spans()); n != 0 {
+		t.Fatalf("expected 0 spans, got: %d", n)
+	}
+}
@@ -0,0 +1,191 @@
+package hypher
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// Usage is resource usage reported by Ops.
+type Usage struct {
+	// TokensIn is the number of input tokens.
+	TokensIn int64
+	// TokensOut is the number of output tokens.
+	TokensOut int64
+	// Cost is the monetary cost.
+	Cost float64
+	// Counters are arbitrary usage counters.
+	Counters map[string]float64
+}
+
+// Tokens returns the total number of tokens.
+func (u Usage) Tokens() int64 {
+	return u.TokensIn + u.TokensOut
+}
+
+// Add returns the sum of u and o.
+func (u Usage) Add(o Usage) Usage {
+	sum := Usage{
+		TokensIn:  u.TokensIn + o.TokensIn,
+		TokensOut: u.TokensOut + o.TokensOut,
+		Cost:      u.Cost + o.Cost,
+	}
+
+	if len(u.Counters) > 0 || len(o.Counters) > 0 {
+		sum.Counters = maps.Clone(u.Counters)
+		if sum.Counters == nil {
+			sum.Counters = make(map[string]float64)
+		}
+		for k, v := range o.Counters {
+			sum.Counters[k] += v
+		}
+	}
+
+	return sum
+}
+
+// Budget limits Usage.
+// Zero limits are unlimited.
+type Budget struct {
+	// MaxTokens limits the total number of tokens.
+	MaxTokens int64
+	// MaxCost limits the cost.
+	MaxCost float64
+	// MaxCounters limit the usage counters.
+	MaxCounters map[string]float64
+}
+
+// Exceeded returns true if u exceeds the budget.
+func (b Budget) Exceeded(u Usage) bool {
+	if b.MaxTokens > 0 && u.Tokens() > b.MaxTokens {
+		return true
+	}
+
+	if b.MaxCost > 0 && u.Cost > b.MaxCost {
+		return true
+	}
+
+	for k, limit := range b.MaxCounters {
+		if limit > 0 && u.Counters[k] > limit {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrBudgetExceeded is returned when Usage exceeds its Budget.
+type ErrBudgetExceeded struct {
+	// Budget is the exceeded budget.
+	Budget Budget
+	// Usage is the usage which exceeded the budget.
+	Usage Usage
+}
+
+// Error implements error.
+func (e *ErrBudgetExceeded) Error() string {
+	return fmt.Sprintf("budget exceeded: tokens: %d/%d, cost: %.4f/%.4f",
+		e.Usage.Tokens(), e.Budget.MaxTokens, e.Usage.Cost, e.Budget.MaxCost)
+}
+
+// Meter meters Usage.
+// The usage recorded in a Meter is also recorded in its parent.
+type Meter struct {
+	parent   *Meter
+	budget   Budget
+	usage    Usage
+	err      error
+	exceeded chan struct{}
+	mu       sync.RWMutex
+}
+
+// NewMeter creates a new Meter which enforces budget and returns it.
+func NewMeter(budget Budget) *Meter {
+	return &Meter{
+		budget:   budget,
+		exceeded: make(chan struct{}),
+	}
+}
+
+// Sub creates a new unlimited Meter whose parent is m and returns it.
+func (m *Meter) Sub() *Meter {
+	sub := NewMeter(Budget{})
+	sub.parent = m
+	return sub
+}
+
+// Record records u in m and all its parents.
+// It returns *ErrBudgetExceeded if the budget of m
+// or the budget of any of its parents is exceeded.
+func (m *Meter) Record(u Usage) error {
+	var err error
+	for meter := m; meter != nil; meter = meter.parent {
+		if merr := meter.record(u); merr != nil && err == nil {
+			err = merr
+		}
+	}
+	return err
+}
+
+func (m *Meter) record(u Usage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.usage = m.usage.Add(u)
+
+	if m.err == nil && m.budget.Exceeded(m.usage) {
+		m.err = &ErrBudgetExceeded{
+			Budget: m.budget,
+			Usage:  m.usage,
+		}
+		close(m.exceeded)
+	}
+
+	return m.err
+}
+
+// Usage returns the recorded usage.
+func (m *Meter) Usage() Usage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.usage.Add(Usage{})
+}
+
+// Err returns *ErrBudgetExceeded if the budget has been exceeded.
+func (m *Meter) Err() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.err
+}
+
+// Exceeded returns a channel which is closed when the budget is exceeded.
+func (m *Meter) Exceeded() <-chan struct{} {
+	return m.exceeded
+}
+
+type meterKey struct{}
+
+// ContextWithMeter returns a copy of ctx which carries m.
+func ContextWithMeter(ctx context.Context, m *Meter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+// MeterFromContext returns the Meter carried by ctx or nil.
+func MeterFromContext(ctx context.Context) *Meter {
+	m, _ := ctx.Value(meterKey{}).(*Meter)
+	return m
+}
+
+// RecordUsage records u in the Meter carried by ctx.
+// Ops use it to report their usage during a Run.
+// It returns *ErrBudgetExceeded if the Run budget is exceeded.
+// If ctx carries no Meter, the usage is discarded.
+func RecordUsage(ctx context.Context, u Usage) error {
+	if m := MeterFromContext(ctx); m != nil {
+		return m.Record(u)
+	}
+	return nil
+}
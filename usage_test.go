@@ -0,0 +1,80 @@
+package hypher
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUsageAdd(t *testing.T) {
+	u1 := Usage{TokensIn: 1, TokensOut: 2, Cost: 0.5, Counters: map[string]float64{"calls": 1}}
+	u2 := Usage{TokensIn: 3, TokensOut: 4, Cost: 0.25, Counters: map[string]float64{"calls": 2, "hits": 1}}
+
+	sum := u1.Add(u2)
+	exp := Usage{TokensIn: 4, TokensOut: 6, Cost: 0.75, Counters: map[string]float64{"calls": 3, "hits": 1}}
+	if !reflect.DeepEqual(sum, exp) {
+		t.Errorf("expected usage: %#v, got: %#v", exp, sum)
+	}
+
+	if u1.Counters["calls"] != 1 {
+		t.Error("Add must not modify its receiver")
+	}
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	testCases := []struct {
+		name     string
+		budget   Budget
+		usage    Usage
+		exceeded bool
+	}{
+		{"Unlimited", Budget{}, Usage{TokensIn: 100, Cost: 100}, false},
+		{"Tokens", Budget{MaxTokens: 10}, Usage{TokensIn: 6, TokensOut: 5}, true},
+		{"TokensWithin", Budget{MaxTokens: 10}, Usage{TokensIn: 5, TokensOut: 5}, false},
+		{"Cost", Budget{MaxCost: 1}, Usage{Cost: 1.5}, true},
+		{"Counters", Budget{MaxCounters: map[string]float64{"calls": 2}}, Usage{Counters: map[string]float64{"calls": 3}}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if exceeded := tc.budget.Exceeded(tc.usage); exceeded != tc.exceeded {
+				t.Errorf("expected exceeded: %v, got: %v", tc.exceeded, exceeded)
+			}
+		})
+	}
+}
+
+func TestMeter(t *testing.T) {
+	m := NewMeter(Budget{MaxTokens: 10})
+	sub := m.Sub()
+	ctx := ContextWithMeter(context.Background(), sub)
+
+	if err := RecordUsage(ctx, Usage{TokensIn: 5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sub.Usage().TokensIn != 5 || m.Usage().TokensIn != 5 {
+		t.Errorf("expected usage to be recorded in meter and its parent")
+	}
+
+	err := RecordUsage(ctx, Usage{TokensOut: 6})
+	var berr *ErrBudgetExceeded
+	if !errors.As(err, &berr) {
+		t.Fatalf("expected budget exceeded error, got: %v", err)
+	}
+
+	select {
+	case <-m.Exceeded():
+	default:
+		t.Error("expected exceeded channel to be closed")
+	}
+
+	if m.Err() == nil || sub.Err() != nil {
+		t.Error("expected only the parent meter to be exceeded")
+	}
+
+	if err := RecordUsage(context.Background(), Usage{TokensIn: 100}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}